@@ -0,0 +1,56 @@
+// Command calibrate-items estimates IRT item parameters for a test from its completed
+// results and stores them for ability scoring.
+//
+//	go run ./cmd/calibrate-items -test <id>                 # 2PL
+//	go run ./cmd/calibrate-items -test <id> -model 3PL -min 200
+//
+// Calibration reads every result of the test and can take a while on large tests, so
+// run it off-peak, e.g. nightly after a tryout closes.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/config"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/psychometrics"
+	"github.com/google/uuid"
+)
+
+func main() {
+	testID := flag.String("test", "", "test ID to calibrate")
+	irtModel := flag.String("model", psychometrics.Model2PL, "IRT model: 1PL, 2PL or 3PL")
+	minRespondents := flag.Int("min", 0, "minimum respondents per question (default 100)")
+	flag.Parse()
+
+	id, err := uuid.Parse(*testID)
+	if err != nil {
+		log.Fatal("Invalid test ID:", err)
+	}
+
+	db := config.InitDatabase()
+	irtService := service.NewIRTService(
+		repository.NewTestRepository(db),
+		repository.NewQuestionRepository(db),
+		repository.NewTestResultRepository(db),
+		repository.NewAttemptRepository(db),
+		repository.NewItemParameterRepository(db),
+	)
+
+	report, err := irtService.CalibrateTest(id, *irtModel, *minRespondents)
+	if err != nil {
+		log.Fatal("Calibration failed:", err)
+	}
+	for _, item := range report.Items {
+		log.Printf("%s a=%.3f b=%.3f c=%.3f (n=%d)", item.QuestionID, item.A, item.B, item.C, item.Respondents)
+	}
+	for _, item := range report.Skipped {
+		log.Printf("%s skipped: %s (n=%d)", item.QuestionID, item.Reason, item.Respondents)
+	}
+	if !report.Converged {
+		log.Printf("Warning: calibration stopped after %d cycles without converging.", report.Cycles)
+	}
+	log.Printf("Calibrated %d questions of test %s (%s) from %d results.", len(report.Items), id, report.Model, report.Respondents)
+}
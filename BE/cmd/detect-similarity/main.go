@@ -0,0 +1,96 @@
+// Command detect-similarity compares the answers of students who took a test in the
+// same window and stores the pairs with suspiciously many identical wrong answers
+// as a similarity run, which admins can read and export from the integrity API.
+//
+//	go run ./cmd/detect-similarity -event <id>
+//	go run ./cmd/detect-similarity -test <id> -from 2026-05-01T08:00:00Z -to 2026-05-01T12:00:00Z
+//	go run ./cmd/detect-similarity -test <id> -alpha 0.0001 -csv pairs.csv
+//
+// Every pair of results is compared, so the run grows with the square of the number
+// of students; run it after the window closes rather than during it.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/config"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/google/uuid"
+)
+
+func main() {
+	testID := flag.String("test", "", "compare results of this test ID")
+	eventID := flag.String("event", "", "compare results of this tryout event ID")
+	from := flag.String("from", "", "only results completed at or after this RFC 3339 time")
+	to := flag.String("to", "", "only results completed at or before this RFC 3339 time")
+	alpha := flag.Float64("alpha", 0.001, "report pairs with a p-value below this")
+	csvPath := flag.String("csv", "", "also write the pairs to this CSV file")
+	flag.Parse()
+
+	req := &service.SimilarityRequest{Alpha: *alpha}
+	switch {
+	case *eventID != "":
+		id, err := uuid.Parse(*eventID)
+		if err != nil {
+			log.Fatal("Invalid event ID:", err)
+		}
+		req.EventID = &id
+	case *testID != "":
+		id, err := uuid.Parse(*testID)
+		if err != nil {
+			log.Fatal("Invalid test ID:", err)
+		}
+		req.TestID = id
+	default:
+		log.Fatal("Pass -test or -event.")
+	}
+	req.From = parseTime("from", *from)
+	req.To = parseTime("to", *to)
+
+	db := config.InitDatabase()
+	similarityService := service.NewSimilarityService(
+		repository.NewTestRepository(db),
+		repository.NewQuestionRepository(db),
+		repository.NewTestResultRepository(db),
+		repository.NewAttemptRepository(db),
+		repository.NewEventRepository(db),
+		repository.NewIntegrityRepository(db),
+	)
+
+	run, err := similarityService.DetectSimilarity(req)
+	if err != nil {
+		log.Fatal("Similarity check failed:", err)
+	}
+	log.Printf("Run %s: %d results, %d questions, %d suspicious pairs (alpha %g).", run.ID, run.Results, run.Questions, run.PairCount, run.Alpha)
+
+	if *csvPath != "" {
+		// Reload so the export carries the student names.
+		stored, err := similarityService.GetSimilarityRun(run.ID.String())
+		if err != nil {
+			log.Fatal("Could not load run:", err)
+		}
+		data, err := similarityService.ExportCSV(stored)
+		if err != nil {
+			log.Fatal("Could not export run:", err)
+		}
+		if err := os.WriteFile(*csvPath, data, 0o644); err != nil {
+			log.Fatal("Could not write CSV:", err)
+		}
+		log.Printf("Pairs written to %s.", *csvPath)
+	}
+}
+
+func parseTime(name, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Fatalf("Invalid -%s time: %v", name, err)
+	}
+	return &t
+}
@@ -2,6 +2,8 @@ package main
 
 import (
     "log"
+    "os"
+    "time"
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/config"
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/handler"
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
@@ -23,6 +25,38 @@ func main() {
         &model.TestResult{},
         &model.PremiumClass{},
         &model.Order{},
+        &model.EssayAnswer{},
+        &model.Notification{},
+        &model.TestSection{},
+        &model.SectionScore{},
+        &model.Attempt{},
+        &model.AttemptDraft{},
+        &model.BankItem{},
+        &model.BankItemVersion{},
+        &model.TestBankItem{},
+        &model.TryoutEvent{},
+        &model.EventRegistration{},
+        &model.TopicMastery{},
+        &model.TopicSnapshot{},
+        &model.ItemParameter{},
+        &model.ItemExposure{},
+        &model.AttemptGrant{},
+        &model.AttemptEvent{},
+        &model.IntegrityReport{},
+        &model.SimilarityRun{},
+        &model.SimilarityPair{},
+        &model.Certificate{},
+        &model.Organization{},
+        &model.OrganizationMember{},
+        &model.OrganizationLicense{},
+        &model.Group{},
+        &model.GroupMember{},
+        &model.Assignment{},
+        &model.ClassModule{},
+        &model.Lesson{},
+        &model.ClassEnrollment{},
+        &model.LessonProgress{},
+        &model.CourseCertificate{},
     )
     if err != nil {
         log.Fatal("Failed to migrate database:", err)
@@ -35,36 +69,100 @@ func main() {
     testResultRepo := repository.NewTestResultRepository(db)
     premiumClassRepo := repository.NewPremiumClassRepository(db)
     orderRepo := repository.NewOrderRepository(db)
+    essayAnswerRepo := repository.NewEssayAnswerRepository(db)
+    notificationRepo := repository.NewNotificationRepository(db)
+    testSectionRepo := repository.NewTestSectionRepository(db)
+    attemptRepo := repository.NewAttemptRepository(db)
+    bankRepo := repository.NewBankRepository(db)
+    eventRepo := repository.NewEventRepository(db)
+    integrityRepo := repository.NewIntegrityRepository(db)
+    certificateRepo := repository.NewCertificateRepository(db)
+    orgRepo := repository.NewOrganizationRepository(db)
+    classContentRepo := repository.NewClassContentRepository(db)
+    analyticsRepo := repository.NewAnalyticsRepository(db)
+    itemParamRepo := repository.NewItemParameterRepository(db)
+    attemptGrantRepo := repository.NewAttemptGrantRepository(db)
 
     // Service
-    authService := service.NewAuthService(userRepo, redisClient)
-    testService := service.NewTestService(testRepo)
-    questionService := service.NewQuestionService(questionRepo)
-    testResultService := service.NewTestResultService(testResultRepo, questionRepo)
+    authService := service.NewAuthService(userRepo, orgRepo, redisClient)
+    testService := service.NewTestService(testRepo, questionRepo, eventRepo)
+    questionService := service.NewQuestionService(questionRepo, testRepo, testSectionRepo, eventRepo)
+    notificationService := service.NewNotificationService(notificationRepo)
+    attemptPolicyService := service.NewAttemptPolicyService(testRepo, attemptRepo, testResultRepo, attemptGrantRepo, userRepo, redisClient)
+    testResultService := service.NewTestResultService(testResultRepo, testRepo, questionRepo, essayAnswerRepo, attemptRepo, itemParamRepo, eventRepo, attemptPolicyService, notificationService)
+    gradingService := service.NewGradingService(essayAnswerRepo, testResultRepo, testResultService)
+    testSectionService := service.NewTestSectionService(testSectionRepo, testRepo)
+    testPackageService := service.NewTestPackageService(testRepo, questionRepo)
+    attemptService := service.NewAttemptService(attemptRepo, testRepo, questionRepo, itemParamRepo, eventRepo, testResultService, attemptPolicyService, redisClient)
+    attemptService.StartDraftFlusher(5 * time.Second)
+    bankService := service.NewBankService(bankRepo, testRepo, testSectionRepo)
+    eventService := service.NewEventService(eventRepo, testRepo, attemptRepo, testResultRepo, attemptService, redisClient)
+    testResultService.AddListener(eventService)
+    leaderboardService := service.NewLeaderboardService(testResultRepo, testRepo, redisClient)
+    testResultService.AddListener(leaderboardService)
+    analyticsService := service.NewAnalyticsService(analyticsRepo, questionRepo, testRepo, testResultRepo, testResultService)
+    testResultService.AddListener(analyticsService)
+    integrityService := service.NewIntegrityService(integrityRepo, attemptRepo, testResultRepo, testResultService)
+    testResultService.AddListener(integrityService)
+    // Certificates get their own key, so rotating the JWT secret does not void
+    // every certificate issued so far.
+    certificateSecret := os.Getenv("CERTIFICATE_SECRET_KEY")
+    if certificateSecret == "" {
+        log.Fatal("CERTIFICATE_SECRET_KEY is not set")
+    }
+    certificateService := service.NewCertificateService(certificateRepo, testResultRepo, userRepo, certificateSecret)
+    testResultService.AddListener(certificateService)
+    reportService := service.NewReportService(testResultService, leaderboardService, userRepo)
+    itemAnalysisService := service.NewItemAnalysisService(testRepo, questionRepo, testResultRepo, attemptRepo)
+    irtService := service.NewIRTService(testRepo, questionRepo, testResultRepo, attemptRepo, itemParamRepo)
+    similarityService := service.NewSimilarityService(testRepo, questionRepo, testResultRepo, attemptRepo, eventRepo, integrityRepo)
     premiumClassService := service.NewPremiumClassService(premiumClassRepo)
-    orderService := service.NewOrderService(orderRepo, userRepo)
+    classContentService := service.NewClassContentService(classContentRepo, premiumClassRepo, testRepo, orderRepo, userRepo, orgRepo, testResultRepo, certificateService)
+    testResultService.AddListener(classContentService)
+    orderService := service.NewOrderService(orderRepo, userRepo, orgRepo, classContentRepo)
+    organizationService := service.NewOrganizationService(orgRepo, userRepo, testRepo, notificationService)
+    organizationService.StartReminderJob(time.Minute)
 
     // Handler
     authHandler := handler.NewAuthHandler(authService)
     testHandler := handler.NewTestHandler(testService)
     questionHandler := handler.NewQuestionHandler(questionService)
-    testResultHandler := handler.NewTestResultHandler(testResultService)
-    premiumClassHandler := handler.NewPremiumClassHandler(premiumClassService)
+    testResultHandler := handler.NewTestResultHandler(testResultService, leaderboardService)
+    premiumClassHandler := handler.NewPremiumClassHandler(premiumClassService, classContentService)
+    classContentHandler := handler.NewClassContentHandler(classContentService)
     orderHandler := handler.NewOrderHandler(orderService)
+    gradingHandler := handler.NewGradingHandler(gradingService)
+    notificationHandler := handler.NewNotificationHandler(notificationService)
+    testSectionHandler := handler.NewTestSectionHandler(testSectionService)
+    testPackageHandler := handler.NewTestPackageHandler(testPackageService)
+    attemptHandler := handler.NewAttemptHandler(attemptService)
+    bankHandler := handler.NewBankHandler(bankService)
+    eventHandler := handler.NewEventHandler(eventService)
+    analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+    itemAnalysisHandler := handler.NewItemAnalysisHandler(itemAnalysisService)
+    irtHandler := handler.NewIRTHandler(irtService)
+    attemptPolicyHandler := handler.NewAttemptPolicyHandler(attemptPolicyService)
+    integrityHandler := handler.NewIntegrityHandler(integrityService)
+    similarityHandler := handler.NewSimilarityHandler(similarityService)
+    certificateHandler := handler.NewCertificateHandler(reportService, certificateService)
+    organizationHandler := handler.NewOrganizationHandler(organizationService)
 
     // App setup
-    app := fiber.New()
+    // Test packages carry their images, so allow bodies above the 4 MB default.
+    app := fiber.New(fiber.Config{BodyLimit: 32 * 1024 * 1024})
     app.Use(logger.New())
 
     app.Use(cors.New(cors.Config{
         AllowOrigins:     "http://localhost:5173",
         AllowCredentials: true,
-        AllowHeaders:     "Origin, Content-Type, Accept",
+        AllowHeaders:     "Origin, Content-Type, Accept, Idempotency-Key",
+        ExposeHeaders:    "Idempotent-Replayed",
     }))
 
     app.Static("/uploads", "./public/uploads")
 
     api := app.Group("/api")
+    idempotent := handler.IdempotencyMiddleware(redisClient)
 
     // AUTH
     auth := api.Group("/auth")
@@ -82,17 +180,41 @@ func main() {
     // TESTS
     tests := api.Group("/tests")
     tests.Get("/", testHandler.GetAllTests)
-    tests.Get("/:id", testHandler.GetTestByID)
+    tests.Get("/manage", handler.AuthMiddleware(), handler.AdminMiddleware(), testHandler.GetManagedTests)
+    tests.Get("/:id", handler.AuthMiddleware(), testHandler.GetTestByID)
+    tests.Get("/:id/sections", testSectionHandler.GetSectionsByTestID)
+    tests.Get("/:id/leaderboard", testResultHandler.GetLeaderboard)
+    tests.Get("/:id/allowance", handler.AuthMiddleware(), attemptPolicyHandler.GetAllowance)
 
     adminTests := tests.Use(handler.AuthMiddleware(), handler.AdminMiddleware())
     adminTests.Post("/", testHandler.CreateTest)
+    adminTests.Post("/import", testPackageHandler.ImportTest)
+    adminTests.Get("/:id/export", testPackageHandler.ExportTest)
     adminTests.Put("/:id", testHandler.UpdateTest)
     adminTests.Delete("/:id", testHandler.DeleteTest)
+    adminTests.Get("/:id/checklist", testHandler.GetPublishChecklist)
+    adminTests.Put("/:id/status", testHandler.ChangeStatus)
+    adminTests.Get("/:id/item-analysis", itemAnalysisHandler.GetItemAnalysis)
+    adminTests.Get("/:id/item-parameters", irtHandler.GetItemParameters)
+    adminTests.Get("/:id/similarity-runs", similarityHandler.GetRuns)
+    adminTests.Get("/:id/attempt-grants", attemptPolicyHandler.GetGrants)
+    adminTests.Post("/:id/attempt-grants", attemptPolicyHandler.GrantAttempts)
+    adminTests.Post("/:id/sections", testSectionHandler.CreateSection)
+    adminTests.Post("/:id/questions/import", questionHandler.ImportQuestions)
+    adminTests.Get("/:id/bank-items", bankHandler.GetTestLinks)
+    adminTests.Post("/:id/bank-items", bankHandler.LinkItem)
+    adminTests.Put("/:id/bank-items/:itemId", bankHandler.UpdateLink)
+    adminTests.Delete("/:id/bank-items/:itemId", bankHandler.UnlinkItem)
+
+    // TEST SECTIONS
+    adminSections := api.Group("/sections", handler.AuthMiddleware(), handler.AdminMiddleware())
+    adminSections.Put("/:id", testSectionHandler.UpdateSection)
+    adminSections.Delete("/:id", testSectionHandler.DeleteSection)
 
     // QUESTIONS
     questions := api.Group("/questions")
-    questions.Get("/test/:testId", questionHandler.GetQuestionsByTestID)
-    questions.Get("/:id", handler.AuthMiddleware(), questionHandler.GetQuestionByID)
+    questions.Get("/test/:testId", handler.AuthMiddleware(), questionHandler.GetQuestionsByTestID)
+    questions.Get("/:id", handler.AuthMiddleware(), handler.AdminMiddleware(), questionHandler.GetQuestionByID)
 
     adminQuestions := questions.Use(handler.AuthMiddleware(), handler.AdminMiddleware())
     adminQuestions.Post("/", questionHandler.CreateQuestion)
@@ -101,8 +223,92 @@ func main() {
 
     // TEST RESULTS
     results := api.Group("/test-results", handler.AuthMiddleware())
-    results.Post("/", testResultHandler.SubmitTest)
+    results.Post("/", idempotent, testResultHandler.SubmitTest)
     results.Get("/user/:userId", testResultHandler.GetResultsByUserID)
+    results.Get("/:id", testResultHandler.GetResultReview)
+    results.Get("/:id/report.pdf", certificateHandler.GetReportPDF)
+    results.Get("/:id/certificate.pdf", certificateHandler.GetCertificatePDF)
+    results.Post("/:id/invalidate", handler.AdminMiddleware(), testResultHandler.InvalidateResult)
+
+    // CERTIFICATES
+    api.Get("/certificates/:serial/verify", certificateHandler.VerifyCertificate)
+
+    // ANALYTICS
+    analytics := api.Group("/analytics", handler.AuthMiddleware())
+    analytics.Get("/me", analyticsHandler.GetMyAnalytics)
+
+    // QUESTION BANK
+    bank := api.Group("/bank", handler.AuthMiddleware(), handler.AdminMiddleware())
+    bank.Get("/items", bankHandler.GetItems)
+    bank.Post("/items", bankHandler.CreateItem)
+    bank.Get("/items/:id", bankHandler.GetItemByID)
+    bank.Put("/items/:id", bankHandler.UpdateItem)
+    bank.Delete("/items/:id", bankHandler.DeleteItem)
+
+    // ATTEMPTS
+    attempts := api.Group("/attempts", handler.AuthMiddleware())
+    attempts.Post("/", idempotent, attemptHandler.StartAttempt)
+    attempts.Get("/:id", attemptHandler.GetAttempt)
+    attempts.Put("/:id/answers", attemptHandler.SaveAnswers)
+    attempts.Post("/:id/sections/submit", idempotent, attemptHandler.SubmitSection)
+    attempts.Get("/:id/next", attemptHandler.NextQuestion)
+    attempts.Post("/:id/answer", idempotent, attemptHandler.AnswerQuestion)
+    attempts.Post("/:id/events", integrityHandler.RecordEvents)
+
+    // INTEGRITY REVIEW
+    integrity := api.Group("/integrity", handler.AuthMiddleware(), handler.AdminMiddleware())
+    integrity.Get("/reviews", integrityHandler.GetReviewQueue)
+    integrity.Get("/reviews/:attemptId", integrityHandler.GetReview)
+    integrity.Post("/reviews/:attemptId/decision", integrityHandler.Decide)
+    integrity.Get("/similarity-runs/:id", similarityHandler.GetRun)
+
+    // ORGANIZATIONS
+    organizations := api.Group("/organizations", handler.AuthMiddleware())
+    organizations.Post("/", handler.AdminMiddleware(), organizationHandler.CreateOrganization)
+    organizations.Get("/", handler.AdminMiddleware(), organizationHandler.GetAllOrganizations)
+    organizations.Get("/mine", organizationHandler.GetMyOrganizations)
+    organizations.Get("/:orgId", organizationHandler.GetOrganization)
+    organizations.Get("/:orgId/members", organizationHandler.GetMembers)
+    organizations.Post("/:orgId/members", organizationHandler.AddMember)
+    organizations.Delete("/:orgId/members/:userId", organizationHandler.RemoveMember)
+    organizations.Put("/:orgId/members/:userId/seat", organizationHandler.SetSeat)
+    organizations.Get("/:orgId/groups", organizationHandler.GetGroups)
+    organizations.Post("/:orgId/groups", organizationHandler.CreateGroup)
+    organizations.Get("/:orgId/groups/:groupId/students", organizationHandler.GetGroupMembers)
+    organizations.Post("/:orgId/groups/:groupId/students", organizationHandler.AddGroupMembers)
+    organizations.Delete("/:orgId/groups/:groupId/students/:userId", organizationHandler.RemoveGroupMember)
+    organizations.Get("/:orgId/groups/:groupId/assignments", organizationHandler.GetAssignments)
+    organizations.Post("/:orgId/groups/:groupId/assignments", organizationHandler.CreateAssignment)
+    organizations.Get("/:orgId/groups/:groupId/assignments/:assignmentId/progress", organizationHandler.GetAssignmentProgress)
+    organizations.Get("/:orgId/results", organizationHandler.GetStudentResults)
+
+    // ASSIGNMENTS
+    api.Get("/assignments/mine", handler.AuthMiddleware(), organizationHandler.GetMyAssignments)
+
+    // TRYOUT EVENTS
+    events := api.Group("/events")
+    events.Get("/", eventHandler.GetUpcomingEvents)
+    events.Get("/manage", handler.AuthMiddleware(), handler.AdminMiddleware(), eventHandler.GetAllEvents)
+    events.Get("/:id", eventHandler.GetEvent)
+    events.Get("/:id/countdown", eventHandler.StreamCountdown)
+    events.Get("/:id/ranking", eventHandler.GetRanking)
+    events.Post("/:id/register", handler.AuthMiddleware(), eventHandler.Register)
+    events.Post("/:id/join", handler.AuthMiddleware(), eventHandler.JoinEvent)
+
+    adminEvents := events.Use(handler.AuthMiddleware(), handler.AdminMiddleware())
+    adminEvents.Post("/", eventHandler.CreateEvent)
+    adminEvents.Put("/:id", eventHandler.UpdateEvent)
+    adminEvents.Delete("/:id", eventHandler.DeleteEvent)
+
+    // ESSAY GRADING
+    grading := api.Group("/grading", handler.AuthMiddleware(), handler.GraderMiddleware())
+    grading.Get("/pending", gradingHandler.GetPendingAnswers)
+    grading.Put("/answers/:id", gradingHandler.GradeAnswer)
+
+    // NOTIFICATIONS
+    notifications := api.Group("/notifications", handler.AuthMiddleware())
+    notifications.Get("/", notificationHandler.GetMyNotifications)
+    notifications.Put("/:id/read", notificationHandler.MarkAsRead)
 
     // FILE UPLOAD
     api.Post("/upload", handler.AuthMiddleware(), handler.UploadFile)
@@ -110,19 +316,34 @@ func main() {
     // PREMIUM CLASSES
     classes := api.Group("/premium-classes")
     classes.Get("/", premiumClassHandler.GetAllClasses)
-    classes.Get("/:id", premiumClassHandler.GetClassByID)
+    classes.Get("/enrolled", handler.AuthMiddleware(), classContentHandler.GetMyEnrollments)
+    classes.Get("/:id", handler.OptionalAuthMiddleware(), premiumClassHandler.GetClassByID)
+    classes.Get("/:id/modules", classContentHandler.GetOutline)
+    classes.Post("/:id/enroll", handler.AuthMiddleware(), classContentHandler.Enroll)
+    classes.Get("/:id/lessons/:lessonId", handler.AuthMiddleware(), classContentHandler.GetLesson)
+    classes.Put("/:id/lessons/:lessonId/progress", handler.AuthMiddleware(), classContentHandler.UpdateProgress)
+    classes.Post("/:id/lessons/:lessonId/complete", handler.AuthMiddleware(), classContentHandler.CompleteLesson)
+    classes.Get("/:id/progress", handler.AuthMiddleware(), classContentHandler.GetClassProgress)
+    classes.Get("/:id/certificate.pdf", handler.AuthMiddleware(), classContentHandler.GetCourseCertificatePDF)
 
     adminClasses := classes.Use(handler.AuthMiddleware(), handler.AdminMiddleware())
     adminClasses.Post("/", premiumClassHandler.CreateClass)
     adminClasses.Put("/:id", premiumClassHandler.UpdateClass)
     adminClasses.Delete("/:id", premiumClassHandler.DeleteClass)
+    adminClasses.Get("/:id/enrollments", classContentHandler.GetEnrollments)
+    adminClasses.Post("/:id/modules", classContentHandler.CreateModule)
+    adminClasses.Put("/:id/modules/:moduleId", classContentHandler.UpdateModule)
+    adminClasses.Delete("/:id/modules/:moduleId", classContentHandler.DeleteModule)
+    adminClasses.Post("/:id/modules/:moduleId/lessons", classContentHandler.CreateLesson)
+    adminClasses.Put("/:id/lessons/:lessonId", classContentHandler.UpdateLesson)
+    adminClasses.Delete("/:id/lessons/:lessonId", classContentHandler.DeleteLesson)
 
     // ORDERS
     orders := api.Group("/orders", handler.AuthMiddleware())
-    orders.Post("/", orderHandler.CreateOrder)
+    orders.Post("/", idempotent, orderHandler.CreateOrder)
     orders.Get("/user/:userId", orderHandler.GetOrdersByUserID)
-    orders.Put("/:id/payment-proof", orderHandler.UploadPaymentProof)
-    orders.Put("/:id/verify", handler.AdminMiddleware(), orderHandler.VerifyOrder)
+    orders.Put("/:id/payment-proof", idempotent, orderHandler.UploadPaymentProof)
+    orders.Put("/:id/verify", handler.AdminMiddleware(), idempotent, orderHandler.VerifyOrder)
     orders.Get("/", handler.AdminMiddleware(), orderHandler.GetAllOrders)
 
     log.Fatal(app.Listen(":3000"))
@@ -0,0 +1,54 @@
+// Command rebuild-leaderboard refills the Redis leaderboards from the completed test
+// results in MySQL, e.g. after Redis data was lost or the ranking rules changed. It
+// also re-marks each student's official result, so run it after changing a test's
+// official result policy.
+//
+//	go run ./cmd/rebuild-leaderboard            # every test
+//	go run ./cmd/rebuild-leaderboard -test <id> # one test
+//
+// Results finalized while a test is being rebuilt may be missed; run it when the
+// site is quiet or run it again afterwards.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/config"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/google/uuid"
+)
+
+func main() {
+	testID := flag.String("test", "", "rebuild only this test ID")
+	flag.Parse()
+
+	db := config.InitDatabase()
+	redisClient := config.InitRedis()
+
+	leaderboardService := service.NewLeaderboardService(
+		repository.NewTestResultRepository(db),
+		repository.NewTestRepository(db),
+		redisClient,
+	)
+
+	if *testID != "" {
+		id, err := uuid.Parse(*testID)
+		if err != nil {
+			log.Fatal("Invalid test ID:", err)
+		}
+		students, err := leaderboardService.Rebuild(id)
+		if err != nil {
+			log.Fatal("Failed to rebuild leaderboard:", err)
+		}
+		log.Printf("Leaderboard for test %s rebuilt with %d students.", id, students)
+		return
+	}
+
+	tests, err := leaderboardService.RebuildAll()
+	if err != nil {
+		log.Fatal("Failed to rebuild leaderboards:", err)
+	}
+	log.Printf("Rebuilt leaderboards for %d tests.", tests)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AnalyticsHandler struct {
+	service service.AnalyticsService
+}
+
+func NewAnalyticsHandler(service service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service}
+}
+
+// GetMyAnalytics returns the logged-in student's topic mastery, weak topics and
+// suggested practice tests.
+func (h *AnalyticsHandler) GetMyAnalytics(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	analytics, err := h.service.GetStudentAnalytics(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve analytics"})
+	}
+	return c.JSON(analytics)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttemptHandler struct {
+	service  service.AttemptService
+	validate *validator.Validate
+}
+
+func NewAttemptHandler(service service.AttemptService) *AttemptHandler {
+	return &AttemptHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *AttemptHandler) StartAttempt(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.StartAttemptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	view, err := h.service.StartAttempt(userID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+func (h *AttemptHandler) GetAttempt(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	view, err := h.service.GetAttempt(userID, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+func (h *AttemptHandler) SubmitSection(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.SubmitSectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	view, err := h.service.SubmitSection(userID, c.Params("id"), &req)
+	if err != nil {
+		if err.Error() == "attempt not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+// NextQuestion returns the open question of an adaptive attempt, serving a new one
+// if the last was answered. The result is included once the test has ended.
+func (h *AttemptHandler) NextQuestion(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	view, err := h.service.NextQuestion(userID, c.Params("id"))
+	if err != nil {
+		if err.Error() == "attempt not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+func (h *AttemptHandler) AnswerQuestion(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.AnswerQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	view, err := h.service.AnswerQuestion(userID, c.Params("id"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "attempt not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "attempt is not adaptive":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+// SaveAnswers autosaves answers and review flags of the open section.
+func (h *AttemptHandler) SaveAnswers(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.SaveAnswersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	saved, err := h.service.SaveAnswers(userID, c.Params("id"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "attempt not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "question is not in the open section", "adaptive attempts are answered one question at a time":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case "section time limit has passed":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not save answers"})
+	}
+	return c.JSON(saved)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttemptPolicyHandler struct {
+	service  service.AttemptPolicyService
+	validate *validator.Validate
+}
+
+func NewAttemptPolicyHandler(service service.AttemptPolicyService) *AttemptPolicyHandler {
+	return &AttemptPolicyHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// GetAllowance shows the logged-in student how many attempts they have left.
+func (h *AttemptPolicyHandler) GetAllowance(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	allowance, err := h.service.GetAllowance(userID, c.Params("id"))
+	if err != nil {
+		if err.Error() == "test not found" || err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve attempts"})
+	}
+	return c.JSON(allowance)
+}
+
+func (h *AttemptPolicyHandler) GrantAttempts(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("userID").(string)
+
+	var req service.GrantAttemptsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	grant, err := h.service.GrantAttempts(c.Params("id"), adminID, &req)
+	if err != nil {
+		switch err.Error() {
+		case "test not found", "invalid test id format", "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not grant attempts"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(grant)
+}
+
+func (h *AttemptPolicyHandler) GetGrants(c *fiber.Ctx) error {
+	grants, err := h.service.GetGrants(c.Params("id"))
+	if err != nil {
+		if err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve grants"})
+	}
+	return c.JSON(grants)
+}
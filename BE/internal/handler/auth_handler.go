@@ -158,7 +158,7 @@ func (h *AuthHandler) GetAllUsers(c *fiber.Ctx) error {
 }
 // --- FUNGSI BARU UNTUK UPDATE USER ---
 type UpdateUserRequest struct {
-	Role      string `json:"role" validate:"required,oneof=admin user"`
+	Role      string `json:"role" validate:"required,oneof=admin user grader"`
 	IsPremium bool   `json:"is_premium" validate:"boolean"`
 }
 
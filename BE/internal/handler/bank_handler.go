@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BankHandler struct {
+	service  service.BankService
+	validate *validator.Validate
+}
+
+func NewBankHandler(service service.BankService) *BankHandler {
+	return &BankHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *BankHandler) CreateItem(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.BankItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	item, err := h.service.CreateItem(userID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+func (h *BankHandler) GetItems(c *fiber.Ctx) error {
+	filter := repository.BankItemFilter{
+		Topic:      c.Query("topic"),
+		Tag:        c.Query("tag"),
+		Difficulty: c.Query("difficulty"),
+		Search:     c.Query("q"),
+	}
+	if author := c.Query("author_id"); author != "" {
+		authorUUID, err := uuid.Parse(author)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid author_id format"})
+		}
+		filter.AuthorID = &authorUUID
+	}
+
+	items, err := h.service.GetItems(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve bank items"})
+	}
+	return c.JSON(items)
+}
+
+func (h *BankHandler) GetItemByID(c *fiber.Ctx) error {
+	item, err := h.service.GetItemByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(item)
+}
+
+func (h *BankHandler) UpdateItem(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.BankItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	item, err := h.service.UpdateItem(userID, c.Params("id"), &req)
+	if err != nil {
+		if err.Error() == "bank item not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(item)
+}
+
+func (h *BankHandler) DeleteItem(c *fiber.Ctx) error {
+	if err := h.service.DeleteItem(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *BankHandler) GetTestLinks(c *fiber.Ctx) error {
+	links, err := h.service.GetTestLinks(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(links)
+}
+
+func (h *BankHandler) LinkItem(c *fiber.Ctx) error {
+	var req service.BankLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	link, err := h.service.LinkItem(c.Params("id"), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+func (h *BankHandler) UpdateLink(c *fiber.Ctx) error {
+	var req service.BankLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.BankItemID = c.Params("itemId")
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	link, err := h.service.UpdateLink(c.Params("id"), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(link)
+}
+
+func (h *BankHandler) UnlinkItem(c *fiber.Ctx) error {
+	if err := h.service.UnlinkItem(c.Params("id"), c.Params("itemId")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CertificateHandler struct {
+	reportService      service.ReportService
+	certificateService service.CertificateService
+}
+
+func NewCertificateHandler(reportService service.ReportService, certificateService service.CertificateService) *CertificateHandler {
+	return &CertificateHandler{reportService, certificateService}
+}
+
+// GetReportPDF downloads the score report of a result. Only the student who took it
+// and admins can download it; anyone else gets the same 404 as for a missing result.
+func (h *CertificateHandler) GetReportPDF(c *fiber.Ctx) error {
+	loggedInUserID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	data, result, err := h.reportService.RenderResultReport(loggedInUserID, userRole, c.Params("id"))
+	if err != nil {
+		switch err.Error() {
+		case "test result not found", "invalid id format", "access denied":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test result not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not render report"})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="score-report-%s.pdf"`, result.ID))
+	return c.Send(data)
+}
+
+// GetCertificatePDF downloads the certificate of a passing official result, issuing
+// it on first download. Only the student who took it and admins can download it.
+func (h *CertificateHandler) GetCertificatePDF(c *fiber.Ctx) error {
+	loggedInUserID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	data, issued, err := h.certificateService.RenderCertificate(loggedInUserID, userRole, c.Params("id"))
+	if err != nil {
+		switch err.Error() {
+		case "test result not found", "invalid id format":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test result not found"})
+		case "certificates are only issued for passing official results", "certificate has been revoked":
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not render certificate"})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="certificate-%s.pdf"`, issued.Serial))
+	return c.Send(data)
+}
+
+// VerifyCertificate is public, so schools and employers can check a serial.
+func (h *CertificateHandler) VerifyCertificate(c *fiber.Ctx) error {
+	verification := h.certificateService.VerifyCertificate(c.Params("serial"))
+	if verification.Status == service.CertificateNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(verification)
+	}
+	return c.JSON(verification)
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ClassContentHandler struct {
+	service  service.ClassContentService
+	validate *validator.Validate
+}
+
+func NewClassContentHandler(service service.ClassContentService) *ClassContentHandler {
+	return &ClassContentHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// classContentError maps the class content service's errors to status codes.
+func classContentError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "class not found", "module not found", "lesson not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case "not enrolled in this class", "premium membership has expired", "premium membership or class purchase required":
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case "class not completed", "certificate has been revoked":
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case "practice test not found", "invalid user ID format":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// GetOutline is public: module and lesson titles, without the lesson content.
+func (h *ClassContentHandler) GetOutline(c *fiber.Ctx) error {
+	outline, err := h.service.GetOutline(c.Params("id"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(outline)
+}
+
+func (h *ClassContentHandler) GetLesson(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	lesson, err := h.service.GetLesson(userID, userRole, c.Params("id"), c.Params("lessonId"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(lesson)
+}
+
+func (h *ClassContentHandler) Enroll(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	enrollment, err := h.service.Enroll(userID, c.Params("id"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(enrollment)
+}
+
+func (h *ClassContentHandler) GetMyEnrollments(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	enrollments, err := h.service.GetMyEnrollments(userID)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(enrollments)
+}
+
+func (h *ClassContentHandler) GetEnrollments(c *fiber.Ctx) error {
+	enrollments, err := h.service.GetEnrollments(c.Params("id"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(enrollments)
+}
+
+func (h *ClassContentHandler) CreateModule(c *fiber.Ctx) error {
+	var req service.ModuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	module, err := h.service.CreateModule(c.Params("id"), &req)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(module)
+}
+
+func (h *ClassContentHandler) UpdateModule(c *fiber.Ctx) error {
+	var req service.ModuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	module, err := h.service.UpdateModule(c.Params("id"), c.Params("moduleId"), &req)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(module)
+}
+
+func (h *ClassContentHandler) DeleteModule(c *fiber.Ctx) error {
+	if err := h.service.DeleteModule(c.Params("id"), c.Params("moduleId")); err != nil {
+		return classContentError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ClassContentHandler) CreateLesson(c *fiber.Ctx) error {
+	var req service.LessonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	lesson, err := h.service.CreateLesson(c.Params("id"), c.Params("moduleId"), &req)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(lesson)
+}
+
+func (h *ClassContentHandler) UpdateLesson(c *fiber.Ctx) error {
+	var req service.LessonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	lesson, err := h.service.UpdateLesson(c.Params("id"), c.Params("lessonId"), &req)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(lesson)
+}
+
+func (h *ClassContentHandler) DeleteLesson(c *fiber.Ctx) error {
+	if err := h.service.DeleteLesson(c.Params("id"), c.Params("lessonId")); err != nil {
+		return classContentError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateProgress records the position reached in a lesson's video.
+func (h *ClassContentHandler) UpdateProgress(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	var req service.ProgressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	progress, err := h.service.UpdateProgress(userID, c.Params("id"), c.Params("lessonId"), &req)
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(progress)
+}
+
+func (h *ClassContentHandler) CompleteLesson(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	progress, err := h.service.CompleteLesson(userID, c.Params("id"), c.Params("lessonId"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(progress)
+}
+
+func (h *ClassContentHandler) GetClassProgress(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	progress, err := h.service.GetClassProgress(userID, c.Params("id"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+	return c.JSON(progress)
+}
+
+// GetCourseCertificatePDF downloads the certificate of a completed class.
+func (h *ClassContentHandler) GetCourseCertificatePDF(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	data, issued, err := h.service.RenderCourseCertificate(userID, c.Params("id"))
+	if err != nil {
+		return classContentError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="certificate-%s.pdf"`, issued.Serial))
+	return c.Send(data)
+}
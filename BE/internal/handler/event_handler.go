@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// countdownSignalWait is how long a stream waits for the shared start signal after
+// its own clock reaches the start before unlocking on its own.
+const countdownSignalWait = 3 * time.Second
+
+type EventHandler struct {
+	service  service.EventService
+	validate *validator.Validate
+}
+
+func NewEventHandler(service service.EventService) *EventHandler {
+	return &EventHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *EventHandler) CreateEvent(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("userID").(string)
+
+	var req service.EventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	event, err := h.service.CreateEvent(adminID, &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(event)
+}
+
+func (h *EventHandler) UpdateEvent(c *fiber.Ctx) error {
+	var req service.EventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	event, err := h.service.UpdateEvent(c.Params("id"), &req)
+	if err != nil {
+		if err.Error() == "event not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(event)
+}
+
+func (h *EventHandler) DeleteEvent(c *fiber.Ctx) error {
+	if err := h.service.DeleteEvent(c.Params("id")); err != nil {
+		if err.Error() == "event is running" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *EventHandler) GetUpcomingEvents(c *fiber.Ctx) error {
+	events, err := h.service.GetUpcomingEvents()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve events"})
+	}
+	return c.JSON(events)
+}
+
+func (h *EventHandler) GetAllEvents(c *fiber.Ctx) error {
+	events, err := h.service.GetAllEvents()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve events"})
+	}
+	return c.JSON(events)
+}
+
+func (h *EventHandler) GetEvent(c *fiber.Ctx) error {
+	view, err := h.service.GetEvent(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+func (h *EventHandler) Register(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	registration, err := h.service.Register(userID, c.Params("id"))
+	if err != nil {
+		if err.Error() == "event not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(registration)
+}
+
+// JoinEvent opens the paper once the event has started. Calling it again returns
+// the same attempt.
+func (h *EventHandler) JoinEvent(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	view, err := h.service.JoinEvent(userID, c.Params("id"))
+	if err != nil {
+		switch err.Error() {
+		case "event not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "not registered for this event":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case "event has not started", "late-join cutoff has passed":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(view)
+}
+
+func (h *EventHandler) GetRanking(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+	ranking, err := h.service.GetRanking(c.Params("id"), page, limit)
+	if err != nil {
+		if err.Error() == "event not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve ranking"})
+	}
+	return c.JSON(ranking)
+}
+
+// StreamCountdown is a server-sent event stream that sends a "countdown" frame every
+// second and a single "unlock" frame when the event starts, after which the client
+// calls JoinEvent. The unlock comes from the shared start signal so every instance
+// releases the paper at the same moment; a moved start time arrives as a new
+// countdown.
+func (h *EventHandler) StreamCountdown(c *fiber.Ctx) error {
+	view, err := h.service.GetEvent(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	event := view.Event
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		signals, unsubscribe := h.service.Subscribe(event.ID)
+		defer unsubscribe()
+
+		if view.Phase != model.EventPhaseScheduled {
+			writeSSE(w, "unlock", fiber.Map{"event_id": event.ID, "phase": view.Phase, "server_time": time.Now()})
+			return
+		}
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		signalled := false
+		for {
+			now := time.Now()
+			if !now.Before(event.StartsAt) {
+				if !signalled {
+					signalled = true
+					_ = h.service.SignalStart(event)
+				} else if now.Sub(event.StartsAt) > countdownSignalWait {
+					writeSSE(w, "unlock", fiber.Map{"event_id": event.ID, "phase": model.EventPhaseRunning, "server_time": now})
+					return
+				}
+			}
+
+			remaining := event.StartsAt.Sub(now)
+			if remaining < 0 {
+				remaining = 0
+			}
+			if err := writeSSE(w, "countdown", fiber.Map{
+				"event_id":          event.ID,
+				"starts_at":         event.StartsAt,
+				"server_time":       now,
+				"remaining_seconds": int(remaining.Round(time.Second).Seconds()),
+			}); err != nil {
+				return
+			}
+
+			select {
+			case signal := <-signals:
+				switch signal.Type {
+				case service.EventSignalStart:
+					writeSSE(w, "unlock", fiber.Map{"event_id": event.ID, "phase": model.EventPhaseRunning, "server_time": time.Now()})
+					return
+				case service.EventSignalSchedule:
+					event.EndsAt = event.EndsAt.Add(signal.StartsAt.Sub(event.StartsAt))
+					event.StartsAt = signal.StartsAt
+					signalled = false
+				}
+			case <-ticker.C:
+			}
+		}
+	}))
+	return nil
+}
+
+// writeSSE writes one server-sent event and flushes it; an error means the client
+// has gone away.
+func writeSSE(w *bufio.Writer, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GradingHandler struct {
+	service  service.GradingService
+	validate *validator.Validate
+}
+
+func NewGradingHandler(service service.GradingService) *GradingHandler {
+	return &GradingHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *GradingHandler) GetPendingAnswers(c *fiber.Ctx) error {
+	answers, err := h.service.GetPendingAnswers(c.Query("test_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(answers)
+}
+
+func (h *GradingHandler) GradeAnswer(c *fiber.Ctx) error {
+	graderID, _ := c.Locals("userID").(string)
+
+	var req service.GradeEssayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	answer, err := h.service.GradeAnswer(graderID, c.Params("id"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "essay answer not found", "test result not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "result has already been finalized":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(answer)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type IntegrityHandler struct {
+	service  service.IntegrityService
+	validate *validator.Validate
+}
+
+func NewIntegrityHandler(service service.IntegrityService) *IntegrityHandler {
+	return &IntegrityHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// RecordEvents accepts a batch of telemetry events for the caller's open attempt.
+func (h *IntegrityHandler) RecordEvents(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	var req service.RecordEventsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	accepted, err := h.service.RecordEvents(userID, c.Params("id"), c.IP(), c.Get(fiber.HeaderUserAgent), &req)
+	if err != nil {
+		switch err.Error() {
+		case "attempt not found", "invalid id format":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "attempt not found"})
+		case "attempt is not in progress":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not record events"})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(accepted)
+}
+
+// GetReviewQueue lists integrity reports by status, flagged ones by default.
+func (h *IntegrityHandler) GetReviewQueue(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+	queue, err := h.service.GetReviewQueue(c.Query("status"), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve review queue"})
+	}
+	return c.JSON(queue)
+}
+
+func (h *IntegrityHandler) GetReview(c *fiber.Ctx) error {
+	review, err := h.service.GetReview(c.Params("attemptId"))
+	if err != nil {
+		if err.Error() == "integrity report not found" || err.Error() == "invalid id format" || err.Error() == "test result not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve review"})
+	}
+	return c.JSON(review)
+}
+
+// Decide clears a flagged attempt or invalidates its result.
+func (h *IntegrityHandler) Decide(c *fiber.Ctx) error {
+	reviewerID, _ := c.Locals("userID").(string)
+
+	var req service.IntegrityDecisionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	report, err := h.service.Decide(reviewerID, c.Params("attemptId"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "integrity report not found", "invalid id format", "test result not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "result is already invalidated", "test result is already invalidated":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not save decision"})
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type IRTHandler struct {
+	service service.IRTService
+}
+
+func NewIRTHandler(service service.IRTService) *IRTHandler {
+	return &IRTHandler{service}
+}
+
+// GetItemParameters lists the IRT parameters last calibrated on a test, easiest first.
+// Calibration itself runs offline with the calibrate-items command.
+func (h *IRTHandler) GetItemParameters(c *fiber.Ctx) error {
+	params, err := h.service.GetItemParameters(c.Params("id"))
+	if err != nil {
+		if err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve item parameters"})
+	}
+	return c.JSON(params)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ItemAnalysisHandler struct {
+	service service.ItemAnalysisService
+}
+
+func NewItemAnalysisHandler(service service.ItemAnalysisService) *ItemAnalysisHandler {
+	return &ItemAnalysisHandler{service}
+}
+
+// GetItemAnalysis returns the item analysis of a test as JSON, or as a CSV download
+// with ?format=csv.
+func (h *ItemAnalysisHandler) GetItemAnalysis(c *fiber.Ctx) error {
+	report, err := h.service.AnalyzeTest(c.Params("id"))
+	if err != nil {
+		if err.Error() == "test not found" || err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not analyse test"})
+	}
+
+	if c.Query("format") != "csv" {
+		return c.JSON(report)
+	}
+	data, err := h.service.ExportCSV(report)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not export report"})
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="item-analysis-%s.csv"`, report.TestID))
+	return c.Send(data)
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"os"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/idempotency"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -39,6 +41,32 @@ func AuthMiddleware() fiber.Handler {
 	}
 }
 
+// OptionalAuthMiddleware sets userID and userRole like AuthMiddleware when a valid
+// access token is sent, and lets the request through without them otherwise.
+func OptionalAuthMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString := c.Cookies("access_token")
+		if tokenString == "" {
+			return c.Next()
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, "Unexpected signing method")
+			}
+			return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+		})
+		if err != nil || !token.Valid {
+			return c.Next()
+		}
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			c.Locals("userID", claims["user_id"])
+			c.Locals("userRole", claims["role"])
+		}
+		return c.Next()
+	}
+}
+
 func AdminMiddleware() fiber.Handler {
     return func(c *fiber.Ctx) error {
         role, ok := c.Locals("userRole").(string)
@@ -49,4 +77,30 @@ func AdminMiddleware() fiber.Handler {
         }
         return c.Next()
     }
-}
\ No newline at end of file
+}
+
+// GraderMiddleware lets admins and graders through to the essay grading queue.
+func GraderMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals("userRole").(string)
+		if !ok || (role != "admin" && role != "grader") {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied: requires grader privileges",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried with
+// the same Idempotency-Key. Keys are scoped to the logged-in user, so it must run
+// after AuthMiddleware.
+func IdempotencyMiddleware(redisClient *redis.Client) fiber.Handler {
+	return idempotency.New(idempotency.Config{
+		Redis: redisClient,
+		Scope: func(c *fiber.Ctx) string {
+			userID, _ := c.Locals("userID").(string)
+			return userID
+		},
+	})
+}
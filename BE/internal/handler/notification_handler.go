@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(service service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service}
+}
+
+func (h *NotificationHandler) GetMyNotifications(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	notifications, err := h.service.GetNotifications(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve notifications"})
+	}
+	return c.JSON(notifications)
+}
+
+func (h *NotificationHandler) MarkAsRead(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+
+	if err := h.service.MarkAsRead(userID, c.Params("id")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrganizationHandler struct {
+	service  service.OrganizationService
+	validate *validator.Validate
+}
+
+func NewOrganizationHandler(service service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+// organizationError maps the organization service's errors to status codes.
+func organizationError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "organization not found", "group not found", "member not found", "user not found", "test not found", "assignment not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case "access denied":
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	case "user is already a member", "no licensed seats left":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case "seats are only given to students", "only students of the organization can join a group",
+		"due date must be in the future", "invalid test id format", "invalid user ID format":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var req service.CreateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	org, err := h.service.CreateOrganization(&req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(org)
+}
+
+func (h *OrganizationHandler) GetAllOrganizations(c *fiber.Ctx) error {
+	orgs, err := h.service.GetAllOrganizations()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve organizations"})
+	}
+	return c.JSON(orgs)
+}
+
+func (h *OrganizationHandler) GetMyOrganizations(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	memberships, err := h.service.GetMyOrganizations(userID)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(memberships)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	summary, err := h.service.GetOrganization(userID, userRole, c.Params("orgId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(summary)
+}
+
+func (h *OrganizationHandler) AddMember(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	var req service.AddMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	member, err := h.service.AddMember(userID, userRole, c.Params("orgId"), &req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(member)
+}
+
+func (h *OrganizationHandler) GetMembers(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	members, err := h.service.GetMembers(userID, userRole, c.Params("orgId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(members)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	if err := h.service.RemoveMember(userID, userRole, c.Params("orgId"), c.Params("userId")); err != nil {
+		return organizationError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *OrganizationHandler) SetSeat(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	var req service.SetSeatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	member, err := h.service.SetSeat(userID, userRole, c.Params("orgId"), c.Params("userId"), &req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(member)
+}
+
+func (h *OrganizationHandler) CreateGroup(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	var req service.CreateGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	group, err := h.service.CreateGroup(userID, c.Params("orgId"), &req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(group)
+}
+
+func (h *OrganizationHandler) GetGroups(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	groups, err := h.service.GetGroups(userID, userRole, c.Params("orgId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(groups)
+}
+
+func (h *OrganizationHandler) GetGroupMembers(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	members, err := h.service.GetGroupMembers(userID, userRole, c.Params("orgId"), c.Params("groupId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(members)
+}
+
+func (h *OrganizationHandler) AddGroupMembers(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	var req service.AddGroupMembersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	members, err := h.service.AddGroupMembers(userID, c.Params("orgId"), c.Params("groupId"), &req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(members)
+}
+
+func (h *OrganizationHandler) RemoveGroupMember(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	if err := h.service.RemoveGroupMember(userID, c.Params("orgId"), c.Params("groupId"), c.Params("userId")); err != nil {
+		return organizationError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *OrganizationHandler) CreateAssignment(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	var req service.CreateAssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+	assignment, err := h.service.CreateAssignment(userID, c.Params("orgId"), c.Params("groupId"), &req)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(assignment)
+}
+
+func (h *OrganizationHandler) GetAssignments(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	assignments, err := h.service.GetAssignments(userID, userRole, c.Params("orgId"), c.Params("groupId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(assignments)
+}
+
+// GetAssignmentProgress reports which students of the group have started, finished
+// or are overdue on an assignment.
+func (h *OrganizationHandler) GetAssignmentProgress(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	progress, err := h.service.GetAssignmentProgress(userID, userRole, c.Params("orgId"), c.Params("groupId"), c.Params("assignmentId"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(progress)
+}
+
+// GetMyAssignments lists the calling student's assignments with their progress.
+func (h *OrganizationHandler) GetMyAssignments(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	assignments, err := h.service.GetMyAssignments(userID)
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(assignments)
+}
+
+// GetStudentResults is the teacher dashboard. ?group_id= and ?test_id= narrow it.
+func (h *OrganizationHandler) GetStudentResults(c *fiber.Ctx) error {
+	userID, _ := c.Locals("userID").(string)
+	results, err := h.service.GetStudentResults(userID, c.Params("orgId"), c.Query("group_id"), c.Query("test_id"))
+	if err != nil {
+		return organizationError(c, err)
+	}
+	return c.JSON(results)
+}
@@ -0,0 +1,21 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// pageParams reads the 1-based "page" and "limit" query parameters.
+func pageParams(c *fiber.Ctx) (int, int) {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", defaultPageLimit)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxPageLimit {
+		limit = defaultPageLimit
+	}
+	return page, limit
+}
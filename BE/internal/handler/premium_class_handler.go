@@ -7,14 +7,16 @@ import (
 )
 
 type PremiumClassHandler struct {
-	service  service.PremiumClassService
-	validate *validator.Validate
+	service        service.PremiumClassService
+	contentService service.ClassContentService
+	validate       *validator.Validate
 }
 
-func NewPremiumClassHandler(service service.PremiumClassService) *PremiumClassHandler {
+func NewPremiumClassHandler(service service.PremiumClassService, contentService service.ClassContentService) *PremiumClassHandler {
 	return &PremiumClassHandler{
-		service:  service,
-		validate: validator.New(),
+		service:        service,
+		contentService: contentService,
+		validate:       validator.New(),
 	}
 }
 
@@ -44,8 +46,11 @@ func (h *PremiumClassHandler) GetAllClasses(c *fiber.Ctx) error {
 	return c.JSON(classes)
 }
 
+// GetClassByID includes the current user's progress when they are logged in and
+// enrolled.
 func (h *PremiumClassHandler) GetClassByID(c *fiber.Ctx) error {
-	class, err := h.service.GetClassByID(c.Params("id"))
+	userID, _ := c.Locals("userID").(string)
+	class, err := h.contentService.GetClassDetail(userID, c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"strings"
+
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/questionimport"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -23,9 +26,31 @@ func NewQuestionHandler(service service.QuestionService) *QuestionHandler {
 type QuestionRequest struct {
 	TestID        string    `json:"test_id" validate:"required,uuid"`
 	QuestionText  string    `json:"question_text" validate:"required"`
-	Options       string    `json:"options" validate:"required,json"`
+	Options       string    `json:"options" validate:"omitempty,json"`
 	CorrectAnswer int       `json:"correct_answer" validate:"gte=0"`
 	Explanation   string    `json:"explanation"`
+	Type          string    `json:"type" validate:"omitempty,oneof=multiple_choice essay"`
+	Rubric        string    `json:"rubric" validate:"omitempty,json"`
+	MaxScore      float64   `json:"max_score" validate:"gte=0"`
+	Points        float64   `json:"points" validate:"gte=0"`
+	Penalty       float64   `json:"penalty" validate:"gte=0"`
+	SectionID     string    `json:"section_id" validate:"omitempty,uuid"`
+	Position      int       `json:"position" validate:"gte=0"`
+	Tags          string    `json:"tags"`
+	Difficulty    string    `json:"difficulty"`
+	Topic         string    `json:"topic" validate:"max=100"`
+}
+
+// sectionID converts the optional section_id field; validation already checked its format.
+func (r *QuestionRequest) sectionID() *uuid.UUID {
+	if r.SectionID == "" {
+		return nil
+	}
+	sectionUUID, err := uuid.Parse(r.SectionID)
+	if err != nil {
+		return nil
+	}
+	return &sectionUUID
 }
 
 func (h *QuestionHandler) CreateQuestion(c *fiber.Ctx) error {
@@ -49,15 +74,41 @@ func (h *QuestionHandler) CreateQuestion(c *fiber.Ctx) error {
 		Options:       req.Options,
 		CorrectAnswer: req.CorrectAnswer,
 		Explanation:   req.Explanation,
+		Type:          req.Type,
+		Rubric:        req.Rubric,
+		MaxScore:      req.MaxScore,
+		Points:        req.Points,
+		Penalty:       req.Penalty,
+		SectionID:     req.sectionID(),
+		Position:      req.Position,
+		Tags:          req.Tags,
+		Difficulty:    req.Difficulty,
+		Topic:         req.Topic,
 	}
 
 	if err := h.service.CreateQuestion(question); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create question"})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create question", "details": err.Error()})
 	}
 	return c.Status(fiber.StatusCreated).JSON(question)
 }
 
+// GetQuestionsByTestID gives admins the full questions. Everyone else gets the
+// paper of an available plain test without its keys.
 func (h *QuestionHandler) GetQuestionsByTestID(c *fiber.Ctx) error {
+	if userRole, _ := c.Locals("userRole").(string); userRole != "admin" {
+		questions, err := h.service.GetPublicQuestions(c.Params("testId"))
+		if err != nil {
+			switch err.Error() {
+			case "test not found":
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+			case "test must be taken through an attempt":
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve questions"})
+		}
+		return c.JSON(questions)
+	}
+
 	questions, err := h.service.GetQuestionsByTestID(c.Params("testId"))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve questions"})
@@ -94,11 +145,21 @@ func (h *QuestionHandler) UpdateQuestion(c *fiber.Ctx) error {
 		Options:       req.Options,
 		CorrectAnswer: req.CorrectAnswer,
 		Explanation:   req.Explanation,
+		Type:          req.Type,
+		Rubric:        req.Rubric,
+		MaxScore:      req.MaxScore,
+		Points:        req.Points,
+		Penalty:       req.Penalty,
+		SectionID:     req.sectionID(),
+		Position:      req.Position,
+		Tags:          req.Tags,
+		Difficulty:    req.Difficulty,
+		Topic:         req.Topic,
 	}
 
 	updatedQuestion, err := h.service.UpdateQuestion(c.Params("id"), questionData)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found or could not be updated"})
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found or could not be updated", "details": err.Error()})
 	}
 	return c.JSON(updatedQuestion)
 }
@@ -108,4 +169,41 @@ func (h *QuestionHandler) DeleteQuestion(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// ImportQuestions accepts a multipart "file" (CSV, XLSX, GIFT or QTI 2.1). The format
+// is taken from the "format" field or the file extension. With dry_run=true only the
+// validation report is returned.
+func (h *QuestionHandler) ImportQuestions(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File upload error: " + err.Error()})
+	}
+
+	format := strings.ToLower(c.FormValue("format"))
+	if format == "" {
+		format = questionimport.DetectFormat(file.Filename)
+	}
+	if format == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown file format, use csv, xlsx, gift or qti"})
+	}
+	dryRun := c.Query("dry_run") == "true" || c.FormValue("dry_run") == "true"
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read uploaded file"})
+	}
+	defer src.Close()
+
+	report, err := h.service.ImportQuestions(c.Params("id"), format, src, dryRun)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(report.Errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+	}
+	if dryRun {
+		return c.JSON(report)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SimilarityHandler struct {
+	service service.SimilarityService
+}
+
+func NewSimilarityHandler(service service.SimilarityService) *SimilarityHandler {
+	return &SimilarityHandler{service}
+}
+
+// GetRuns lists the answer similarity runs of a test, newest first.
+func (h *SimilarityHandler) GetRuns(c *fiber.Ctx) error {
+	runs, err := h.service.GetSimilarityRuns(c.Params("id"))
+	if err != nil {
+		if err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve similarity runs"})
+	}
+	return c.JSON(runs)
+}
+
+// GetRun returns a similarity run with its pairs as JSON, or as a CSV download with
+// ?format=csv.
+func (h *SimilarityHandler) GetRun(c *fiber.Ctx) error {
+	run, err := h.service.GetSimilarityRun(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") != "csv" {
+		return c.JSON(run)
+	}
+	data, err := h.service.ExportCSV(run)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not export report"})
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="answer-similarity-%s.csv"`, run.ID))
+	return c.Send(data)
+}
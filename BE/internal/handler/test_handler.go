@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"time"
+
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
 	"github.com/go-playground/validator/v10"
@@ -27,6 +29,31 @@ type TestRequest struct {
 	Duration    int    `json:"duration" validate:"required,gt=0"`
 	IsPremium   bool   `json:"is_premium"`
 	ImageURL    string `json:"image_url" validate:"omitempty,url"`
+	ScoringPolicy string   `json:"scoring_policy" validate:"omitempty,oneof=percent_correct weighted"`
+	BlankPenalty  float64  `json:"blank_penalty" validate:"gte=0"`
+	PassingScore  *float64 `json:"passing_score"`
+	ShuffleQuestions bool   `json:"shuffle_questions"`
+	ShuffleOptions   bool   `json:"shuffle_options"`
+	DrawCount        int    `json:"draw_count" validate:"gte=0"`
+	DrawTag          string `json:"draw_tag"`
+	DrawDifficulty   string `json:"draw_difficulty"`
+	AvailableFrom    *time.Time `json:"available_from"`
+	AvailableUntil   *time.Time `json:"available_until"`
+	AbilityEstimator string     `json:"ability_estimator" validate:"omitempty,oneof=eap mle"`
+	ScaleMean        float64    `json:"scale_mean"`
+	ScaleSD          float64    `json:"scale_sd" validate:"gte=0"`
+	Adaptive            bool    `json:"adaptive"`
+	AdaptiveMinItems    int     `json:"adaptive_min_items" validate:"gte=0"`
+	AdaptiveMaxItems    int     `json:"adaptive_max_items" validate:"gte=0"`
+	AdaptiveStopSE      float64 `json:"adaptive_stop_se" validate:"gte=0"`
+	AdaptiveMaxExposure float64 `json:"adaptive_max_exposure" validate:"gte=0,lte=1"`
+	MaxAttempts         int     `json:"max_attempts" validate:"gte=0"`
+	AttemptCooldown     int     `json:"attempt_cooldown" validate:"gte=0"`
+	OfficialResult      string  `json:"official_result" validate:"omitempty,oneof=first best last average"`
+}
+
+type TestStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=draft review published archived"`
 }
 
 func (h *TestHandler) CreateTest(c *fiber.Ctx) error {
@@ -47,16 +74,50 @@ func (h *TestHandler) CreateTest(c *fiber.Ctx) error {
 		Duration:    req.Duration,
 		IsPremium:   req.IsPremium,
 		ImageURL:    req.ImageURL,
+		ScoringPolicy: req.ScoringPolicy,
+		BlankPenalty:  req.BlankPenalty,
+		PassingScore:  req.PassingScore,
+		ShuffleQuestions: req.ShuffleQuestions,
+		ShuffleOptions:   req.ShuffleOptions,
+		DrawCount:        req.DrawCount,
+		DrawTag:          req.DrawTag,
+		DrawDifficulty:   req.DrawDifficulty,
+		AvailableFrom:    req.AvailableFrom,
+		AvailableUntil:   req.AvailableUntil,
+		AbilityEstimator: req.AbilityEstimator,
+		ScaleMean:        req.ScaleMean,
+		ScaleSD:          req.ScaleSD,
+		Adaptive:            req.Adaptive,
+		AdaptiveMinItems:    req.AdaptiveMinItems,
+		AdaptiveMaxItems:    req.AdaptiveMaxItems,
+		AdaptiveStopSE:      req.AdaptiveStopSE,
+		AdaptiveMaxExposure: req.AdaptiveMaxExposure,
+		MaxAttempts:         req.MaxAttempts,
+		AttemptCooldown:     req.AttemptCooldown,
+		OfficialResult:      req.OfficialResult,
 	}
 
 	if err := h.service.CreateTest(test); err != nil {
+		if err.Error() == "available_until must be after available_from" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create test"})
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(test)
 }
 
+// GetAllTests is the public listing and only shows tests that can be taken now.
 func (h *TestHandler) GetAllTests(c *fiber.Ctx) error {
+	tests, err := h.service.GetAvailableTests()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve tests"})
+	}
+	return c.JSON(tests)
+}
+
+// GetManagedTests lists every test regardless of status or window, for admins.
+func (h *TestHandler) GetManagedTests(c *fiber.Ctx) error {
 	tests, err := h.service.GetAllTests()
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve tests"})
@@ -64,7 +125,20 @@ func (h *TestHandler) GetAllTests(c *fiber.Ctx) error {
 	return c.JSON(tests)
 }
 
+// GetTestByID shows admins any test as stored. Everyone else only sees available
+// tests, without the answer keys.
 func (h *TestHandler) GetTestByID(c *fiber.Ctx) error {
+	if userRole, _ := c.Locals("userRole").(string); userRole != "admin" {
+		test, err := h.service.GetPublicTest(c.Params("id"))
+		if err != nil {
+			if err.Error() == "test not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve test"})
+		}
+		return c.JSON(test)
+	}
+
 	test, err := h.service.GetTestByID(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
@@ -90,10 +164,34 @@ func (h *TestHandler) UpdateTest(c *fiber.Ctx) error {
 		Duration:    req.Duration,
 		IsPremium:   req.IsPremium,
 		ImageURL:    req.ImageURL,
+		ScoringPolicy: req.ScoringPolicy,
+		BlankPenalty:  req.BlankPenalty,
+		PassingScore:  req.PassingScore,
+		ShuffleQuestions: req.ShuffleQuestions,
+		ShuffleOptions:   req.ShuffleOptions,
+		DrawCount:        req.DrawCount,
+		DrawTag:          req.DrawTag,
+		DrawDifficulty:   req.DrawDifficulty,
+		AvailableFrom:    req.AvailableFrom,
+		AvailableUntil:   req.AvailableUntil,
+		AbilityEstimator: req.AbilityEstimator,
+		ScaleMean:        req.ScaleMean,
+		ScaleSD:          req.ScaleSD,
+		Adaptive:            req.Adaptive,
+		AdaptiveMinItems:    req.AdaptiveMinItems,
+		AdaptiveMaxItems:    req.AdaptiveMaxItems,
+		AdaptiveStopSE:      req.AdaptiveStopSE,
+		AdaptiveMaxExposure: req.AdaptiveMaxExposure,
+		MaxAttempts:         req.MaxAttempts,
+		AttemptCooldown:     req.AttemptCooldown,
+		OfficialResult:      req.OfficialResult,
 	}
 
 	updatedTest, err := h.service.UpdateTest(c.Params("id"), testData)
 	if err != nil {
+		if err.Error() == "available_until must be after available_from" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found or could not be updated"})
 	}
 	return c.JSON(updatedTest)
@@ -104,4 +202,37 @@ func (h *TestHandler) DeleteTest(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
 	}
 	return c.SendStatus(fiber.StatusNoContent)
-}
\ No newline at end of file
+}
+
+func (h *TestHandler) GetPublishChecklist(c *fiber.Ctx) error {
+	checklist, err := h.service.GetPublishChecklist(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"checklist": checklist})
+}
+
+// ChangeStatus moves the test to another workflow status. A failed publish
+// checklist is returned with 422 so the editor can show what to fix.
+func (h *TestHandler) ChangeStatus(c *fiber.Ctx) error {
+	var req TestStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	test, checklist, err := h.service.ChangeStatus(c.Params("id"), req.Status)
+	if err != nil {
+		switch err.Error() {
+		case "test not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "publish checklist failed":
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error(), "checklist": checklist})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"test": test, "checklist": checklist})
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TestPackageHandler struct {
+	service service.TestPackageService
+}
+
+func NewTestPackageHandler(service service.TestPackageService) *TestPackageHandler {
+	return &TestPackageHandler{service}
+}
+
+// ExportTest downloads the test as a zip archive with manifest.json and media files.
+func (h *TestPackageHandler) ExportTest(c *fiber.Ctx) error {
+	archive, err := h.service.ExportTest(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="test-%s.zip"`, c.Params("id")))
+	return c.Send(archive)
+}
+
+// ImportTest recreates a test from an archive sent as multipart "file".
+func (h *TestPackageHandler) ImportTest(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File upload error: " + err.Error()})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read uploaded file"})
+	}
+	defer src.Close()
+	archive, err := io.ReadAll(src)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read uploaded file"})
+	}
+
+	report, err := h.service.ImportTest(archive)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
@@ -1,16 +1,19 @@
 package handler
 
 import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type TestResultHandler struct {
-	service service.TestResultService
+	service            service.TestResultService
+	leaderboardService service.LeaderboardService
 }
 
-func NewTestResultHandler(service service.TestResultService) *TestResultHandler {
-	return &TestResultHandler{service}
+func NewTestResultHandler(service service.TestResultService, leaderboardService service.LeaderboardService) *TestResultHandler {
+	return &TestResultHandler{service, leaderboardService}
 }
 
 func (h *TestResultHandler) SubmitTest(c *fiber.Ctx) error {
@@ -23,6 +26,17 @@ func (h *TestResultHandler) SubmitTest(c *fiber.Ctx) error {
 
 	result, err := h.service.SubmitTest(userID, &req)
 	if err != nil {
+		if err.Error() == "maximum attempts reached" || err.Error() == "attempt cooldown has not passed" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		switch err.Error() {
+		case "test not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "test is not available", "test must be taken through an attempt":
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		case "another attempt is being started":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -42,6 +56,72 @@ func (h *TestResultHandler) GetResultsByUserID(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve results"})
 	}
+	h.leaderboardService.AttachRanks(results)
 
 	return c.JSON(results)
-}
\ No newline at end of file
+}
+
+// GetLeaderboard lists each student's best result on a test, highest score first
+// and faster first on equal scores.
+func (h *TestResultHandler) GetLeaderboard(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+	leaderboard, err := h.leaderboardService.GetLeaderboard(c.Params("id"), page, limit)
+	if err != nil {
+		if err.Error() == "test not found" || err.Error() == "invalid test id format" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve leaderboard"})
+	}
+	return c.JSON(leaderboard)
+}
+
+// GetResultReview returns one result with a per-question breakdown. Only the
+// student who took it and admins can see it.
+func (h *TestResultHandler) GetResultReview(c *fiber.Ctx) error {
+	loggedInUserID, _ := c.Locals("userID").(string)
+	userRole, _ := c.Locals("userRole").(string)
+	review, err := h.service.GetResultReviewFor(loggedInUserID, userRole, c.Params("id"))
+	if err != nil {
+		switch err.Error() {
+		case "test result not found", "invalid id format":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Test result not found"})
+		case "access denied":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve result"})
+	}
+
+	ranked := []model.TestResult{*review.Result}
+	h.leaderboardService.AttachRanks(ranked)
+	review.Result = &ranked[0]
+	return c.JSON(review)
+}
+
+// InvalidateResult voids a result so it no longer counts on leaderboards, rankings or
+// as the student's official result.
+func (h *TestResultHandler) InvalidateResult(c *fiber.Ctx) error {
+	resultID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid result ID"})
+	}
+
+	var req service.InvalidateResultRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(req.Reason) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Reason must be at most 255 characters"})
+	}
+
+	result, err := h.service.InvalidateResult(resultID, req.Reason)
+	if err != nil {
+		switch err.Error() {
+		case "test result not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "test result is already invalidated":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not invalidate result"})
+	}
+	return c.JSON(result)
+}
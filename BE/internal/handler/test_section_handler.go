@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TestSectionHandler struct {
+	service  service.TestSectionService
+	validate *validator.Validate
+}
+
+func NewTestSectionHandler(service service.TestSectionService) *TestSectionHandler {
+	return &TestSectionHandler{
+		service:  service,
+		validate: validator.New(),
+	}
+}
+
+func (h *TestSectionHandler) CreateSection(c *fiber.Ctx) error {
+	var req service.SectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	section, err := h.service.CreateSection(c.Params("id"), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(section)
+}
+
+func (h *TestSectionHandler) GetSectionsByTestID(c *fiber.Ctx) error {
+	sections, err := h.service.GetSectionsByTestID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(sections)
+}
+
+func (h *TestSectionHandler) UpdateSection(c *fiber.Ctx) error {
+	var req service.SectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Validation failed", "details": err.Error()})
+	}
+
+	section, err := h.service.UpdateSection(c.Params("id"), &req)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(section)
+}
+
+func (h *TestSectionHandler) DeleteSection(c *fiber.Ctx) error {
+	if err := h.service.DeleteSection(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
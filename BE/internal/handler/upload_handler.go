@@ -1,10 +1,8 @@
 package handler
 
 import (
-	"fmt"
-	"path/filepath"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/upload"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 func UploadFile(c *fiber.Ctx) error {
@@ -13,19 +11,19 @@ func UploadFile(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File upload error: " + err.Error()})
 	}
 
-	ext := filepath.Ext(file.Filename)
-	uniqueFilename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
-
-	savePath := fmt.Sprintf("./public/uploads/%s", uniqueFilename)
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File upload error: " + err.Error()})
+	}
+	defer src.Close()
 
-	if err := c.SaveFile(file, savePath); err != nil {
+	fileURL, err := upload.Save(file.Filename, src)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save file"})
 	}
 
-	fileURL := fmt.Sprintf("/uploads/%s", uniqueFilename)
-
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "File uploaded successfully",
 		"url":     fileURL,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attempt is a test that a student has started but not necessarily finished. For
+// sectioned tests CurrentSection is the index of the open section; every section
+// before it is locked. Answers holds the answers of the locked sections as JSON,
+// already mapped back to the authored option indexes. Adaptive attempts have no
+// sections; there CurrentSection counts the questions served so far.
+//
+// Layout records the paper this student got (question order, drawn questions and
+// option permutations, all derived from Seed) so it can be rebuilt on every request
+// and shown again in a review.
+//
+// A student has at most one attempt per tryout event; the unique index makes two
+// joins sent at the same time start only one.
+type Attempt struct {
+	ID              uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID          uuid.UUID  `gorm:"type:char(36);not null;index" json:"test_id"`
+	UserID          uuid.UUID  `gorm:"type:char(36);not null;index;uniqueIndex:idx_attempt_user_event" json:"user_id"`
+	Status          string     `gorm:"type:varchar(20);default:'in_progress'" json:"status"`
+	CurrentSection  int        `gorm:"default:0" json:"current_section"`
+	SectionDeadline time.Time  `json:"section_deadline"`
+	Answers         string     `gorm:"type:json" json:"-"`
+	Seed            int64      `json:"seed"`
+	Layout          string     `gorm:"type:json" json:"-"`
+	StartedAt       time.Time  `json:"started_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+	TestResultID    *uuid.UUID `gorm:"type:char(36)" json:"test_result_id,omitempty"`
+	EventID         *uuid.UUID `gorm:"type:char(36);index;uniqueIndex:idx_attempt_user_event" json:"event_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+const (
+	AttemptStatusInProgress = "in_progress"
+	AttemptStatusSubmitted  = "submitted"
+)
+
+// AttemptLayoutItem is one served question in display order. OptionOrder[i] is the
+// authored index of the option shown at position i; it is empty when options were
+// not shuffled.
+type AttemptLayoutItem struct {
+	QuestionID  uuid.UUID `json:"question_id"`
+	OptionOrder []int     `json:"option_order,omitempty"`
+}
+
+// AttemptDraft is the MySQL copy of the answers autosaved for an in-progress attempt.
+// Redis holds the live draft; this row is written behind it and used when the Redis
+// copy is gone. Answers is a JSON list of saved answers with authored option indexes.
+type AttemptDraft struct {
+	AttemptID uuid.UUID `gorm:"type:char(36);primaryKey" json:"attempt_id"`
+	Answers   string    `gorm:"type:json" json:"-"`
+	SavedAt   time.Time `json:"saved_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttemptGrant gives a student attempts on a test beyond Test.MaxAttempts.
+type AttemptGrant struct {
+	ID            uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID        uuid.UUID `gorm:"type:char(36);not null;index:idx_attempt_grant_user_test" json:"test_id"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;index:idx_attempt_grant_user_test" json:"user_id"`
+	ExtraAttempts int       `gorm:"not null" json:"extra_attempts"`
+	Reason        string    `gorm:"type:varchar(255)" json:"reason"`
+	GrantedBy     uuid.UUID `gorm:"type:char(36)" json:"granted_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	User          User      `gorm:"foreignKey:UserID" json:"user"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BankItem is a question in the shared question bank. Its content lives in
+// versions; editing the content adds a version instead of changing an old one, so
+// stored answers (which reference BankItemVersion.ID) keep their exact wording.
+type BankItem struct {
+	ID             uuid.UUID         `gorm:"type:char(36);primaryKey" json:"id"`
+	Topic          string            `gorm:"type:varchar(100);index" json:"topic"`
+	Tags           string            `gorm:"type:varchar(255)" json:"tags"`
+	Difficulty     string            `gorm:"type:varchar(50)" json:"difficulty"`
+	AuthorID       uuid.UUID         `gorm:"type:char(36);not null" json:"author_id"`
+	CurrentVersion int               `gorm:"default:1" json:"current_version"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Versions       []BankItemVersion `gorm:"foreignKey:BankItemID" json:"versions,omitempty"`
+}
+
+type BankItemVersion struct {
+	ID            uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	BankItemID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_bank_item_version" json:"bank_item_id"`
+	Version       int       `gorm:"not null;uniqueIndex:idx_bank_item_version" json:"version"`
+	Type          string    `gorm:"type:varchar(20);default:'multiple_choice'" json:"type"`
+	QuestionText  string    `gorm:"type:text;not null" json:"question_text"`
+	Options       string    `gorm:"type:json" json:"options"`
+	CorrectAnswer int       `json:"correct_answer"`
+	Explanation   string    `gorm:"type:text" json:"explanation"`
+	Rubric        string    `gorm:"type:text" json:"rubric,omitempty"`
+	MaxScore      float64   `gorm:"type:decimal(6,2);default:0" json:"max_score"`
+	CreatedBy     uuid.UUID `gorm:"type:char(36)" json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TestBankItem links a bank item into a test with test-specific ordering and
+// points. Without PinnedVersion the link follows the item's current version.
+type TestBankItem struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID        uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_test_bank_item" json:"test_id"`
+	BankItemID    uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_test_bank_item" json:"bank_item_id"`
+	PinnedVersion *int       `json:"pinned_version"`
+	SectionID     *uuid.UUID `gorm:"type:char(36)" json:"section_id"`
+	Position      int        `gorm:"default:0" json:"position"`
+	Points        float64    `gorm:"type:decimal(6,2);default:1" json:"points"`
+	Penalty       float64    `gorm:"type:decimal(6,2);default:0" json:"penalty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
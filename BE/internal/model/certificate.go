@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Certificate is issued for a passing official result. RecipientName, TestTitle and
+// Score are copied at issue time, so the certificate reads the same after the user
+// or test is renamed; they are also covered by the serial's signature.
+type Certificate struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	Serial        string     `gorm:"type:varchar(40);uniqueIndex;not null" json:"serial"`
+	TestResultID  uuid.UUID  `gorm:"type:char(36);uniqueIndex;not null" json:"test_result_id"`
+	UserID        uuid.UUID  `gorm:"type:char(36);not null;index" json:"user_id"`
+	TestID        uuid.UUID  `gorm:"type:char(36);not null" json:"test_id"`
+	RecipientName string     `gorm:"type:varchar(255)" json:"recipient_name"`
+	TestTitle     string     `gorm:"type:varchar(255)" json:"test_title"`
+	Score         float64    `gorm:"type:decimal(5,2)" json:"score"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `gorm:"type:varchar(255)" json:"revoked_reason,omitempty"`
+}
+
+// CourseCertificate is issued when a user completes a premium class. It uses the
+// same serial scheme as Certificate, so one verify endpoint serves both.
+type CourseCertificate struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	Serial        string     `gorm:"type:varchar(40);uniqueIndex;not null" json:"serial"`
+	ClassID       uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_course_certificate" json:"class_id"`
+	UserID        uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_course_certificate;index" json:"user_id"`
+	RecipientName string     `gorm:"type:varchar(255)" json:"recipient_name"`
+	CourseTitle   string     `gorm:"type:varchar(255)" json:"course_title"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `gorm:"type:varchar(255)" json:"revoked_reason,omitempty"`
+}
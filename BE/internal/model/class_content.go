@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClassModule is a chapter of a premium class. Modules and their lessons are shown
+// in Position order.
+type ClassModule struct {
+	ID          uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	ClassID     uuid.UUID `gorm:"type:char(36);not null;index" json:"class_id"`
+	Title       string    `gorm:"type:varchar(255);not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Position    int       `gorm:"default:0" json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Lessons []Lesson `gorm:"foreignKey:ModuleID" json:"lessons,omitempty"`
+}
+
+// Lesson is one unit of a module: a video, a rich text body (HTML), downloadable
+// attachments and optionally a practice test. Attachments is a JSON list of
+// LessonAttachment. VideoDuration is in seconds.
+type Lesson struct {
+	ID             uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	ClassID        uuid.UUID  `gorm:"type:char(36);not null;index" json:"class_id"`
+	ModuleID       uuid.UUID  `gorm:"type:char(36);not null;index" json:"module_id"`
+	Title          string     `gorm:"type:varchar(255);not null" json:"title"`
+	Position       int        `gorm:"default:0" json:"position"`
+	VideoURL       string     `gorm:"type:varchar(500)" json:"video_url"`
+	VideoDuration  int        `gorm:"default:0" json:"video_duration"`
+	Content        string     `gorm:"type:longtext" json:"content"`
+	Attachments    string     `gorm:"type:json" json:"-"`
+	PracticeTestID *uuid.UUID `gorm:"type:char(36)" json:"practice_test_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type LessonAttachment struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// ClassEnrollment records that a user joined a premium class. Enrollments through
+// a premium membership only open the lessons while the membership lasts; those
+// through a class order (OrderID set) keep them open.
+type ClassEnrollment struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	ClassID   uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_class_enrollment" json:"class_id"`
+	UserID    uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_class_enrollment;index" json:"user_id"`
+	Source    string     `gorm:"type:varchar(20);not null" json:"source"`
+	OrderID   *uuid.UUID `gorm:"type:char(36)" json:"order_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User  User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Class PremiumClass `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+}
+
+const (
+	EnrollmentSourcePremium = "premium"
+	EnrollmentSourceOrder   = "order"
+)
+
+// LessonProgress is how far a user has got with one lesson. VideoPosition is where
+// they last were in the video and WatchedUntil the furthest they have watched, both
+// in seconds. MarkedDone records that they marked a lesson without a timed video as
+// done. CompletedAt is set once the lesson's completion rules are met.
+type LessonProgress struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_lesson_progress" json:"user_id"`
+	LessonID      uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_lesson_progress" json:"lesson_id"`
+	ClassID       uuid.UUID  `gorm:"type:char(36);not null;index" json:"class_id"`
+	VideoPosition int        `gorm:"default:0" json:"video_position"`
+	WatchedUntil  int        `gorm:"default:0" json:"watched_until"`
+	MarkedDone    bool       `gorm:"default:false" json:"marked_done"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EssayAnswer is a student's answer to an essay question waiting in the grading queue.
+// The question text and rubric are copied at submission time so later edits to the
+// question do not change what the grader scores against.
+type EssayAnswer struct {
+	ID             uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	TestResultID   uuid.UUID  `gorm:"type:char(36);not null;index" json:"test_result_id"`
+	TestID         uuid.UUID  `gorm:"type:char(36);not null;index" json:"test_id"`
+	QuestionID     uuid.UUID  `gorm:"type:char(36);not null" json:"question_id"`
+	SectionID      *uuid.UUID `gorm:"type:char(36)" json:"section_id,omitempty"`
+	UserID         uuid.UUID  `gorm:"type:char(36);not null" json:"user_id"`
+	QuestionText   string     `gorm:"type:text" json:"question_text"`
+	Rubric         string     `gorm:"type:text" json:"rubric,omitempty"`
+	AnswerText     string     `gorm:"type:text" json:"answer_text"`
+	MaxScore       float64    `gorm:"type:decimal(6,2)" json:"max_score"`
+	Points         float64    `gorm:"type:decimal(6,2)" json:"points"`
+	Score          *float64   `gorm:"type:decimal(6,2)" json:"score"`
+	CriteriaScores string     `gorm:"type:text" json:"criteria_scores,omitempty"`
+	Feedback       string     `gorm:"type:text" json:"feedback"`
+	Status         string     `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	GradedBy       *uuid.UUID `gorm:"type:char(36)" json:"graded_by,omitempty"`
+	GradedAt       *time.Time `json:"graded_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+const (
+	EssayStatusPending = "pending"
+	EssayStatusGraded  = "graded"
+)
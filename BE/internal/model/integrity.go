@@ -0,0 +1,94 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttemptEvent is one telemetry event a client reported during an attempt. IP and
+// UserAgent are taken from the request that delivered it, not from the client.
+type AttemptEvent struct {
+	ID         uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	AttemptID  uuid.UUID `gorm:"type:char(36);not null;index" json:"attempt_id"`
+	Type       string    `gorm:"type:varchar(30);not null" json:"type"`
+	Detail     string    `gorm:"type:varchar(255)" json:"detail,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+	ReceivedAt time.Time `json:"received_at"`
+	IP         string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent  string    `gorm:"type:varchar(255)" json:"user_agent"`
+}
+
+const (
+	AttemptEventBlur           = "blur"
+	AttemptEventFocus          = "focus"
+	AttemptEventFullscreenExit = "fullscreen_exit"
+	AttemptEventCopy           = "copy"
+	AttemptEventPaste          = "paste"
+	AttemptEventHeartbeat      = "heartbeat"
+)
+
+// IntegrityReport is the integrity score of a finished attempt. Flags is a JSON list
+// of the signals that contributed to Score. Attempts at or above the flag threshold
+// wait in the review queue until an admin clears them or invalidates the result.
+type IntegrityReport struct {
+	AttemptID    uuid.UUID  `gorm:"type:char(36);primaryKey" json:"attempt_id"`
+	TestResultID uuid.UUID  `gorm:"type:char(36);index" json:"test_result_id"`
+	TestID       uuid.UUID  `gorm:"type:char(36);index" json:"test_id"`
+	UserID       uuid.UUID  `gorm:"type:char(36);index" json:"user_id"`
+	Score        int        `json:"score"`
+	Flags        string     `gorm:"type:json" json:"flags"`
+	Status       string     `gorm:"type:varchar(20);index" json:"status"`
+	ReviewNote   string     `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedBy   *uuid.UUID `gorm:"type:char(36)" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	User         User       `gorm:"foreignKey:UserID" json:"user"`
+	Test         Test       `gorm:"foreignKey:TestID" json:"-"`
+}
+
+const (
+	IntegrityClean       = "clean"
+	IntegrityFlagged     = "flagged"
+	IntegrityCleared     = "cleared"
+	IntegrityInvalidated = "invalidated"
+)
+
+// SimilarityRun is one answer similarity check over the results of a test in a time
+// window, or of a tryout event. Alpha is the Bonferroni adjusted p-value a pair had to
+// beat to be listed.
+type SimilarityRun struct {
+	ID         uuid.UUID        `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID     uuid.UUID        `gorm:"type:char(36);not null;index" json:"test_id"`
+	EventID    *uuid.UUID       `gorm:"type:char(36);index" json:"event_id,omitempty"`
+	WindowFrom *time.Time       `json:"window_from,omitempty"`
+	WindowTo   *time.Time       `json:"window_to,omitempty"`
+	Alpha      float64          `json:"alpha"`
+	Results    int              `json:"results"`
+	Questions  int              `json:"questions"`
+	PairCount  int              `json:"pair_count"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Pairs      []SimilarityPair `gorm:"foreignKey:RunID" json:"pairs,omitempty"`
+}
+
+// SimilarityPair is a pair of results that share suspiciously many identical wrong
+// answers. PValue is adjusted for the number of pairs tested in the run, and
+// Significance is -log10(PValue), so larger is more suspicious.
+type SimilarityPair struct {
+	ID               uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	RunID            uuid.UUID `gorm:"type:char(36);not null;index" json:"run_id"`
+	SourceResultID   uuid.UUID `gorm:"type:char(36)" json:"source_result_id"`
+	SourceUserID     uuid.UUID `gorm:"type:char(36)" json:"source_user_id"`
+	CopierResultID   uuid.UUID `gorm:"type:char(36)" json:"copier_result_id"`
+	CopierUserID     uuid.UUID `gorm:"type:char(36)" json:"copier_user_id"`
+	SourceWrong      int       `json:"source_wrong"`
+	CopierWrong      int       `json:"copier_wrong"`
+	IdenticalWrong   int       `json:"identical_wrong"`
+	IdenticalAnswers int       `json:"identical_answers"`
+	Expected         float64   `json:"expected"`
+	PValue           float64   `json:"p_value"`
+	Significance     float64   `json:"significance"`
+	SourceUser       User      `gorm:"foreignKey:SourceUserID" json:"source_user"`
+	CopierUser       User      `gorm:"foreignKey:CopierUserID" json:"copier_user"`
+}
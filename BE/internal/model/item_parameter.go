@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemParameter holds the IRT parameters calibrated for a question. QuestionID is a
+// legacy question ID or a bank item version ID, the same ID answers refer to.
+type ItemParameter struct {
+	QuestionID   uuid.UUID `gorm:"type:char(36);primaryKey" json:"question_id"`
+	TestID       uuid.UUID `gorm:"type:char(36);index" json:"test_id"`
+	Model        string    `gorm:"type:varchar(5)" json:"model"`
+	A            float64   `gorm:"type:decimal(8,4)" json:"a"`
+	B            float64   `gorm:"type:decimal(8,4)" json:"b"`
+	C            float64   `gorm:"type:decimal(8,4)" json:"c"`
+	Respondents  int       `json:"respondents"`
+	CalibratedAt time.Time `json:"calibrated_at"`
+}
+
+// ItemExposure counts how many adaptive attempts on a test were served a question.
+type ItemExposure struct {
+	TestID     uuid.UUID `gorm:"type:char(36);primaryKey" json:"test_id"`
+	QuestionID uuid.UUID `gorm:"type:char(36);primaryKey" json:"question_id"`
+	Served     int       `json:"served"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:char(36);not null;index" json:"user_id"`
+	Type      string     `gorm:"type:varchar(50)" json:"type"`
+	Title     string     `gorm:"type:varchar(255)" json:"title"`
+	Message   string     `gorm:"type:text" json:"message"`
+	Link      string     `gorm:"type:varchar(255)" json:"link"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -11,6 +11,9 @@ type Order struct {
 	ItemType        string    `gorm:"type:varchar(50)" json:"item_type"`
 	ItemID          uuid.UUID `gorm:"type:char(36);not null" json:"item_id"`
 	Amount          float64   `gorm:"type:decimal(10,2)" json:"amount"`
+	// Seats is the number of licensed seats bought by a group license order, whose
+	// ItemID is the organization.
+	Seats           int       `gorm:"default:0" json:"seats,omitempty"`
 	Status          string    `gorm:"type:varchar(50);default:'pending'" json:"status"` 
 	PaymentProofURL string    `gorm:"type:varchar(255)" json:"payment_proof_url"`
 	CreatedAt       time.Time `json:"created_at"`
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a school or institution that buys group licenses for its students.
+// Everything below it carries OrganizationID, and repositories always filter on it,
+// so one organization never sees another's members, groups or assignments.
+type Organization struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationMember links a user to an organization as a teacher or a student. A
+// user may belong to several organizations. HasSeat marks students holding one of
+// the organization's licensed seats, which gives them premium access while a license
+// is active.
+type OrganizationMember struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_org_member" json:"organization_id"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_org_member;index" json:"user_id"`
+	Role           string    `gorm:"type:varchar(20);not null" json:"role"`
+	HasSeat        bool      `gorm:"default:false" json:"has_seat"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	User         User         `gorm:"foreignKey:UserID" json:"user"`
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+const (
+	OrgRoleTeacher = "teacher"
+	OrgRoleStudent = "student"
+)
+
+// OrganizationLicense is a block of seats bought with one group license order. Seats
+// of all unexpired licenses add up.
+type OrganizationLicense struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:char(36);not null;index" json:"organization_id"`
+	OrderID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex" json:"order_id"`
+	Seats          int       `json:"seats"`
+	StartsAt       time.Time `json:"starts_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Group is a class of students inside an organization, led by one teacher.
+type Group struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:char(36);not null;index" json:"organization_id"`
+	TeacherID      uuid.UUID `gorm:"type:char(36);not null;index" json:"teacher_id"`
+	Name           string    `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	Teacher User `gorm:"foreignKey:TeacherID" json:"teacher"`
+}
+
+// TableName avoids GROUPS, which is a reserved word in MySQL 8.
+func (Group) TableName() string {
+	return "student_groups"
+}
+
+type GroupMember struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:char(36);not null;index" json:"organization_id"`
+	GroupID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_group_member" json:"group_id"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_group_member;index" json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// Assignment asks every student of a group to take a test by DueAt. Only results
+// completed after the assignment was made count towards it. OverdueRemindedAt is set
+// once the overdue reminders have gone out, so they are sent only once.
+type Assignment struct {
+	ID                uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	OrganizationID    uuid.UUID  `gorm:"type:char(36);not null;index" json:"organization_id"`
+	GroupID           uuid.UUID  `gorm:"type:char(36);not null;index" json:"group_id"`
+	TestID            uuid.UUID  `gorm:"type:char(36);not null" json:"test_id"`
+	AssignedBy        uuid.UUID  `gorm:"type:char(36);not null" json:"assigned_by"`
+	DueAt             time.Time  `gorm:"index" json:"due_at"`
+	OverdueRemindedAt *time.Time `json:"overdue_reminded_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	Test  Test  `gorm:"foreignKey:TestID" json:"test"`
+	Group Group `gorm:"foreignKey:GroupID" json:"group,omitempty"`
+}
@@ -14,6 +14,9 @@ type PremiumClass struct {
 	ImageURL    string    `gorm:"type:varchar(255)" json:"image_url"`
 	Category    string    `gorm:"type:varchar(100)" json:"category"`
 	Duration    string    `gorm:"type:varchar(100)" json:"duration"` // <-- DITAMBAH
+	// FinalTestID is the class's final quiz. When set, the class is only complete
+	// once the student has passed it as well as finishing every lesson.
+	FinalTestID *uuid.UUID `gorm:"type:char(36)" json:"final_test_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
\ No newline at end of file
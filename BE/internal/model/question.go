@@ -1,6 +1,8 @@
 package model
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 )
 
@@ -8,10 +10,52 @@ type Question struct {
     // TAMBAHKAN TAG JSON DI SINI
 	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
 	TestID         uuid.UUID `gorm:"type:char(36);not null"   json:"test_id"`
+	SectionID      *uuid.UUID `gorm:"type:char(36);index"    json:"section_id"`
+	Position       int       `gorm:"default:0"               json:"position"`
 	QuestionText   string    `gorm:"type:text;not null"      json:"question_text"`
 	Options        string    `gorm:"type:json"               json:"options"`
 	CorrectAnswer  int       `                               json:"correct_answer"`
 	Explanation    string    `gorm:"type:text"               json:"explanation"`
+	Type           string    `gorm:"type:varchar(20);default:'multiple_choice'" json:"type"`
+	Rubric         string    `gorm:"type:text"               json:"rubric,omitempty"`
+	MaxScore       float64   `gorm:"type:decimal(6,2);default:0" json:"max_score"`
+	Points         float64   `gorm:"type:decimal(6,2);default:1" json:"points"`
+	Penalty        float64   `gorm:"type:decimal(6,2);default:0" json:"penalty"`
+	Tags           string    `gorm:"type:varchar(255)"       json:"tags"`
+	Difficulty     string    `gorm:"type:varchar(50)"        json:"difficulty"`
+	// Topic is the subject area or sub-skill the question measures, used for
+	// per-topic analytics.
+	Topic          string    `gorm:"type:varchar(100);index" json:"topic"`
+
+	// Set only for questions served from the question bank, where ID is the
+	// BankItemVersion ID.
+	BankItemID     *uuid.UUID `gorm:"-"                      json:"bank_item_id,omitempty"`
+	BankVersion    int       `gorm:"-"                       json:"bank_version,omitempty"`
 	
 	Test           Test `gorm:"foreignKey:TestID" json:"-"`
-}
\ No newline at end of file
+}
+
+const (
+	QuestionTypeMultipleChoice = "multiple_choice"
+	QuestionTypeEssay          = "essay"
+)
+
+// RubricCriterion is one row of an essay rubric stored as JSON in Question.Rubric.
+type RubricCriterion struct {
+	Criterion string  `json:"criterion"`
+	MaxScore  float64 `json:"max_score"`
+}
+
+func (q *Question) IsEssay() bool {
+	return q.Type == QuestionTypeEssay
+}
+
+// HasTag reports whether the comma-separated Tags contain tag, ignoring case.
+func (q *Question) HasTag(tag string) bool {
+	for _, t := range strings.Split(q.Tags, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// SectionScore is the per-section breakdown stored alongside a TestResult. Title and
+// PassingScore are copied from the section so the result stays readable after edits.
+type SectionScore struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	TestResultID   uuid.UUID `gorm:"type:char(36);not null;index" json:"test_result_id"`
+	SectionID      uuid.UUID `gorm:"type:char(36);not null" json:"section_id"`
+	Title          string    `gorm:"type:varchar(255)" json:"title"`
+	Position       int       `json:"position"`
+	TotalQuestions int       `json:"total_questions"`
+	CorrectAnswers int       `json:"correct_answers"`
+	WrongAnswers   int       `json:"wrong_answers"`
+	BlankAnswers   int       `json:"blank_answers"`
+	RawPoints      float64   `gorm:"type:decimal(8,2)" json:"raw_points"`
+	MaxPoints      float64   `gorm:"type:decimal(8,2)" json:"max_points"`
+	Score          float64   `gorm:"type:decimal(5,2)" json:"score"`
+	PassingScore   *float64  `gorm:"type:decimal(8,2)" json:"passing_score"`
+	Passed         *bool     `json:"passed"`
+}
@@ -14,7 +14,86 @@ type Test struct {
     Duration    int       `json:"duration"`
     IsPremium   bool      `gorm:"default:false" json:"is_premium"`
     ImageURL    string    `gorm:"type:varchar(255)" json:"image_url"`
+    ScoringPolicy string  `gorm:"type:varchar(30);default:'percent_correct'" json:"scoring_policy"`
+    BlankPenalty  float64 `gorm:"type:decimal(6,2);default:0" json:"blank_penalty"`
+    PassingScore  *float64 `gorm:"type:decimal(8,2)" json:"passing_score"`
+    ShuffleQuestions bool   `gorm:"default:false" json:"shuffle_questions"`
+    ShuffleOptions   bool   `gorm:"default:false" json:"shuffle_options"`
+    // DrawCount > 0 serves only that many questions from the pool (per section when
+    // the test is sectioned). DrawTag and DrawDifficulty narrow the pool first.
+    DrawCount        int    `gorm:"default:0" json:"draw_count"`
+    DrawTag          string `gorm:"type:varchar(100)" json:"draw_tag"`
+    DrawDifficulty   string `gorm:"type:varchar(50)" json:"draw_difficulty"`
+    // Rows created before the workflow existed default to published so they stay
+    // visible; CreateTest always starts new tests as drafts.
+    Status         string     `gorm:"type:varchar(20);default:'published';index" json:"status"`
+    AvailableFrom  *time.Time `json:"available_from"`
+    AvailableUntil *time.Time `json:"available_until"`
+    // AbilityEstimator turns on IRT scoring: "eap" or "mle" estimates theta from the
+    // calibrated item parameters and maps it to ScaleMean + ScaleSD*theta. Empty keeps
+    // the test on raw scores only.
+    AbilityEstimator string  `gorm:"type:varchar(10)" json:"ability_estimator"`
+    ScaleMean        float64 `gorm:"type:decimal(8,2);default:500" json:"scale_mean"`
+    ScaleSD          float64 `gorm:"type:decimal(8,2);default:100" json:"scale_sd"`
+    // Adaptive tests pick each next question from the calibrated pool by the
+    // student's current ability and stop after AdaptiveMaxItems questions, or once
+    // AdaptiveMinItems are answered and the standard error is at most AdaptiveStopSE.
+    // AdaptiveMaxExposure caps the share of attempts that may see any one question.
+    Adaptive            bool    `gorm:"default:false" json:"adaptive"`
+    AdaptiveMinItems    int     `gorm:"default:0" json:"adaptive_min_items"`
+    AdaptiveMaxItems    int     `gorm:"default:0" json:"adaptive_max_items"`
+    AdaptiveStopSE      float64 `gorm:"type:decimal(6,3);default:0" json:"adaptive_stop_se"`
+    AdaptiveMaxExposure float64 `gorm:"type:decimal(4,3);default:0" json:"adaptive_max_exposure"`
+    // MaxAttempts limits how often a student may take the test (0 is unlimited) and
+    // AttemptCooldown is the minutes they must wait between attempts. OfficialResult
+    // picks the result that counts for leaderboards, analytics and certificates;
+    // after changing it, rebuild the leaderboard to re-mark earlier results.
+    MaxAttempts     int    `gorm:"default:0" json:"max_attempts"`
+    AttemptCooldown int    `gorm:"default:0" json:"attempt_cooldown"`
+    OfficialResult  string `gorm:"type:varchar(10);default:'best'" json:"official_result"`
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
     Questions   []Question `gorm:"foreignKey:TestID" json:"questions"`
-}
\ No newline at end of file
+    Sections    []TestSection `gorm:"foreignKey:TestID" json:"sections,omitempty"`
+}
+
+const (
+    // ScoringPercentCorrect counts every question once and ignores points and penalties.
+    ScoringPercentCorrect = "percent_correct"
+    // ScoringWeighted uses Question.Points and Question.Penalty plus Test.BlankPenalty.
+    ScoringWeighted = "weighted"
+)
+
+const (
+    TestStatusDraft     = "draft"
+    TestStatusReview    = "review"
+    TestStatusPublished = "published"
+    TestStatusArchived  = "archived"
+)
+
+const (
+    OfficialFirst   = "first"
+    OfficialBest    = "best"
+    OfficialLast    = "last"
+    OfficialAverage = "average"
+)
+
+const (
+    AbilityEAP = "eap"
+    AbilityMLE = "mle"
+)
+
+// IsAvailable reports whether the test is published and inside its availability
+// window at now. A nil bound leaves that side of the window open.
+func (t *Test) IsAvailable(now time.Time) bool {
+    if t.Status != TestStatusPublished {
+        return false
+    }
+    if t.AvailableFrom != nil && now.Before(*t.AvailableFrom) {
+        return false
+    }
+    if t.AvailableUntil != nil && !now.Before(*t.AvailableUntil) {
+        return false
+    }
+    return true
+}
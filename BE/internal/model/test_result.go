@@ -12,10 +12,47 @@ type TestResult struct {
 	Score          float64   `gorm:"type:decimal(5,2)"        json:"score"`
 	TotalQuestions int       `                                json:"total_questions"`
 	CorrectAnswers int       `                                json:"correct_answers"`
+	WrongAnswers   int       `                                json:"wrong_answers"`
+	BlankAnswers   int       `                                json:"blank_answers"`
+	RawPoints      float64   `gorm:"type:decimal(8,2)"        json:"raw_points"`
+	MaxPoints      float64   `gorm:"type:decimal(8,2)"        json:"max_points"`
+	Passed         *bool     `                                json:"passed"`
 	TimeSpent      int       `                                json:"time_spent"`
 	Answers        string    `gorm:"type:json"                json:"answers"`    
+	Status         string    `gorm:"type:varchar(20);default:'completed'" json:"status"`
 	CompletedAt    time.Time `                                json:"completed_at"`
+	FinalizedAt    *time.Time `                               json:"finalized_at,omitempty"`
+	InvalidatedReason string `gorm:"type:varchar(255)"        json:"invalidated_reason,omitempty"`
+
+	AttemptID      *uuid.UUID `gorm:"type:char(36)"          json:"attempt_id,omitempty"`
+
+	// IsOfficial marks the one completed result per student and test that counts,
+	// chosen by Test.OfficialResult. OfficialScore is the score it counts with: its
+	// own Score, or the mean of all attempts under the average policy.
+	IsOfficial     bool      `gorm:"default:false;index"     json:"is_official"`
+	OfficialScore  *float64  `gorm:"type:decimal(5,2)"        json:"official_score,omitempty"`
+
+	// IRT ability estimate and its reported scale score, set when the test uses an
+	// ability estimator and at least one answered question has item parameters.
+	// Score keeps the raw percentage either way.
+	Theta          *float64  `gorm:"type:decimal(8,4)"        json:"theta,omitempty"`
+	ThetaSE        *float64  `gorm:"type:decimal(8,4)"        json:"theta_se,omitempty"`
+	ScaledScore    *float64  `gorm:"type:decimal(8,2)"        json:"scaled_score,omitempty"`
+
+	// Place on the test leaderboard, filled in when results are read.
+	Rank           *int64    `gorm:"-"                       json:"rank,omitempty"`
+	Percentile     *float64  `gorm:"-"                       json:"percentile,omitempty"`
+	RankedCount    int64     `gorm:"-"                       json:"ranked_count,omitempty"`
 
 	User User `gorm:"foreignKey:UserID"`
 	Test Test `gorm:"foreignKey:TestID"`
-}
\ No newline at end of file
+	SectionScores []SectionScore `gorm:"foreignKey:TestResultID" json:"section_scores,omitempty"`
+}
+
+const (
+	ResultStatusCompleted     = "completed"
+	ResultStatusPendingReview = "pending_review"
+	// ResultStatusInvalidated results were voided by an admin, e.g. after an
+	// integrity review. They no longer count anywhere.
+	ResultStatusInvalidated   = "invalidated"
+)
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestSection splits a test into timed parts (e.g. TWK/TIU/TKP) that are taken in
+// Position order. Duration is in minutes, like Test.Duration, and PassingScore is
+// compared with the raw points earned in the section.
+type TestSection struct {
+	ID           uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID       uuid.UUID `gorm:"type:char(36);not null;index" json:"test_id"`
+	Title        string    `gorm:"type:varchar(255);not null" json:"title"`
+	Position     int       `gorm:"default:0" json:"position"`
+	Duration     int       `json:"duration"`
+	PassingScore *float64  `gorm:"type:decimal(8,2)" json:"passing_score"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
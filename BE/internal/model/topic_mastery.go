@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TopicMastery is a student's total for one topic across the official result of
+// every test they took. Mastery is PointsEarned over PointsPossible as a 0-100
+// percentage.
+type TopicMastery struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_mastery_user_topic" json:"user_id"`
+	Topic          string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_mastery_user_topic" json:"topic"`
+	Attempted      int       `json:"attempted"`
+	Correct        int       `json:"correct"`
+	Wrong          int       `json:"wrong"`
+	Blank          int       `json:"blank"`
+	PointsEarned   float64   `gorm:"type:decimal(10,2)" json:"points_earned"`
+	PointsPossible float64   `gorm:"type:decimal(10,2)" json:"points_possible"`
+	Mastery        float64   `gorm:"type:decimal(5,2)" json:"mastery"`
+	LastResultAt   time.Time `json:"last_result_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TopicSnapshot records how a student did on one topic in their official result on
+// a test, and their mastery right after it, so trends can be drawn over time. The
+// counts are kept so mastery can be rebuilt when the official result changes.
+type TopicSnapshot struct {
+	ID             uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;index:idx_snapshot_user_topic" json:"user_id"`
+	Topic          string    `gorm:"type:varchar(100);not null;index:idx_snapshot_user_topic" json:"topic"`
+	TestResultID   uuid.UUID `gorm:"type:char(36);not null;index" json:"test_result_id"`
+	TestID         uuid.UUID `gorm:"type:char(36)" json:"test_id"`
+	Questions      int       `json:"questions"`
+	Correct        int       `json:"correct"`
+	Wrong          int       `json:"wrong"`
+	Blank          int       `json:"blank"`
+	PointsEarned   float64   `gorm:"type:decimal(10,2)" json:"points_earned"`
+	PointsPossible float64   `gorm:"type:decimal(10,2)" json:"points_possible"`
+	Score          float64   `gorm:"type:decimal(5,2)" json:"score"`
+	Mastery        float64   `gorm:"type:decimal(5,2)" json:"mastery"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TryoutEvent is a scheduled sitting of a test for a registered cohort. Every
+// participant's attempt starts at StartsAt and ends at EndsAt (StartsAt plus the
+// test duration), however late they join. Nobody can join after LateJoinUntil.
+type TryoutEvent struct {
+	ID                   uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	TestID               uuid.UUID  `gorm:"type:char(36);not null;index" json:"test_id"`
+	Title                string     `gorm:"type:varchar(255);not null" json:"title"`
+	Description          string     `gorm:"type:text" json:"description"`
+	StartsAt             time.Time  `gorm:"index" json:"starts_at"`
+	LateJoinUntil        time.Time  `json:"late_join_until"`
+	EndsAt               time.Time  `json:"ends_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	Capacity             int        `gorm:"default:0" json:"capacity"`
+	CreatedBy            uuid.UUID  `gorm:"type:char(36)" json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+
+	Test Test `gorm:"foreignKey:TestID" json:"-"`
+}
+
+const (
+	EventPhaseScheduled = "scheduled"
+	EventPhaseRunning   = "running"
+	EventPhaseFinished  = "finished"
+)
+
+// Phase reports where the event is at now.
+func (e *TryoutEvent) Phase(now time.Time) string {
+	switch {
+	case now.Before(e.StartsAt):
+		return EventPhaseScheduled
+	case now.Before(e.EndsAt):
+		return EventPhaseRunning
+	default:
+		return EventPhaseFinished
+	}
+}
+
+// RegistrationOpen reports whether students can still register at now. Without an
+// explicit close time registration stays open until the late-join cutoff.
+func (e *TryoutEvent) RegistrationOpen(now time.Time) bool {
+	if e.RegistrationClosesAt != nil {
+		return now.Before(*e.RegistrationClosesAt)
+	}
+	return now.Before(e.LateJoinUntil)
+}
+
+// EventRegistration is a student's seat in an event. AttemptID is set once they join.
+type EventRegistration struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	EventID   uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_event_user" json:"event_id"`
+	UserID    uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_event_user" json:"user_id"`
+	AttemptID *uuid.UUID `gorm:"type:char(36)" json:"attempt_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MasteryRebuild recomputes a student's topic totals in place from all of their
+// snapshots, oldest first, and sets each snapshot's running Mastery. Topics missing
+// from the map are added by the rebuild; topics left with nothing attempted are
+// deleted.
+type MasteryRebuild func(snapshots []model.TopicSnapshot, masteries map[string]*model.TopicMastery)
+
+type AnalyticsRepository interface {
+	ReplaceTestSnapshots(userID, testID uuid.UUID, snapshots []model.TopicSnapshot, rebuild MasteryRebuild) error
+	FindMastery(userID uuid.UUID) ([]model.TopicMastery, error)
+	FindSnapshots(userID uuid.UUID) ([]model.TopicSnapshot, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db}
+}
+
+// ReplaceTestSnapshots swaps the snapshots a student has for one test for the given
+// ones and rebuilds their mastery from all snapshots left, in one transaction with
+// the student's mastery rows locked. Replacing with the same snapshots again
+// changes nothing, so a result can be applied more than once.
+func (r *analyticsRepository) ReplaceTestSnapshots(userID, testID uuid.UUID, snapshots []model.TopicSnapshot, rebuild MasteryRebuild) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var rows []model.TopicMastery
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND test_id = ?", userID, testID).Delete(&model.TopicSnapshot{}).Error; err != nil {
+			return err
+		}
+		if len(snapshots) > 0 {
+			if err := tx.Create(&snapshots).Error; err != nil {
+				return err
+			}
+		}
+
+		var all []model.TopicSnapshot
+		if err := tx.Where("user_id = ?", userID).Order("completed_at asc").Find(&all).Error; err != nil {
+			return err
+		}
+		masteries := make(map[string]*model.TopicMastery, len(rows))
+		for i := range rows {
+			masteries[rows[i].Topic] = &rows[i]
+		}
+		rebuild(all, masteries)
+
+		for i := range all {
+			if err := tx.Model(&all[i]).Update("mastery", all[i].Mastery).Error; err != nil {
+				return err
+			}
+		}
+		for _, mastery := range masteries {
+			if mastery.Attempted == 0 {
+				if err := tx.Delete(mastery).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Save(mastery).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *analyticsRepository) FindMastery(userID uuid.UUID) ([]model.TopicMastery, error) {
+	var masteries []model.TopicMastery
+	err := r.db.Where("user_id = ?", userID).Order("topic asc").Find(&masteries).Error
+	return masteries, err
+}
+
+// FindSnapshots returns the student's snapshots, oldest first.
+func (r *analyticsRepository) FindSnapshots(userID uuid.UUID) ([]model.TopicSnapshot, error) {
+	var snapshots []model.TopicSnapshot
+	err := r.db.Where("user_id = ?", userID).Order("completed_at asc").Find(&snapshots).Error
+	return snapshots, err
+}
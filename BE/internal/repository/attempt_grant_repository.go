@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttemptGrantRepository interface {
+	Create(grant *model.AttemptGrant) error
+	FindByTestID(testID uuid.UUID) ([]model.AttemptGrant, error)
+	SumExtra(testID, userID uuid.UUID) (int, error)
+}
+
+type attemptGrantRepository struct {
+	db *gorm.DB
+}
+
+func NewAttemptGrantRepository(db *gorm.DB) AttemptGrantRepository {
+	return &attemptGrantRepository{db}
+}
+
+// Create stores the grant only; the student it is for is never written through it.
+func (r *attemptGrantRepository) Create(grant *model.AttemptGrant) error {
+	return r.db.Omit("User").Create(grant).Error
+}
+
+func (r *attemptGrantRepository) FindByTestID(testID uuid.UUID) ([]model.AttemptGrant, error) {
+	var grants []model.AttemptGrant
+	err := r.db.Preload("User").Where("test_id = ?", testID).Order("created_at desc").Find(&grants).Error
+	return grants, err
+}
+
+// SumExtra returns the extra attempts granted to a student on a test.
+func (r *attemptGrantRepository) SumExtra(testID, userID uuid.UUID) (int, error) {
+	var total int
+	err := r.db.Model(&model.AttemptGrant{}).
+		Where("test_id = ? AND user_id = ?", testID, userID).
+		Select("COALESCE(SUM(extra_attempts), 0)").Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttemptRepository interface {
+	Create(attempt *model.Attempt) error
+	FindByID(id uuid.UUID) (*model.Attempt, error)
+	FindByIDs(ids []uuid.UUID) ([]model.Attempt, error)
+	Update(attempt *model.Attempt) error
+	Advance(attempt *model.Attempt, status string, section int) (bool, error)
+	CountByTestID(testID uuid.UUID) (int64, error)
+	FindByUserAndTest(userID, testID uuid.UUID) ([]model.Attempt, error)
+	FindByUserAndEvent(userID, eventID uuid.UUID) (*model.Attempt, error)
+	SaveDraft(draft *model.AttemptDraft) error
+	FindDraft(attemptID uuid.UUID) (*model.AttemptDraft, error)
+}
+
+type attemptRepository struct {
+	db *gorm.DB
+}
+
+func NewAttemptRepository(db *gorm.DB) AttemptRepository {
+	return &attemptRepository{db}
+}
+
+func (r *attemptRepository) Create(attempt *model.Attempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *attemptRepository) FindByID(id uuid.UUID) (*model.Attempt, error) {
+	var attempt model.Attempt
+	err := r.db.First(&attempt, "id = ?", id).Error
+	return &attempt, err
+}
+
+func (r *attemptRepository) FindByIDs(ids []uuid.UUID) ([]model.Attempt, error) {
+	var attempts []model.Attempt
+	if len(ids) == 0 {
+		return attempts, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *attemptRepository) Update(attempt *model.Attempt) error {
+	return r.db.Save(attempt).Error
+}
+
+// Advance saves the attempt only if the stored row is still in the given status and
+// section, and reports whether it did. Requests moving the same attempt on at once
+// race here; only one of them wins and the others must drop their copy.
+func (r *attemptRepository) Advance(attempt *model.Attempt, status string, section int) (bool, error) {
+	res := r.db.Model(attempt).Select("*").Omit("created_at").
+		Where("status = ? AND current_section = ?", status, section).Updates(attempt)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *attemptRepository) CountByTestID(testID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Attempt{}).Where("test_id = ?", testID).Count(&count).Error
+	return count, err
+}
+
+func (r *attemptRepository) SaveDraft(draft *model.AttemptDraft) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(draft).Error
+}
+
+// FindDraft returns the MySQL copy of an attempt's draft, or nil when there is none.
+func (r *attemptRepository) FindDraft(attemptID uuid.UUID) (*model.AttemptDraft, error) {
+	var drafts []model.AttemptDraft
+	if err := r.db.Where("attempt_id = ?", attemptID).Limit(1).Find(&drafts).Error; err != nil {
+		return nil, err
+	}
+	if len(drafts) == 0 {
+		return nil, nil
+	}
+	return &drafts[0], nil
+}
+
+func (r *attemptRepository) FindByUserAndTest(userID, testID uuid.UUID) ([]model.Attempt, error) {
+	var attempts []model.Attempt
+	err := r.db.Where("user_id = ? AND test_id = ?", userID, testID).Order("started_at asc").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *attemptRepository) FindByUserAndEvent(userID, eventID uuid.UUID) (*model.Attempt, error) {
+	var attempt model.Attempt
+	err := r.db.First(&attempt, "user_id = ? AND event_id = ?", userID, eventID).Error
+	return &attempt, err
+}
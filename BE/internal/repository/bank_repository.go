@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BankItemFilter struct {
+	Topic      string
+	Tag        string
+	Difficulty string
+	AuthorID   *uuid.UUID
+	Search     string
+}
+
+type BankRepository interface {
+	CreateItem(item *model.BankItem, version *model.BankItemVersion) error
+	FindItems(filter BankItemFilter) ([]model.BankItem, error)
+	FindItemByID(id uuid.UUID) (*model.BankItem, error)
+	UpdateItem(item *model.BankItem) error
+	AddVersion(item *model.BankItem, version *model.BankItemVersion) error
+	DeleteItem(id uuid.UUID) error
+	CountLinks(itemID uuid.UUID) (int64, error)
+
+	CreateLink(link *model.TestBankItem) error
+	FindLink(testID, itemID uuid.UUID) (*model.TestBankItem, error)
+	FindLinksByTestID(testID uuid.UUID) ([]model.TestBankItem, error)
+	UpdateLink(link *model.TestBankItem) error
+	DeleteLink(testID, itemID uuid.UUID) error
+}
+
+type bankRepository struct {
+	db *gorm.DB
+}
+
+func NewBankRepository(db *gorm.DB) BankRepository {
+	return &bankRepository{db}
+}
+
+func (r *bankRepository) CreateItem(item *model.BankItem, version *model.BankItemVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Versions").Create(item).Error; err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+}
+
+func (r *bankRepository) FindItems(filter BankItemFilter) ([]model.BankItem, error) {
+	var items []model.BankItem
+	query := r.db.Model(&model.BankItem{})
+	if filter.Topic != "" {
+		query = query.Where("topic = ?", filter.Topic)
+	}
+	if filter.Tag != "" {
+		query = query.Where("FIND_IN_SET(?, REPLACE(tags, ', ', ',')) > 0", filter.Tag)
+	}
+	if filter.Difficulty != "" {
+		query = query.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.AuthorID != nil {
+		query = query.Where("author_id = ?", *filter.AuthorID)
+	}
+	if filter.Search != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM bank_item_versions v WHERE v.bank_item_id = bank_items.id AND v.version = bank_items.current_version AND v.question_text LIKE ?)", "%"+filter.Search+"%")
+	}
+	err := query.Preload("Versions", "version = (SELECT current_version FROM bank_items WHERE bank_items.id = bank_item_versions.bank_item_id)").
+		Order("updated_at desc").Find(&items).Error
+	return items, err
+}
+
+func (r *bankRepository) FindItemByID(id uuid.UUID) (*model.BankItem, error) {
+	var item model.BankItem
+	err := r.db.Preload("Versions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("version desc")
+	}).First(&item, "id = ?", id).Error
+	return &item, err
+}
+
+func (r *bankRepository) UpdateItem(item *model.BankItem) error {
+	return r.db.Omit("Versions").Save(item).Error
+}
+
+// AddVersion stores a new version and makes it the item's current one.
+func (r *bankRepository) AddVersion(item *model.BankItem, version *model.BankItemVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		item.CurrentVersion = version.Version
+		return tx.Omit("Versions").Save(item).Error
+	})
+}
+
+func (r *bankRepository) DeleteItem(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.BankItemVersion{}, "bank_item_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.BankItem{}, "id = ?", id).Error
+	})
+}
+
+func (r *bankRepository) CountLinks(itemID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TestBankItem{}).Where("bank_item_id = ?", itemID).Count(&count).Error
+	return count, err
+}
+
+func (r *bankRepository) CreateLink(link *model.TestBankItem) error {
+	return r.db.Create(link).Error
+}
+
+func (r *bankRepository) FindLink(testID, itemID uuid.UUID) (*model.TestBankItem, error) {
+	var link model.TestBankItem
+	err := r.db.First(&link, "test_id = ? AND bank_item_id = ?", testID, itemID).Error
+	return &link, err
+}
+
+func (r *bankRepository) FindLinksByTestID(testID uuid.UUID) ([]model.TestBankItem, error) {
+	var links []model.TestBankItem
+	err := r.db.Where("test_id = ?", testID).Order("position asc").Find(&links).Error
+	return links, err
+}
+
+func (r *bankRepository) UpdateLink(link *model.TestBankItem) error {
+	return r.db.Save(link).Error
+}
+
+func (r *bankRepository) DeleteLink(testID, itemID uuid.UUID) error {
+	result := r.db.Delete(&model.TestBankItem{}, "test_id = ? AND bank_item_id = ?", testID, itemID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
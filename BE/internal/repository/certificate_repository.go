@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CertificateRepository interface {
+	Create(certificate *model.Certificate) error
+	Update(certificate *model.Certificate) error
+	FindByResultID(resultID uuid.UUID) (*model.Certificate, error)
+	FindByUserAndTest(userID, testID uuid.UUID) ([]model.Certificate, error)
+	FindBySerial(serial string) (*model.Certificate, error)
+
+	CreateCourse(certificate *model.CourseCertificate) error
+	UpdateCourse(certificate *model.CourseCertificate) error
+	FindCourse(classID, userID uuid.UUID) (*model.CourseCertificate, error)
+	FindCourseBySerial(serial string) (*model.CourseCertificate, error)
+}
+
+type certificateRepository struct {
+	db *gorm.DB
+}
+
+func NewCertificateRepository(db *gorm.DB) CertificateRepository {
+	return &certificateRepository{db}
+}
+
+func (r *certificateRepository) Create(certificate *model.Certificate) error {
+	return r.db.Create(certificate).Error
+}
+
+func (r *certificateRepository) Update(certificate *model.Certificate) error {
+	return r.db.Save(certificate).Error
+}
+
+func (r *certificateRepository) FindByResultID(resultID uuid.UUID) (*model.Certificate, error) {
+	var certificate model.Certificate
+	err := r.db.First(&certificate, "test_result_id = ?", resultID).Error
+	return &certificate, err
+}
+
+func (r *certificateRepository) FindByUserAndTest(userID, testID uuid.UUID) ([]model.Certificate, error) {
+	var certificates []model.Certificate
+	err := r.db.Where("user_id = ? AND test_id = ?", userID, testID).Find(&certificates).Error
+	return certificates, err
+}
+
+func (r *certificateRepository) FindBySerial(serial string) (*model.Certificate, error) {
+	var certificate model.Certificate
+	err := r.db.First(&certificate, "serial = ?", serial).Error
+	return &certificate, err
+}
+
+func (r *certificateRepository) CreateCourse(certificate *model.CourseCertificate) error {
+	return r.db.Create(certificate).Error
+}
+
+func (r *certificateRepository) UpdateCourse(certificate *model.CourseCertificate) error {
+	return r.db.Save(certificate).Error
+}
+
+func (r *certificateRepository) FindCourse(classID, userID uuid.UUID) (*model.CourseCertificate, error) {
+	var certificate model.CourseCertificate
+	err := r.db.First(&certificate, "class_id = ? AND user_id = ?", classID, userID).Error
+	return &certificate, err
+}
+
+func (r *certificateRepository) FindCourseBySerial(serial string) (*model.CourseCertificate, error) {
+	var certificate model.CourseCertificate
+	err := r.db.First(&certificate, "serial = ?", serial).Error
+	return &certificate, err
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClassContentRepository stores the modules, lessons and enrollments of premium
+// classes. Lookups take the class ID as well, so a lesson is never found through
+// another class.
+type ClassContentRepository interface {
+	CreateModule(module *model.ClassModule) error
+	FindModule(classID, moduleID uuid.UUID) (*model.ClassModule, error)
+	FindModules(classID uuid.UUID) ([]model.ClassModule, error)
+	UpdateModule(module *model.ClassModule) error
+	DeleteModule(classID, moduleID uuid.UUID) error
+	CountModules(classID uuid.UUID) (int64, error)
+
+	CreateLesson(lesson *model.Lesson) error
+	FindLesson(classID, lessonID uuid.UUID) (*model.Lesson, error)
+	UpdateLesson(lesson *model.Lesson) error
+	DeleteLesson(classID, lessonID uuid.UUID) error
+	CountLessons(moduleID uuid.UUID) (int64, error)
+
+	CreateEnrollment(enrollment *model.ClassEnrollment) error
+	UpdateEnrollment(enrollment *model.ClassEnrollment) error
+	FindEnrollment(classID, userID uuid.UUID) (*model.ClassEnrollment, error)
+	FindEnrollments(classID uuid.UUID) ([]model.ClassEnrollment, error)
+	FindEnrollmentsByUser(userID uuid.UUID) ([]model.ClassEnrollment, error)
+
+	FindProgress(userID, lessonID uuid.UUID) (*model.LessonProgress, error)
+	FindClassProgress(userID, classID uuid.UUID) ([]model.LessonProgress, error)
+	SaveProgress(progress *model.LessonProgress) error
+}
+
+type classContentRepository struct {
+	db *gorm.DB
+}
+
+func NewClassContentRepository(db *gorm.DB) ClassContentRepository {
+	return &classContentRepository{db}
+}
+
+func byPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc, created_at asc")
+}
+
+func (r *classContentRepository) CreateModule(module *model.ClassModule) error {
+	return r.db.Omit("Lessons").Create(module).Error
+}
+
+func (r *classContentRepository) FindModule(classID, moduleID uuid.UUID) (*model.ClassModule, error) {
+	var module model.ClassModule
+	err := r.db.First(&module, "id = ? AND class_id = ?", moduleID, classID).Error
+	return &module, err
+}
+
+// FindModules returns a class's modules with their lessons, both in order.
+func (r *classContentRepository) FindModules(classID uuid.UUID) ([]model.ClassModule, error) {
+	var modules []model.ClassModule
+	err := r.db.Preload("Lessons", byPosition).Scopes(byPosition).Where("class_id = ?", classID).Find(&modules).Error
+	return modules, err
+}
+
+func (r *classContentRepository) UpdateModule(module *model.ClassModule) error {
+	return r.db.Omit("Lessons").Save(module).Error
+}
+
+// DeleteModule deletes a module together with its lessons.
+func (r *classContentRepository) DeleteModule(classID, moduleID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("class_id = ? AND module_id = ?", classID, moduleID).Delete(&model.Lesson{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("class_id = ? AND id = ?", classID, moduleID).Delete(&model.ClassModule{}).Error
+	})
+}
+
+func (r *classContentRepository) CountModules(classID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ClassModule{}).Where("class_id = ?", classID).Count(&count).Error
+	return count, err
+}
+
+func (r *classContentRepository) CreateLesson(lesson *model.Lesson) error {
+	return r.db.Create(lesson).Error
+}
+
+func (r *classContentRepository) FindLesson(classID, lessonID uuid.UUID) (*model.Lesson, error) {
+	var lesson model.Lesson
+	err := r.db.First(&lesson, "id = ? AND class_id = ?", lessonID, classID).Error
+	return &lesson, err
+}
+
+func (r *classContentRepository) UpdateLesson(lesson *model.Lesson) error {
+	return r.db.Save(lesson).Error
+}
+
+func (r *classContentRepository) DeleteLesson(classID, lessonID uuid.UUID) error {
+	return r.db.Where("class_id = ? AND id = ?", classID, lessonID).Delete(&model.Lesson{}).Error
+}
+
+func (r *classContentRepository) CountLessons(moduleID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Lesson{}).Where("module_id = ?", moduleID).Count(&count).Error
+	return count, err
+}
+
+func (r *classContentRepository) CreateEnrollment(enrollment *model.ClassEnrollment) error {
+	return r.db.Omit("User", "Class").Create(enrollment).Error
+}
+
+func (r *classContentRepository) UpdateEnrollment(enrollment *model.ClassEnrollment) error {
+	return r.db.Omit("User", "Class").Save(enrollment).Error
+}
+
+func (r *classContentRepository) FindEnrollment(classID, userID uuid.UUID) (*model.ClassEnrollment, error) {
+	var enrollment model.ClassEnrollment
+	err := r.db.First(&enrollment, "class_id = ? AND user_id = ?", classID, userID).Error
+	return &enrollment, err
+}
+
+func (r *classContentRepository) FindEnrollments(classID uuid.UUID) ([]model.ClassEnrollment, error) {
+	var enrollments []model.ClassEnrollment
+	err := r.db.Preload("User").Where("class_id = ?", classID).Order("created_at desc").Find(&enrollments).Error
+	return enrollments, err
+}
+
+func (r *classContentRepository) FindEnrollmentsByUser(userID uuid.UUID) ([]model.ClassEnrollment, error) {
+	var enrollments []model.ClassEnrollment
+	err := r.db.Preload("Class").Where("user_id = ?", userID).Order("created_at desc").Find(&enrollments).Error
+	return enrollments, err
+}
+
+func (r *classContentRepository) FindProgress(userID, lessonID uuid.UUID) (*model.LessonProgress, error) {
+	var progress model.LessonProgress
+	err := r.db.First(&progress, "user_id = ? AND lesson_id = ?", userID, lessonID).Error
+	return &progress, err
+}
+
+func (r *classContentRepository) FindClassProgress(userID, classID uuid.UUID) ([]model.LessonProgress, error) {
+	var progress []model.LessonProgress
+	err := r.db.Where("user_id = ? AND class_id = ?", userID, classID).Find(&progress).Error
+	return progress, err
+}
+
+func (r *classContentRepository) SaveProgress(progress *model.LessonProgress) error {
+	return r.db.Save(progress).Error
+}
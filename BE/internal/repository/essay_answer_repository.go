@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EssayAnswerRepository interface {
+	FindPending(testID *uuid.UUID) ([]model.EssayAnswer, error)
+	FindByID(id uuid.UUID) (*model.EssayAnswer, error)
+	FindByResultID(resultID uuid.UUID) ([]model.EssayAnswer, error)
+	Update(answer *model.EssayAnswer) error
+}
+
+type essayAnswerRepository struct {
+	db *gorm.DB
+}
+
+func NewEssayAnswerRepository(db *gorm.DB) EssayAnswerRepository {
+	return &essayAnswerRepository{db}
+}
+
+func (r *essayAnswerRepository) FindPending(testID *uuid.UUID) ([]model.EssayAnswer, error) {
+	var answers []model.EssayAnswer
+	query := r.db.Where("status = ?", model.EssayStatusPending)
+	if testID != nil {
+		query = query.Where("test_id = ?", *testID)
+	}
+	err := query.Order("created_at asc").Find(&answers).Error
+	return answers, err
+}
+
+func (r *essayAnswerRepository) FindByID(id uuid.UUID) (*model.EssayAnswer, error) {
+	var answer model.EssayAnswer
+	err := r.db.First(&answer, "id = ?", id).Error
+	return &answer, err
+}
+
+func (r *essayAnswerRepository) FindByResultID(resultID uuid.UUID) ([]model.EssayAnswer, error) {
+	var answers []model.EssayAnswer
+	err := r.db.Where("test_result_id = ?", resultID).Find(&answers).Error
+	return answers, err
+}
+
+func (r *essayAnswerRepository) Update(answer *model.EssayAnswer) error {
+	return r.db.Save(answer).Error
+}
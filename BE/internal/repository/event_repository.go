@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EventRepository interface {
+	Create(event *model.TryoutEvent) error
+	FindUpcoming(now time.Time) ([]model.TryoutEvent, error)
+	FindAll() ([]model.TryoutEvent, error)
+	FindByID(id uuid.UUID) (*model.TryoutEvent, error)
+	Update(event *model.TryoutEvent) error
+	Delete(id uuid.UUID) error
+	CountByTestID(testID uuid.UUID) (int64, error)
+
+	RegisterWithinCapacity(registration *model.EventRegistration, capacity int) (bool, error)
+	FindRegistration(eventID, userID uuid.UUID) (*model.EventRegistration, error)
+	CountRegistrations(eventID uuid.UUID) (int64, error)
+	UpdateRegistration(registration *model.EventRegistration) error
+}
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) EventRepository {
+	return &eventRepository{db}
+}
+
+func (r *eventRepository) Create(event *model.TryoutEvent) error {
+	return r.db.Create(event).Error
+}
+
+// FindUpcoming returns events that have not ended yet, soonest first.
+func (r *eventRepository) FindUpcoming(now time.Time) ([]model.TryoutEvent, error) {
+	var events []model.TryoutEvent
+	err := r.db.Where("ends_at > ?", now).Order("starts_at asc").Find(&events).Error
+	return events, err
+}
+
+func (r *eventRepository) FindAll() ([]model.TryoutEvent, error) {
+	var events []model.TryoutEvent
+	err := r.db.Order("starts_at desc").Find(&events).Error
+	return events, err
+}
+
+func (r *eventRepository) FindByID(id uuid.UUID) (*model.TryoutEvent, error) {
+	var event model.TryoutEvent
+	err := r.db.First(&event, "id = ?", id).Error
+	return &event, err
+}
+
+func (r *eventRepository) Update(event *model.TryoutEvent) error {
+	return r.db.Omit("Test").Save(event).Error
+}
+
+// Delete removes the event with its registrations. Attempts and results already
+// taken in the event are kept.
+func (r *eventRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.EventRegistration{}, "event_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.TryoutEvent{}, "id = ?", id).Error
+	})
+}
+
+// CountByTestID counts the events, past or upcoming, that use the test as their paper.
+func (r *eventRepository) CountByTestID(testID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.TryoutEvent{}).Where("test_id = ?", testID).Count(&count).Error
+	return count, err
+}
+
+// RegisterWithinCapacity stores the registration if the event has fewer than capacity
+// registrations, and reports whether it did. A capacity of zero means no limit. The
+// event row is locked while registrations are counted, so two students cannot both
+// take the last place.
+func (r *eventRepository) RegisterWithinCapacity(registration *model.EventRegistration, capacity int) (bool, error) {
+	registered := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var event model.TryoutEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, "id = ?", registration.EventID).Error; err != nil {
+			return err
+		}
+		if capacity > 0 {
+			var count int64
+			if err := tx.Model(&model.EventRegistration{}).Where("event_id = ?", registration.EventID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= int64(capacity) {
+				return nil
+			}
+		}
+		if err := tx.Create(registration).Error; err != nil {
+			return err
+		}
+		registered = true
+		return nil
+	})
+	return registered, err
+}
+
+func (r *eventRepository) FindRegistration(eventID, userID uuid.UUID) (*model.EventRegistration, error) {
+	var registration model.EventRegistration
+	err := r.db.First(&registration, "event_id = ? AND user_id = ?", eventID, userID).Error
+	return &registration, err
+}
+
+func (r *eventRepository) CountRegistrations(eventID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.EventRegistration{}).Where("event_id = ?", eventID).Count(&count).Error
+	return count, err
+}
+
+func (r *eventRepository) UpdateRegistration(registration *model.EventRegistration) error {
+	return r.db.Save(registration).Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IntegrityRepository interface {
+	CreateEvents(events []model.AttemptEvent) error
+	CountEvents(attemptID uuid.UUID) (int64, error)
+	FindEvents(attemptID uuid.UUID) ([]model.AttemptEvent, error)
+	SaveReport(report *model.IntegrityReport) error
+	FindReport(attemptID uuid.UUID) (*model.IntegrityReport, error)
+	FindReports(status string, offset, limit int) ([]model.IntegrityReport, int64, error)
+	CreateSimilarityRun(run *model.SimilarityRun) error
+	FindSimilarityRuns(testID uuid.UUID) ([]model.SimilarityRun, error)
+	FindSimilarityRun(id uuid.UUID) (*model.SimilarityRun, error)
+}
+
+type integrityRepository struct {
+	db *gorm.DB
+}
+
+func NewIntegrityRepository(db *gorm.DB) IntegrityRepository {
+	return &integrityRepository{db}
+}
+
+func (r *integrityRepository) CreateEvents(events []model.AttemptEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.Create(&events).Error
+}
+
+func (r *integrityRepository) CountEvents(attemptID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.AttemptEvent{}).Where("attempt_id = ?", attemptID).Count(&count).Error
+	return count, err
+}
+
+func (r *integrityRepository) FindEvents(attemptID uuid.UUID) ([]model.AttemptEvent, error) {
+	var events []model.AttemptEvent
+	err := r.db.Where("attempt_id = ?", attemptID).Order("received_at asc").Find(&events).Error
+	return events, err
+}
+
+func (r *integrityRepository) SaveReport(report *model.IntegrityReport) error {
+	return r.db.Omit(clause.Associations).Save(report).Error
+}
+
+func (r *integrityRepository) FindReport(attemptID uuid.UUID) (*model.IntegrityReport, error) {
+	var report model.IntegrityReport
+	err := r.db.Preload("User").First(&report, "attempt_id = ?", attemptID).Error
+	return &report, err
+}
+
+// FindReports pages through reports with the given status, highest score first.
+func (r *integrityRepository) FindReports(status string, offset, limit int) ([]model.IntegrityReport, int64, error) {
+	var total int64
+	query := r.db.Model(&model.IntegrityReport{}).Where("status = ?", status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reports []model.IntegrityReport
+	err := query.Preload("User").Order("score desc, created_at asc").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, total, err
+}
+
+// CreateSimilarityRun stores a run together with its pairs.
+func (r *integrityRepository) CreateSimilarityRun(run *model.SimilarityRun) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Pairs").Create(run).Error; err != nil {
+			return err
+		}
+		if len(run.Pairs) == 0 {
+			return nil
+		}
+		return tx.Omit("SourceUser", "CopierUser").CreateInBatches(run.Pairs, 500).Error
+	})
+}
+
+// FindSimilarityRuns lists the runs of a test, newest first, without their pairs.
+func (r *integrityRepository) FindSimilarityRuns(testID uuid.UUID) ([]model.SimilarityRun, error) {
+	var runs []model.SimilarityRun
+	err := r.db.Where("test_id = ?", testID).Order("created_at desc").Find(&runs).Error
+	return runs, err
+}
+
+// FindSimilarityRun loads a run with its pairs, most significant first.
+func (r *integrityRepository) FindSimilarityRun(id uuid.UUID) (*model.SimilarityRun, error) {
+	var run model.SimilarityRun
+	err := r.db.Preload("Pairs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("p_value asc")
+	}).Preload("Pairs.SourceUser").Preload("Pairs.CopierUser").First(&run, "id = ?", id).Error
+	return &run, err
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ItemParameterRepository interface {
+	Save(params []model.ItemParameter) error
+	FindByQuestionIDs(ids []uuid.UUID) ([]model.ItemParameter, error)
+	FindByTestID(testID uuid.UUID) ([]model.ItemParameter, error)
+	IncrementExposure(testID, questionID uuid.UUID) error
+	FindExposures(testID uuid.UUID) ([]model.ItemExposure, error)
+}
+
+type itemParameterRepository struct {
+	db *gorm.DB
+}
+
+func NewItemParameterRepository(db *gorm.DB) ItemParameterRepository {
+	return &itemParameterRepository{db}
+}
+
+// Save inserts the parameters, replacing any earlier calibration of the same questions.
+func (r *itemParameterRepository) Save(params []model.ItemParameter) error {
+	if len(params) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&params).Error
+}
+
+func (r *itemParameterRepository) FindByQuestionIDs(ids []uuid.UUID) ([]model.ItemParameter, error) {
+	var params []model.ItemParameter
+	if len(ids) == 0 {
+		return params, nil
+	}
+	err := r.db.Where("question_id IN ?", ids).Find(&params).Error
+	return params, err
+}
+
+func (r *itemParameterRepository) FindByTestID(testID uuid.UUID) ([]model.ItemParameter, error) {
+	var params []model.ItemParameter
+	err := r.db.Where("test_id = ?", testID).Order("b").Find(&params).Error
+	return params, err
+}
+
+func (r *itemParameterRepository) IncrementExposure(testID, questionID uuid.UUID) error {
+	exposure := model.ItemExposure{TestID: testID, QuestionID: questionID, Served: 1}
+	return r.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"served": gorm.Expr("served + 1")}),
+	}).Create(&exposure).Error
+}
+
+func (r *itemParameterRepository) FindExposures(testID uuid.UUID) ([]model.ItemExposure, error) {
+	var exposures []model.ItemExposure
+	err := r.db.Where("test_id = ?", testID).Find(&exposures).Error
+	return exposures, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *model.Notification) error
+	FindByUserID(userID uuid.UUID) ([]model.Notification, error)
+	MarkAsRead(id, userID uuid.UUID) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db}
+}
+
+func (r *notificationRepository) Create(notification *model.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *notificationRepository) FindByUserID(userID uuid.UUID) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) MarkAsRead(id, userID uuid.UUID) error {
+	result := r.db.Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
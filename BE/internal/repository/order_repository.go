@@ -12,6 +12,7 @@ type OrderRepository interface {
 	Update(order *model.Order) error
 	FindByUserID(userID uuid.UUID) ([]model.Order, error)
 	FindAll() ([]model.Order, error) // <-- DITAMBAHKAN
+	FindCompleted(userID uuid.UUID, itemType string, itemID uuid.UUID) (*model.Order, error)
 }
 
 type orderRepository struct {
@@ -47,3 +48,11 @@ func (r *orderRepository) FindAll() ([]model.Order, error) {
 	err := r.db.Preload("User").Order("created_at desc").Find(&orders).Error
 	return orders, err
 }
+
+// FindCompleted returns the user's latest completed order for the item.
+func (r *orderRepository) FindCompleted(userID uuid.UUID, itemType string, itemID uuid.UUID) (*model.Order, error) {
+	var order model.Order
+	err := r.db.Where("user_id = ? AND item_type = ? AND item_id = ? AND status = ?", userID, itemType, itemID, "completed").
+		Order("updated_at desc").First(&order).Error
+	return &order, err
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrganizationRepository stores organizations and everything that belongs to one.
+// Every method below Create, FindAll and the per-user lookups takes the organization
+// ID and filters on it, so a caller holding the wrong organization finds nothing
+// rather than another school's data.
+type OrganizationRepository interface {
+	Create(org *model.Organization) error
+	FindAll() ([]model.Organization, error)
+	FindByID(id uuid.UUID) (*model.Organization, error)
+	FindMembershipsByUser(userID uuid.UUID) ([]model.OrganizationMember, error)
+	HasActiveSeat(userID uuid.UUID, at time.Time) (bool, error)
+
+	AddMember(member *model.OrganizationMember) error
+	FindMember(orgID, userID uuid.UUID) (*model.OrganizationMember, error)
+	FindMembers(orgID uuid.UUID, role string) ([]model.OrganizationMember, error)
+	UpdateMember(member *model.OrganizationMember) error
+	SeatMember(member *model.OrganizationMember, at time.Time) (bool, error)
+	RemoveMember(orgID, userID uuid.UUID) error
+	CountSeated(orgID uuid.UUID) (int64, error)
+
+	ActivateLicense(license *model.OrganizationLicense) (bool, error)
+	FindLicenses(orgID uuid.UUID) ([]model.OrganizationLicense, error)
+	ActiveSeats(orgID uuid.UUID, at time.Time) (int64, error)
+
+	CreateGroup(group *model.Group) error
+	FindGroup(orgID, groupID uuid.UUID) (*model.Group, error)
+	FindGroups(orgID uuid.UUID) ([]model.Group, error)
+	FindGroupsByTeacher(orgID, teacherID uuid.UUID) ([]model.Group, error)
+	FindGroupsByStudent(orgID, userID uuid.UUID) ([]model.Group, error)
+	AddGroupMembers(members []model.GroupMember) error
+	FindGroupMembers(orgID, groupID uuid.UUID) ([]model.GroupMember, error)
+	RemoveGroupMember(orgID, groupID, userID uuid.UUID) error
+
+	CreateAssignment(assignment *model.Assignment) error
+	FindAssignment(orgID, groupID, assignmentID uuid.UUID) (*model.Assignment, error)
+	FindAssignments(orgID, groupID uuid.UUID) ([]model.Assignment, error)
+	FindAssignmentsByStudent(userID uuid.UUID) ([]model.Assignment, error)
+	FindOverdueAssignments(at time.Time) ([]model.Assignment, error)
+	ClaimAssignmentReminder(assignment *model.Assignment, at time.Time) (bool, error)
+	FindAssignmentWork(assignment *model.Assignment, userIDs []uuid.UUID) ([]model.TestResult, []model.Attempt, error)
+	FindStudentResults(orgID, teacherID uuid.UUID, groupID, testID *uuid.UUID) ([]model.TestResult, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db}
+}
+
+// inOrganization limits a query on a table with an organization_id column.
+func inOrganization(orgID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("organization_id = ?", orgID)
+	}
+}
+
+func (r *organizationRepository) Create(org *model.Organization) error {
+	return r.db.Create(org).Error
+}
+
+func (r *organizationRepository) FindAll() ([]model.Organization, error) {
+	var orgs []model.Organization
+	err := r.db.Order("name asc").Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *organizationRepository) FindByID(id uuid.UUID) (*model.Organization, error) {
+	var org model.Organization
+	err := r.db.First(&org, "id = ?", id).Error
+	return &org, err
+}
+
+// FindMembershipsByUser lists the organizations a user belongs to, with their role.
+func (r *organizationRepository) FindMembershipsByUser(userID uuid.UUID) ([]model.OrganizationMember, error) {
+	var members []model.OrganizationMember
+	err := r.db.Preload("Organization").Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
+// HasActiveSeat reports whether the user holds a seat in any organization with an
+// unexpired license.
+func (r *organizationRepository) HasActiveSeat(userID uuid.UUID, at time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.OrganizationMember{}).
+		Joins("JOIN organization_licenses ON organization_licenses.organization_id = organization_members.organization_id").
+		Where("organization_members.user_id = ? AND organization_members.has_seat = ?", userID, true).
+		Where("organization_licenses.starts_at <= ? AND organization_licenses.expires_at > ?", at, at).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *organizationRepository) AddMember(member *model.OrganizationMember) error {
+	return r.db.Omit("User", "Organization").Create(member).Error
+}
+
+func (r *organizationRepository) FindMember(orgID, userID uuid.UUID) (*model.OrganizationMember, error) {
+	var member model.OrganizationMember
+	err := r.db.Scopes(inOrganization(orgID)).Preload("User").First(&member, "user_id = ?", userID).Error
+	return &member, err
+}
+
+// FindMembers lists an organization's members, or only those with role when it is set.
+func (r *organizationRepository) FindMembers(orgID uuid.UUID, role string) ([]model.OrganizationMember, error) {
+	var members []model.OrganizationMember
+	query := r.db.Scopes(inOrganization(orgID)).Preload("User")
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	err := query.Order("created_at asc").Find(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) UpdateMember(member *model.OrganizationMember) error {
+	return r.db.Scopes(inOrganization(member.OrganizationID)).Omit("User", "Organization").Save(member).Error
+}
+
+// SeatMember gives the member a seat if the organization's licenses running at the
+// time have one left, and reports whether it did. The organization row is locked
+// while the seats are counted, so two teachers cannot hand out the last seat twice.
+func (r *organizationRepository) SeatMember(member *model.OrganizationMember, at time.Time) (bool, error) {
+	seated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var org model.Organization
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&org, "id = ?", member.OrganizationID).Error; err != nil {
+			return err
+		}
+		var seats, used int64
+		if err := tx.Model(&model.OrganizationLicense{}).Scopes(inOrganization(member.OrganizationID)).
+			Where("starts_at <= ? AND expires_at > ?", at, at).
+			Select("COALESCE(SUM(seats), 0)").Scan(&seats).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.OrganizationMember{}).Scopes(inOrganization(member.OrganizationID)).
+			Where("has_seat = ?", true).Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= seats {
+			return nil
+		}
+		member.HasSeat = true
+		if err := tx.Scopes(inOrganization(member.OrganizationID)).Omit("User", "Organization").Save(member).Error; err != nil {
+			member.HasSeat = false
+			return err
+		}
+		seated = true
+		return nil
+	})
+	return seated, err
+}
+
+// RemoveMember takes the user out of the organization and all of its groups.
+func (r *organizationRepository) RemoveMember(orgID, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(inOrganization(orgID)).Where("user_id = ?", userID).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Scopes(inOrganization(orgID)).Where("user_id = ?", userID).Delete(&model.OrganizationMember{}).Error
+	})
+}
+
+func (r *organizationRepository) CountSeated(orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.OrganizationMember{}).Scopes(inOrganization(orgID)).Where("has_seat = ?", true).Count(&count).Error
+	return count, err
+}
+
+// ActivateLicense completes the license's order and creates the license together,
+// and reports whether it did. An order that is already completed is left alone, so
+// verifying it twice never adds a second license.
+func (r *organizationRepository) ActivateLicense(license *model.OrganizationLicense) (bool, error) {
+	activated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		completed := tx.Model(&model.Order{}).Where("id = ? AND status <> ?", license.OrderID, "completed").Update("status", "completed")
+		if completed.Error != nil || completed.RowsAffected == 0 {
+			return completed.Error
+		}
+		if err := tx.Create(license).Error; err != nil {
+			return err
+		}
+		activated = true
+		return nil
+	})
+	return activated, err
+}
+
+func (r *organizationRepository) FindLicenses(orgID uuid.UUID) ([]model.OrganizationLicense, error) {
+	var licenses []model.OrganizationLicense
+	err := r.db.Scopes(inOrganization(orgID)).Order("expires_at desc").Find(&licenses).Error
+	return licenses, err
+}
+
+// ActiveSeats adds up the seats of the organization's licenses running at the time.
+func (r *organizationRepository) ActiveSeats(orgID uuid.UUID, at time.Time) (int64, error) {
+	var seats int64
+	err := r.db.Model(&model.OrganizationLicense{}).Scopes(inOrganization(orgID)).
+		Where("starts_at <= ? AND expires_at > ?", at, at).
+		Select("COALESCE(SUM(seats), 0)").Scan(&seats).Error
+	return seats, err
+}
+
+func (r *organizationRepository) CreateGroup(group *model.Group) error {
+	return r.db.Omit("Teacher").Create(group).Error
+}
+
+func (r *organizationRepository) FindGroup(orgID, groupID uuid.UUID) (*model.Group, error) {
+	var group model.Group
+	err := r.db.Scopes(inOrganization(orgID)).Preload("Teacher").First(&group, "id = ?", groupID).Error
+	return &group, err
+}
+
+func (r *organizationRepository) FindGroups(orgID uuid.UUID) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Scopes(inOrganization(orgID)).Preload("Teacher").Order("name asc").Find(&groups).Error
+	return groups, err
+}
+
+func (r *organizationRepository) FindGroupsByTeacher(orgID, teacherID uuid.UUID) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Scopes(inOrganization(orgID)).Preload("Teacher").Where("teacher_id = ?", teacherID).Order("name asc").Find(&groups).Error
+	return groups, err
+}
+
+func (r *organizationRepository) FindGroupsByStudent(orgID, userID uuid.UUID) ([]model.Group, error) {
+	var groups []model.Group
+	err := r.db.Preload("Teacher").
+		Where("student_groups.organization_id = ?", orgID).
+		Where("student_groups.id IN (?)", r.db.Model(&model.GroupMember{}).Select("group_id").
+			Where("organization_id = ? AND user_id = ?", orgID, userID)).
+		Order("name asc").Find(&groups).Error
+	return groups, err
+}
+
+// AddGroupMembers adds students to a group, skipping those already in it.
+func (r *organizationRepository) AddGroupMembers(members []model.GroupMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return r.db.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+func (r *organizationRepository) FindGroupMembers(orgID, groupID uuid.UUID) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	err := r.db.Scopes(inOrganization(orgID)).Preload("User").Where("group_id = ?", groupID).Order("created_at asc").Find(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) RemoveGroupMember(orgID, groupID, userID uuid.UUID) error {
+	return r.db.Scopes(inOrganization(orgID)).Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+}
+
+func (r *organizationRepository) CreateAssignment(assignment *model.Assignment) error {
+	return r.db.Omit("Test", "Group").Create(assignment).Error
+}
+
+func (r *organizationRepository) FindAssignment(orgID, groupID, assignmentID uuid.UUID) (*model.Assignment, error) {
+	var assignment model.Assignment
+	err := r.db.Scopes(inOrganization(orgID)).Preload("Test").Preload("Group").
+		Where("group_id = ?", groupID).First(&assignment, "id = ?", assignmentID).Error
+	return &assignment, err
+}
+
+func (r *organizationRepository) FindAssignments(orgID, groupID uuid.UUID) ([]model.Assignment, error) {
+	var assignments []model.Assignment
+	err := r.db.Scopes(inOrganization(orgID)).Preload("Test").Where("group_id = ?", groupID).Order("due_at asc").Find(&assignments).Error
+	return assignments, err
+}
+
+// FindAssignmentsByStudent lists the assignments of every group the user is a
+// student in, across their organizations, soonest due first.
+func (r *organizationRepository) FindAssignmentsByStudent(userID uuid.UUID) ([]model.Assignment, error) {
+	var assignments []model.Assignment
+	err := r.db.Preload("Test").Preload("Group").
+		Joins("JOIN group_members ON group_members.group_id = assignments.group_id AND group_members.organization_id = assignments.organization_id").
+		Where("group_members.user_id = ?", userID).
+		Order("assignments.due_at asc").Find(&assignments).Error
+	return assignments, err
+}
+
+// FindOverdueAssignments lists assignments past their due date whose overdue
+// reminders have not been sent.
+func (r *organizationRepository) FindOverdueAssignments(at time.Time) ([]model.Assignment, error) {
+	var assignments []model.Assignment
+	err := r.db.Preload("Test").Where("due_at <= ? AND overdue_reminded_at IS NULL", at).Find(&assignments).Error
+	return assignments, err
+}
+
+// ClaimAssignmentReminder marks the assignment reminded and reports whether this
+// call did so, so only one of several reminder runs sends its reminders.
+func (r *organizationRepository) ClaimAssignmentReminder(assignment *model.Assignment, at time.Time) (bool, error) {
+	claimed := r.db.Model(&model.Assignment{}).Scopes(inOrganization(assignment.OrganizationID)).
+		Where("id = ? AND overdue_reminded_at IS NULL", assignment.ID).Update("overdue_reminded_at", at)
+	if claimed.Error != nil {
+		return false, claimed.Error
+	}
+	if claimed.RowsAffected != 1 {
+		return false, nil
+	}
+	assignment.OverdueRemindedAt = &at
+	return true, nil
+}
+
+// FindAssignmentWork returns what the given students did on the assignment's test
+// since it was assigned: their results, oldest first, except invalidated ones, and
+// their attempts. Only students of the assignment's group are looked at.
+func (r *organizationRepository) FindAssignmentWork(assignment *model.Assignment, userIDs []uuid.UUID) ([]model.TestResult, []model.Attempt, error) {
+	if len(userIDs) == 0 {
+		return nil, nil, nil
+	}
+	students := r.db.Model(&model.GroupMember{}).Select("user_id").
+		Where("organization_id = ? AND group_id = ? AND user_id IN ?", assignment.OrganizationID, assignment.GroupID, userIDs)
+
+	var results []model.TestResult
+	err := r.db.Where("test_id = ? AND user_id IN (?) AND completed_at >= ? AND status <> ?",
+		assignment.TestID, students, assignment.CreatedAt, model.ResultStatusInvalidated).
+		Order("completed_at asc").Find(&results).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	var attempts []model.Attempt
+	err = r.db.Where("test_id = ? AND user_id IN (?) AND started_at >= ?", assignment.TestID, students, assignment.CreatedAt).
+		Order("started_at asc").Find(&attempts).Error
+	return results, attempts, err
+}
+
+// FindStudentResults returns the results of students in the teacher's groups of the
+// organization on tests assigned to their own group, newest first, optionally
+// narrowed to one group and one test. As in FindAssignmentWork only results completed
+// since the test was assigned count. Invalidated results are left out.
+func (r *organizationRepository) FindStudentResults(orgID, teacherID uuid.UUID, groupID, testID *uuid.UUID) ([]model.TestResult, error) {
+	// What the students took on their own, or before it was assigned, is none of
+	// the school's business.
+	assigned := r.db.Model(&model.GroupMember{}).Select("1").
+		Joins("JOIN student_groups ON student_groups.id = group_members.group_id").
+		Joins("JOIN assignments ON assignments.group_id = group_members.group_id").
+		Where("group_members.user_id = test_results.user_id AND assignments.test_id = test_results.test_id AND test_results.completed_at >= assignments.created_at").
+		Where("group_members.organization_id = ? AND student_groups.organization_id = ? AND assignments.organization_id = ? AND student_groups.teacher_id = ?", orgID, orgID, orgID, teacherID)
+	if groupID != nil {
+		assigned = assigned.Where("group_members.group_id = ?", *groupID)
+	}
+	query := r.db.Preload("User").Preload("Test").
+		Where("EXISTS (?) AND status <> ?", assigned, model.ResultStatusInvalidated)
+	if testID != nil {
+		query = query.Where("test_id = ?", *testID)
+	}
+	var results []model.TestResult
+	err := query.Order("completed_at desc").Find(&results).Error
+	return results, err
+}
@@ -8,8 +8,11 @@ import (
 
 type QuestionRepository interface {
 	Create(question *model.Question) error
+	CreateBatch(questions []model.Question) error
 	FindByTestID(testID uuid.UUID) ([]model.Question, error)
 	FindByID(id uuid.UUID) (*model.Question, error)
+	FindByIDs(testID uuid.UUID, ids []uuid.UUID) ([]model.Question, error)
+	CountByTopics(topics []string) (map[uuid.UUID]map[string]int, error)
 	Update(question *model.Question) error
 	Delete(id uuid.UUID) error
 }
@@ -26,10 +29,27 @@ func (r *questionRepository) Create(question *model.Question) error {
 	return r.db.Create(question).Error
 }
 
+// CreateBatch inserts all questions or none of them.
+func (r *questionRepository) CreateBatch(questions []model.Question) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(questions, 100).Error
+	})
+}
+
+// FindByTestID returns the test's own questions followed by the bank items linked to
+// it, each resolved to its pinned or current version.
 func (r *questionRepository) FindByTestID(testID uuid.UUID) ([]model.Question, error) {
 	var questions []model.Question
 	err := r.db.Where("test_id = ?", testID).Find(&questions).Error
-	return questions, err
+	if err != nil {
+		return nil, err
+	}
+
+	bankQuestions, err := r.findBankQuestions(testID)
+	if err != nil {
+		return nil, err
+	}
+	return append(questions, bankQuestions...), nil
 }
 
 func (r *questionRepository) FindByID(id uuid.UUID) (*model.Question, error) {
@@ -38,10 +58,131 @@ func (r *questionRepository) FindByID(id uuid.UUID) (*model.Question, error) {
 	return &question, err
 }
 
+// FindByIDs resolves question IDs as stored in answers: the test's own questions and
+// bank item versions, whether or not the version is still the one linked. Bank
+// questions take their points and section from the test's link when it still exists.
+func (r *questionRepository) FindByIDs(testID uuid.UUID, ids []uuid.UUID) ([]model.Question, error) {
+	var questions []model.Question
+	if len(ids) == 0 {
+		return questions, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []bankQuestionRow
+	err := r.db.Table("bank_item_versions AS v").
+		Select("v.*, l.section_id, COALESCE(l.position, 0) AS position, COALESCE(l.points, 1) AS points, COALESCE(l.penalty, 0) AS penalty, i.tags, i.difficulty, i.topic").
+		Joins("JOIN bank_items AS i ON i.id = v.bank_item_id").
+		Joins("LEFT JOIN test_bank_items AS l ON l.bank_item_id = v.bank_item_id AND l.test_id = ?", testID).
+		Where("v.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(questions, bankRowsToQuestions(testID, rows)...), nil
+}
+
+type topicCountRow struct {
+	TestID uuid.UUID
+	Topic  string
+	Count  int
+}
+
+// CountByTopics counts, per test and topic, the questions a test serves on the given
+// topics, including questions linked from the bank.
+func (r *questionRepository) CountByTopics(topics []string) (map[uuid.UUID]map[string]int, error) {
+	counts := make(map[uuid.UUID]map[string]int)
+	if len(topics) == 0 {
+		return counts, nil
+	}
+
+	var rows, bankRows []topicCountRow
+	err := r.db.Model(&model.Question{}).
+		Select("test_id, topic, COUNT(*) AS count").
+		Where("topic IN ?", topics).
+		Group("test_id, topic").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Table("test_bank_items AS l").
+		Select("l.test_id, i.topic, COUNT(*) AS count").
+		Joins("JOIN bank_items AS i ON i.id = l.bank_item_id").
+		Where("i.topic IN ?", topics).
+		Group("l.test_id, i.topic").
+		Scan(&bankRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range append(rows, bankRows...) {
+		if counts[row.TestID] == nil {
+			counts[row.TestID] = make(map[string]int)
+		}
+		counts[row.TestID][row.Topic] += row.Count
+	}
+	return counts, nil
+}
+
 func (r *questionRepository) Update(question *model.Question) error {
 	return r.db.Save(question).Error
 }
 
 func (r *questionRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&model.Question{}, "id = ?", id).Error
-}
\ No newline at end of file
+}
+
+type bankQuestionRow struct {
+	model.BankItemVersion
+	SectionID  *uuid.UUID
+	Position   int
+	Points     float64
+	Penalty    float64
+	Tags       string
+	Difficulty string
+	Topic      string
+}
+
+func (r *questionRepository) findBankQuestions(testID uuid.UUID) ([]model.Question, error) {
+	var rows []bankQuestionRow
+	err := r.db.Table("test_bank_items AS l").
+		Select("v.*, l.section_id, l.position, l.points, l.penalty, i.tags, i.difficulty, i.topic").
+		Joins("JOIN bank_items AS i ON i.id = l.bank_item_id").
+		Joins("JOIN bank_item_versions AS v ON v.bank_item_id = l.bank_item_id AND v.version = COALESCE(l.pinned_version, i.current_version)").
+		Where("l.test_id = ?", testID).
+		Order("l.position asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return bankRowsToQuestions(testID, rows), nil
+}
+
+func bankRowsToQuestions(testID uuid.UUID, rows []bankQuestionRow) []model.Question {
+	questions := make([]model.Question, 0, len(rows))
+	for _, row := range rows {
+		bankItemID := row.BankItemID
+		questions = append(questions, model.Question{
+			ID:            row.ID,
+			TestID:        testID,
+			SectionID:     row.SectionID,
+			Position:      row.Position,
+			QuestionText:  row.QuestionText,
+			Options:       row.Options,
+			CorrectAnswer: row.CorrectAnswer,
+			Explanation:   row.Explanation,
+			Type:          row.Type,
+			Rubric:        row.Rubric,
+			MaxScore:      row.MaxScore,
+			Points:        row.Points,
+			Penalty:       row.Penalty,
+			Tags:          row.Tags,
+			Difficulty:    row.Difficulty,
+			Topic:         row.Topic,
+			BankItemID:    &bankItemID,
+			BankVersion:   row.Version,
+		})
+	}
+	return questions
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+    "time"
+
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
     "github.com/google/uuid"
     "gorm.io/gorm"
@@ -9,6 +11,7 @@ import (
 type TestRepository interface {
     Create(test *model.Test) error
     FindAll() ([]model.Test, error)
+    FindAvailable(now time.Time) ([]model.Test, error)
     FindByID(id uuid.UUID) (*model.Test, error)
     Update(test *model.Test) error
     Delete(id uuid.UUID) error
@@ -32,14 +35,27 @@ func (r *testRepository) FindAll() ([]model.Test, error) {
     return tests, err
 }
 
+// FindAvailable returns the published tests whose availability window contains now.
+func (r *testRepository) FindAvailable(now time.Time) ([]model.Test, error) {
+    var tests []model.Test
+    err := r.db.Preload("Questions").
+        Where("status = ?", model.TestStatusPublished).
+        Where("available_from IS NULL OR available_from <= ?", now).
+        Where("available_until IS NULL OR available_until > ?", now).
+        Find(&tests).Error
+    return tests, err
+}
+
 func (r *testRepository) FindByID(id uuid.UUID) (*model.Test, error) {
     var test model.Test
-    err := r.db.Preload("Questions").First(&test, "id = ?", id).Error
+    err := r.db.Preload("Questions").Preload("Sections", func(db *gorm.DB) *gorm.DB {
+        return db.Order("position asc")
+    }).First(&test, "id = ?", id).Error
     return &test, err
 }
 
 func (r *testRepository) Update(test *model.Test) error {
-    return r.db.Save(test).Error
+    return r.db.Omit("Sections").Save(test).Error
 }
 
 func (r *testRepository) Delete(id uuid.UUID) error {
@@ -4,11 +4,23 @@ import (
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TestResultRepository interface {
 	Create(result *model.TestResult) error
+	CreateWithEssayAnswers(result *model.TestResult, essays []model.EssayAnswer) error
+	FindByID(id uuid.UUID) (*model.TestResult, error)
 	FindByUserID(userID uuid.UUID) ([]model.TestResult, error)
+	FindByIDs(ids []uuid.UUID) ([]model.TestResult, error)
+	FindFinalByEventID(eventID uuid.UUID) ([]model.TestResult, error)
+	FindFinalByTestID(testID uuid.UUID) ([]model.TestResult, error)
+	FindByUserAndTest(userID, testID uuid.UUID) ([]model.TestResult, error)
+	FindOfficial(testID, userID uuid.UUID) (*model.TestResult, error)
+	SetOfficial(testID, userID, resultID uuid.UUID, score float64) error
+	ClearOfficial(testID, userID uuid.UUID) error
+	Update(result *model.TestResult) error
+	FinalizePending(result *model.TestResult) (bool, error)
 }
 
 type testResultRepository struct {
@@ -23,8 +35,129 @@ func (r *testResultRepository) Create(result *model.TestResult) error {
 	return r.db.Create(result).Error
 }
 
+// CreateWithEssayAnswers stores the result, its section scores and its grading queue
+// entries together, so a result is never left in pending_review without anything
+// to review.
+func (r *testResultRepository) CreateWithEssayAnswers(result *model.TestResult, essays []model.EssayAnswer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+		if len(essays) == 0 {
+			return nil
+		}
+		return tx.Create(&essays).Error
+	})
+}
+
+func (r *testResultRepository) FindByID(id uuid.UUID) (*model.TestResult, error) {
+	var result model.TestResult
+	err := r.db.Preload("Test").Preload("SectionScores", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc")
+	}).First(&result, "id = ?", id).Error
+	return &result, err
+}
+
 func (r *testResultRepository) FindByUserID(userID uuid.UUID) ([]model.TestResult, error) {
 	var results []model.TestResult
 	err := r.db.Preload("Test").Where("user_id = ?", userID).Find(&results).Error
 	return results, err
-}
\ No newline at end of file
+}
+
+func (r *testResultRepository) Update(result *model.TestResult) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(result).Error; err != nil {
+			return err
+		}
+		for i := range result.SectionScores {
+			if err := tx.Save(&result.SectionScores[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FinalizePending saves a result that is leaving pending_review, but only while the
+// stored row is still pending_review. It reports whether this call finalized it, so
+// graders finishing the last essays at the same time finalize the result once.
+func (r *testResultRepository) FinalizePending(result *model.TestResult) (bool, error) {
+	finalized := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(result).Select("*").Omit(clause.Associations).
+			Where("status = ?", model.ResultStatusPendingReview).Updates(result)
+		if res.Error != nil || res.RowsAffected != 1 {
+			return res.Error
+		}
+		for i := range result.SectionScores {
+			if err := tx.Save(&result.SectionScores[i]).Error; err != nil {
+				return err
+			}
+		}
+		finalized = true
+		return nil
+	})
+	return finalized, err
+}
+
+// FindByIDs loads results with their users, in no particular order.
+func (r *testResultRepository) FindByIDs(ids []uuid.UUID) ([]model.TestResult, error) {
+	var results []model.TestResult
+	if len(ids) == 0 {
+		return results, nil
+	}
+	err := r.db.Preload("User").Where("id IN ?", ids).Find(&results).Error
+	return results, err
+}
+
+// FindFinalByEventID returns the completed results of attempts taken in a tryout event.
+func (r *testResultRepository) FindFinalByEventID(eventID uuid.UUID) ([]model.TestResult, error) {
+	var results []model.TestResult
+	err := r.db.Joins("JOIN attempts ON attempts.id = test_results.attempt_id").
+		Where("attempts.event_id = ? AND test_results.status = ?", eventID, model.ResultStatusCompleted).
+		Find(&results).Error
+	return results, err
+}
+
+// FindFinalByTestID returns every completed result of a test.
+func (r *testResultRepository) FindFinalByTestID(testID uuid.UUID) ([]model.TestResult, error) {
+	var results []model.TestResult
+	err := r.db.Where("test_id = ? AND status = ?", testID, model.ResultStatusCompleted).Find(&results).Error
+	return results, err
+}
+
+// FindByUserAndTest returns all of a student's results on a test, oldest first.
+func (r *testResultRepository) FindByUserAndTest(userID, testID uuid.UUID) ([]model.TestResult, error) {
+	var results []model.TestResult
+	err := r.db.Where("user_id = ? AND test_id = ?", userID, testID).Order("completed_at asc").Find(&results).Error
+	return results, err
+}
+
+func (r *testResultRepository) FindOfficial(testID, userID uuid.UUID) (*model.TestResult, error) {
+	var result model.TestResult
+	err := r.db.First(&result, "test_id = ? AND user_id = ? AND is_official = ?", testID, userID, true).Error
+	return &result, err
+}
+
+// SetOfficial makes resultID the student's official result on the test with the given
+// score and clears the mark from their other results.
+func (r *testResultRepository) SetOfficial(testID, userID, resultID uuid.UUID, score float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.TestResult{}).
+			Where("test_id = ? AND user_id = ? AND id <> ? AND is_official = ?", testID, userID, resultID, true).
+			Updates(map[string]interface{}{"is_official": false, "official_score": nil}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.TestResult{}).Where("id = ?", resultID).
+			Updates(map[string]interface{}{"is_official": true, "official_score": score}).Error
+	})
+}
+
+// ClearOfficial removes the official mark from all of a student's results on the test,
+// for when none of them counts any more.
+func (r *testResultRepository) ClearOfficial(testID, userID uuid.UUID) error {
+	return r.db.Model(&model.TestResult{}).
+		Where("test_id = ? AND user_id = ? AND is_official = ?", testID, userID, true).
+		Updates(map[string]interface{}{"is_official": false, "official_score": nil}).Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TestSectionRepository interface {
+	Create(section *model.TestSection) error
+	FindByID(id uuid.UUID) (*model.TestSection, error)
+	FindByTestID(testID uuid.UUID) ([]model.TestSection, error)
+	Update(section *model.TestSection) error
+	Delete(id uuid.UUID) error
+}
+
+type testSectionRepository struct {
+	db *gorm.DB
+}
+
+func NewTestSectionRepository(db *gorm.DB) TestSectionRepository {
+	return &testSectionRepository{db}
+}
+
+func (r *testSectionRepository) Create(section *model.TestSection) error {
+	return r.db.Create(section).Error
+}
+
+func (r *testSectionRepository) FindByID(id uuid.UUID) (*model.TestSection, error) {
+	var section model.TestSection
+	err := r.db.First(&section, "id = ?", id).Error
+	return &section, err
+}
+
+func (r *testSectionRepository) FindByTestID(testID uuid.UUID) ([]model.TestSection, error) {
+	var sections []model.TestSection
+	err := r.db.Where("test_id = ?", testID).Order("position asc").Find(&sections).Error
+	return sections, err
+}
+
+func (r *testSectionRepository) Update(section *model.TestSection) error {
+	return r.db.Save(section).Error
+}
+
+// Delete removes the section and moves its questions back to the unsectioned pool.
+func (r *testSectionRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Question{}).Where("section_id = ?", id).Update("section_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.TestSection{}, "id = ?", id).Error
+	})
+}
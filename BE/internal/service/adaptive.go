@@ -0,0 +1,410 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/psychometrics"
+	"github.com/google/uuid"
+)
+
+// Defaults for adaptive tests that leave their stopping rule at zero.
+const (
+	adaptiveDefaultMinItems = 5
+	adaptiveDefaultMaxItems = 20
+	adaptiveDefaultStopSE   = 0.3
+	// adaptiveRandomesque is how many of the most informative questions the next one
+	// is drawn from, so students of similar ability do not all get the same sequence.
+	adaptiveRandomesque = 5
+	// adaptiveExposureWarmup is how many attempts a test needs before the exposure
+	// cap applies; with fewer the observed rates are too noisy to act on.
+	adaptiveExposureWarmup = 20
+)
+
+type AnswerQuestionRequest struct {
+	QuestionID     string `json:"question_id" validate:"required,uuid"`
+	SelectedAnswer int    `json:"selected_answer"`
+	TimeSpent      int    `json:"time_spent"`
+}
+
+// AdaptiveProgress tells an adaptive attempt how far it is. The test may end before
+// MaxItems once the ability estimate is precise enough.
+type AdaptiveProgress struct {
+	Answered int `json:"answered"`
+	MaxItems int `json:"max_items"`
+}
+
+// adaptiveItem is a question of the adaptive pool with its calibrated parameters.
+type adaptiveItem struct {
+	question model.Question
+	params   psychometrics.ItemParams
+}
+
+// adaptiveState is an adaptive attempt decoded: the questions served so far in order
+// and the answers given, one per served question except the one still open.
+type adaptiveState struct {
+	pool    map[uuid.UUID]adaptiveItem
+	layout  []model.AttemptLayoutItem
+	answers []UserAnswer
+}
+
+func (st *adaptiveState) pending() *model.AttemptLayoutItem {
+	if len(st.layout) > len(st.answers) {
+		return &st.layout[len(st.layout)-1]
+	}
+	return nil
+}
+
+func (st *adaptiveState) served() []model.Question {
+	questions := make([]model.Question, 0, len(st.layout))
+	for _, item := range st.layout {
+		if pooled, ok := st.pool[item.QuestionID]; ok {
+			questions = append(questions, pooled.question)
+		}
+	}
+	return questions
+}
+
+// ability is the EAP estimate from the answered questions, starting from a standard
+// normal prior.
+func (st *adaptiveState) ability() psychometrics.Ability {
+	correct := make(map[string]bool, len(st.answers))
+	for _, answer := range st.answers {
+		questionUUID, err := uuid.Parse(answer.QuestionID)
+		if err != nil {
+			continue
+		}
+		correct[answer.QuestionID] = answer.SelectedAnswer == st.pool[questionUUID].question.CorrectAnswer
+	}
+	var items []psychometrics.ItemParams
+	var responses []int
+	for _, item := range st.layout {
+		got, answered := correct[item.QuestionID.String()]
+		if !answered {
+			continue
+		}
+		items = append(items, st.pool[item.QuestionID].params)
+		if got {
+			responses = append(responses, 1)
+		} else {
+			responses = append(responses, 0)
+		}
+	}
+	return psychometrics.EAP(responses, items, psychometrics.NormalQuadrature(61))
+}
+
+func adaptiveStopRule(test *model.Test) (minItems, maxItems int, stopSE float64) {
+	minItems, maxItems, stopSE = test.AdaptiveMinItems, test.AdaptiveMaxItems, test.AdaptiveStopSE
+	if maxItems <= 0 {
+		maxItems = adaptiveDefaultMaxItems
+	}
+	if minItems <= 0 {
+		minItems = adaptiveDefaultMinItems
+	}
+	if stopSE <= 0 {
+		stopSE = adaptiveDefaultStopSE
+	}
+	return minItems, maxItems, stopSE
+}
+
+// loadAdaptiveState loads the calibrated multiple choice questions of the test and
+// decodes what the attempt has been served and answered.
+func (s *attemptService) loadAdaptiveState(attempt *model.Attempt, test *model.Test) (*adaptiveState, error) {
+	questions, err := s.questionRepo.FindByTestID(test.ID)
+	if err != nil {
+		return nil, errors.New("could not retrieve questions for the test")
+	}
+	ids := make([]uuid.UUID, 0, len(questions))
+	for _, q := range questions {
+		if !q.IsEssay() {
+			ids = append(ids, q.ID)
+		}
+	}
+	params, err := s.paramRepo.FindByQuestionIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byQuestion := make(map[uuid.UUID]model.ItemParameter, len(params))
+	for _, p := range params {
+		byQuestion[p.QuestionID] = p
+	}
+
+	st := &adaptiveState{pool: make(map[uuid.UUID]adaptiveItem, len(params))}
+	for _, q := range questions {
+		if p, ok := byQuestion[q.ID]; ok && !q.IsEssay() {
+			st.pool[q.ID] = adaptiveItem{question: q, params: psychometrics.ItemParams{A: p.A, B: p.B, C: p.C}}
+		}
+	}
+	if attempt.Layout != "" {
+		if err := json.Unmarshal([]byte(attempt.Layout), &st.layout); err != nil {
+			return nil, errors.New("stored attempt layout is invalid")
+		}
+	}
+	if err := json.Unmarshal([]byte(attempt.Answers), &st.answers); err != nil {
+		return nil, errors.New("stored attempt answers are invalid")
+	}
+	return st, nil
+}
+
+// startAdaptive creates an adaptive attempt and serves its first question. The whole
+// test runs on one timer of Test.Duration; sections do not apply.
+func (s *attemptService) startAdaptive(userUUID uuid.UUID, test *model.Test, startedAt time.Time, eventID *uuid.UUID) (*AttemptView, error) {
+	expiresAt := startedAt.Add(time.Duration(test.Duration) * time.Minute)
+	attempt := &model.Attempt{
+		ID:              uuid.New(),
+		TestID:          test.ID,
+		UserID:          userUUID,
+		Status:          model.AttemptStatusInProgress,
+		SectionDeadline: expiresAt,
+		Answers:         "[]",
+		Seed:            rand.Int63(),
+		Layout:          "[]",
+		StartedAt:       startedAt,
+		ExpiresAt:       expiresAt,
+		EventID:         eventID,
+	}
+	st, err := s.loadAdaptiveState(attempt, test)
+	if err != nil {
+		return nil, err
+	}
+	if len(st.pool) == 0 {
+		return nil, errors.New("test has no calibrated questions")
+	}
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		return nil, err
+	}
+	return s.advanceAdaptive(attempt, test, st)
+}
+
+// continueAdaptive shows the open question of an adaptive attempt, serving the next
+// one or finishing the attempt when none is open.
+func (s *attemptService) continueAdaptive(attempt *model.Attempt, test *model.Test) (*AttemptView, error) {
+	st, err := s.loadAdaptiveState(attempt, test)
+	if err != nil {
+		return nil, err
+	}
+	return s.advanceAdaptive(attempt, test, st)
+}
+
+func (s *attemptService) NextQuestion(userID, attemptID string) (*AttemptView, error) {
+	attempt, err := s.findOwnAttempt(userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	test, err := s.testRepo.FindByID(attempt.TestID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	if !test.Adaptive {
+		return nil, errors.New("attempt is not adaptive")
+	}
+	view, err := s.continueAdaptive(attempt, test)
+	if err == errAttemptChanged {
+		// Another request served the next question first; show that one.
+		return s.NextQuestion(userID, attemptID)
+	}
+	return view, err
+}
+
+// AnswerQuestion records the answer to the open question of an adaptive attempt and
+// serves the next one, or grades the attempt once the stopping rule is met. Only the
+// open question can be answered and an answer cannot be changed.
+func (s *attemptService) AnswerQuestion(userID, attemptID string, req *AnswerQuestionRequest) (*AttemptView, error) {
+	attempt, err := s.findOwnAttempt(userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	test, err := s.testRepo.FindByID(attempt.TestID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	if !test.Adaptive {
+		return nil, errors.New("attempt is not adaptive")
+	}
+	if attempt.Status != model.AttemptStatusInProgress {
+		return nil, errors.New("attempt is already submitted")
+	}
+	st, err := s.loadAdaptiveState(attempt, test)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(attempt.ExpiresAt.Add(attemptGracePeriod)) {
+		if _, err := s.advanceAdaptive(attempt, test, st); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("attempt time limit has passed")
+	}
+
+	open := st.pending()
+	if open == nil || open.QuestionID.String() != req.QuestionID {
+		return nil, errors.New("question is not the open question")
+	}
+	answer := UserAnswer{QuestionID: req.QuestionID, SelectedAnswer: req.SelectedAnswer, TimeSpent: req.TimeSpent}
+	if len(open.OptionOrder) > 0 {
+		answer.SelectedAnswer = originalOption(open.OptionOrder, answer.SelectedAnswer)
+	}
+	st.answers = append(st.answers, answer)
+	encoded, err := json.Marshal(st.answers)
+	if err != nil {
+		return nil, err
+	}
+	attempt.Answers = string(encoded)
+	return s.advanceAdaptive(attempt, test, st)
+}
+
+// advanceAdaptive moves an adaptive attempt forward: an open question stays open,
+// otherwise the attempt is graded when time ran out or the stopping rule is met, and
+// the next question is served if not.
+func (s *attemptService) advanceAdaptive(attempt *model.Attempt, test *model.Test, st *adaptiveState) (*AttemptView, error) {
+	minItems, maxItems, stopSE := adaptiveStopRule(test)
+	if attempt.Status != model.AttemptStatusInProgress {
+		return buildAdaptiveView(attempt, st, maxItems, nil), nil
+	}
+
+	expired := time.Now().After(attempt.ExpiresAt.Add(attemptGracePeriod))
+	if st.pending() != nil && !expired {
+		return buildAdaptiveView(attempt, st, maxItems, nil), nil
+	}
+
+	ability := st.ability()
+	answered := len(st.answers)
+	done := expired || answered >= maxItems || (answered >= minItems && ability.SE <= stopSE)
+	var next *model.AttemptLayoutItem
+	if !done {
+		var err error
+		if next, err = s.pickAdaptiveItem(attempt, test, st, ability.Theta); err != nil {
+			return nil, err
+		}
+	}
+	if next == nil {
+		result, err := s.finishAttempt(attempt, test, st.served(), st.answers, attempt.CurrentSection)
+		if err != nil {
+			return nil, err
+		}
+		return buildAdaptiveView(attempt, st, maxItems, result), nil
+	}
+
+	st.layout = append(st.layout, *next)
+	encoded, err := json.Marshal(st.layout)
+	if err != nil {
+		return nil, err
+	}
+	attempt.Layout = string(encoded)
+	// CurrentSection counts the served questions of an adaptive attempt, so of two
+	// requests serving the next question at once only one is saved.
+	servedBefore := attempt.CurrentSection
+	attempt.CurrentSection = len(st.layout)
+	saved, err := s.attemptRepo.Advance(attempt, model.AttemptStatusInProgress, servedBefore)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errAttemptChanged
+	}
+	if err := s.paramRepo.IncrementExposure(test.ID, next.QuestionID); err != nil {
+		return nil, err
+	}
+	return buildAdaptiveView(attempt, st, maxItems, nil), nil
+}
+
+// pickAdaptiveItem chooses the next question at random from the few unserved ones
+// that are most informative at theta. Questions already shown to more than
+// AdaptiveMaxExposure of the test's attempts are left out while others remain. It
+// returns nil when the pool is exhausted.
+func (s *attemptService) pickAdaptiveItem(attempt *model.Attempt, test *model.Test, st *adaptiveState, theta float64) (*model.AttemptLayoutItem, error) {
+	served := make(map[uuid.UUID]bool, len(st.layout))
+	for _, item := range st.layout {
+		served[item.QuestionID] = true
+	}
+	var candidates []adaptiveItem
+	for id, item := range st.pool {
+		if !served[id] {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	if test.AdaptiveMaxExposure > 0 {
+		attempts, err := s.attemptRepo.CountByTestID(test.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= adaptiveExposureWarmup {
+			exposures, err := s.paramRepo.FindExposures(test.ID)
+			if err != nil {
+				return nil, err
+			}
+			rate := make(map[uuid.UUID]float64, len(exposures))
+			for _, e := range exposures {
+				rate[e.QuestionID] = float64(e.Served) / float64(attempts)
+			}
+			var allowed []adaptiveItem
+			for _, item := range candidates {
+				if rate[item.question.ID] < test.AdaptiveMaxExposure {
+					allowed = append(allowed, item)
+				}
+			}
+			if len(allowed) > 0 {
+				candidates = allowed
+			}
+		}
+	}
+
+	// Map iteration order is random, so sort fully to keep the choice reproducible
+	// from the attempt seed.
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := psychometrics.Information(theta, candidates[i].params), psychometrics.Information(theta, candidates[j].params)
+		if a != b {
+			return a > b
+		}
+		return candidates[i].question.ID.String() < candidates[j].question.ID.String()
+	})
+	if len(candidates) > adaptiveRandomesque {
+		candidates = candidates[:adaptiveRandomesque]
+	}
+	rng := rand.New(rand.NewSource(attempt.Seed + int64(len(st.layout))))
+	chosen := candidates[rng.Intn(len(candidates))].question
+
+	item := &model.AttemptLayoutItem{QuestionID: chosen.ID}
+	if test.ShuffleOptions {
+		if count := optionCount(chosen.Options); count > 1 {
+			item.OptionOrder = rng.Perm(count)
+		}
+	}
+	return item, nil
+}
+
+func buildAdaptiveView(attempt *model.Attempt, st *adaptiveState, maxItems int, result *model.TestResult) *AttemptView {
+	view := &AttemptView{
+		Attempt:       attempt,
+		TotalSections: 1,
+		Questions:     []AttemptQuestion{},
+		Result:        result,
+		Adaptive:      &AdaptiveProgress{Answered: len(st.answers), MaxItems: maxItems},
+	}
+	open := st.pending()
+	if attempt.Status != model.AttemptStatusInProgress || open == nil {
+		return view
+	}
+
+	q := st.pool[open.QuestionID].question
+	view.Section = &AttemptSectionView{Index: 0, Duration: int(attempt.ExpiresAt.Sub(attempt.StartedAt).Minutes())}
+	view.Questions = append(view.Questions, AttemptQuestion{
+		ID:           q.ID,
+		SectionID:    q.SectionID,
+		Type:         q.Type,
+		QuestionText: q.QuestionText,
+		Options:      shuffledOptions(q.Options, open.OptionOrder),
+		Points:       q.Points,
+	})
+	if remaining := time.Until(attempt.ExpiresAt); remaining > 0 {
+		view.RemainingSeconds = int(remaining.Seconds())
+	}
+	return view
+}
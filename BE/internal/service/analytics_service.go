@@ -0,0 +1,382 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// A topic is weak once the student has seen enough of it and is below the bar.
+	weakTopicMinQuestions = 3
+	weakTopicMastery      = 60
+	maxWeakTopics         = 5
+	maxSuggestedTests     = 5
+	// trendLength is how many recent results each topic trend shows, and trendWindow
+	// how many of them are averaged on each side when telling the direction.
+	trendLength = 10
+	trendWindow = 3
+	// trendThreshold is the change in average score, in points, that counts as a move.
+	trendThreshold = 5
+)
+
+const (
+	TrendNew       = "new"
+	TrendImproving = "improving"
+	TrendDeclining = "declining"
+	TrendSteady    = "steady"
+)
+
+type TopicTrendPoint struct {
+	TestResultID uuid.UUID `json:"test_result_id"`
+	TestID       uuid.UUID `json:"test_id"`
+	Score        float64   `json:"score"`
+	Mastery      float64   `json:"mastery"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+type TopicAnalytics struct {
+	Topic     string            `json:"topic"`
+	Attempted int               `json:"attempted"`
+	Correct   int               `json:"correct"`
+	Wrong     int               `json:"wrong"`
+	Blank     int               `json:"blank"`
+	Mastery   float64           `json:"mastery"`
+	Direction string            `json:"direction"`
+	Trend     []TopicTrendPoint `json:"trend"`
+}
+
+type SuggestedTest struct {
+	TestID            uuid.UUID `json:"test_id"`
+	Title             string    `json:"title"`
+	Category          string    `json:"category"`
+	Difficulty        string    `json:"difficulty"`
+	IsPremium         bool      `json:"is_premium"`
+	Topics            []string  `json:"topics"`
+	MatchingQuestions int       `json:"matching_questions"`
+	AlreadyTaken      bool      `json:"already_taken"`
+}
+
+type StudentAnalytics struct {
+	Topics         []TopicAnalytics `json:"topics"`
+	WeakTopics     []TopicAnalytics `json:"weak_topics"`
+	SuggestedTests []SuggestedTest  `json:"suggested_tests"`
+}
+
+type AnalyticsService interface {
+	GetStudentAnalytics(userID string) (*StudentAnalytics, error)
+	ResultFinalized(result *model.TestResult)
+	ResultInvalidated(result *model.TestResult)
+}
+
+type analyticsService struct {
+	analyticsRepo     repository.AnalyticsRepository
+	questionRepo      repository.QuestionRepository
+	testRepo          repository.TestRepository
+	resultRepo        repository.TestResultRepository
+	testResultService TestResultService
+}
+
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, questionRepo repository.QuestionRepository, testRepo repository.TestRepository, resultRepo repository.TestResultRepository, testResultService TestResultService) AnalyticsService {
+	return &analyticsService{analyticsRepo, questionRepo, testRepo, resultRepo, testResultService}
+}
+
+// topicTally is one result's contribution to a topic.
+type topicTally struct {
+	questions, correct, wrong, blank int
+	earned, possible                 float64
+}
+
+// ResultFinalized brings the student's analytics up to date with the result's test.
+// Only the official result of each test counts towards topic mastery and trends, and
+// a new result can change which one that is, so the test's contribution is rebuilt
+// rather than added to.
+func (s *analyticsService) ResultFinalized(result *model.TestResult) {
+	s.refreshTest(result.UserID, result.TestID)
+}
+
+// ResultInvalidated takes an invalidated result out of the student's topics. The
+// official result has been re-selected by now, so the test falls back to it, or
+// stops counting when nothing is left.
+func (s *analyticsService) ResultInvalidated(result *model.TestResult) {
+	s.refreshTest(result.UserID, result.TestID)
+}
+
+// refreshTest replaces the snapshots of a student's test with those of their current
+// official result on it, or drops them when none is left, and rebuilds mastery.
+func (s *analyticsService) refreshTest(userID, testID uuid.UUID) {
+	results, err := s.resultRepo.FindByUserAndTest(userID, testID)
+	if err != nil {
+		log.Printf("analytics: could not load results of user %s on test %s: %v", userID, testID, err)
+		return
+	}
+	var snapshots []model.TopicSnapshot
+	for i := range results {
+		if results[i].IsOfficial && results[i].Status == model.ResultStatusCompleted {
+			if snapshots, err = s.topicSnapshots(&results[i]); err != nil {
+				log.Printf("analytics: could not review result %s: %v", results[i].ID, err)
+				return
+			}
+			break
+		}
+	}
+	if err := s.analyticsRepo.ReplaceTestSnapshots(userID, testID, snapshots, rebuildMastery); err != nil {
+		log.Printf("analytics: could not update user %s on test %s: %v", userID, testID, err)
+	}
+}
+
+// topicSnapshots tallies a result per topic. It reuses the result review so topics
+// are counted on exactly the questions that were graded. Questions without a topic,
+// deleted questions and ungraded essays are left out.
+func (s *analyticsService) topicSnapshots(result *model.TestResult) ([]model.TopicSnapshot, error) {
+	review, err := s.testResultService.GetResultReview(result.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	tallies := make(map[string]*topicTally)
+	var topics []string
+	for _, q := range review.Questions {
+		if q.Topic == "" || q.Missing || q.Outcome == EssayOutcomePending {
+			continue
+		}
+		tally, ok := tallies[q.Topic]
+		if !ok {
+			tally = &topicTally{}
+			tallies[q.Topic] = tally
+			topics = append(topics, q.Topic)
+		}
+		tally.questions++
+		tally.earned += q.PointsEarned
+		tally.possible += q.Points
+		switch q.Outcome {
+		case AnswerCorrect:
+			tally.correct++
+		case AnswerWrong:
+			tally.wrong++
+		case AnswerBlank:
+			tally.blank++
+		}
+	}
+
+	completedAt := result.CompletedAt
+	if result.FinalizedAt != nil {
+		completedAt = *result.FinalizedAt
+	}
+	snapshots := make([]model.TopicSnapshot, 0, len(topics))
+	for _, topic := range topics {
+		tally := tallies[topic]
+		snapshots = append(snapshots, model.TopicSnapshot{
+			ID:             uuid.New(),
+			UserID:         result.UserID,
+			Topic:          topic,
+			TestResultID:   result.ID,
+			TestID:         result.TestID,
+			Questions:      tally.questions,
+			Correct:        tally.correct,
+			Wrong:          tally.wrong,
+			Blank:          tally.blank,
+			PointsEarned:   tally.earned,
+			PointsPossible: tally.possible,
+			Score:          masteryPercent(tally.earned, tally.possible),
+			CompletedAt:    completedAt,
+		})
+	}
+	return snapshots, nil
+}
+
+// rebuildMastery recomputes topic totals from a student's snapshots, oldest first,
+// recording the running mastery on each snapshot.
+func rebuildMastery(snapshots []model.TopicSnapshot, masteries map[string]*model.TopicMastery) {
+	for _, mastery := range masteries {
+		*mastery = model.TopicMastery{ID: mastery.ID, UserID: mastery.UserID, Topic: mastery.Topic}
+	}
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		mastery, ok := masteries[snapshot.Topic]
+		if !ok {
+			mastery = &model.TopicMastery{ID: uuid.New(), UserID: snapshot.UserID, Topic: snapshot.Topic}
+			masteries[snapshot.Topic] = mastery
+		}
+		mastery.Attempted += snapshot.Questions
+		mastery.Correct += snapshot.Correct
+		mastery.Wrong += snapshot.Wrong
+		mastery.Blank += snapshot.Blank
+		mastery.PointsEarned += snapshot.PointsEarned
+		mastery.PointsPossible += snapshot.PointsPossible
+		mastery.Mastery = masteryPercent(mastery.PointsEarned, mastery.PointsPossible)
+		mastery.LastResultAt = snapshot.CompletedAt
+		snapshot.Mastery = mastery.Mastery
+	}
+}
+
+// masteryPercent is earned over possible as a percentage clamped to 0-100 and
+// rounded to two decimals; penalties can push a topic below zero points.
+func masteryPercent(earned, possible float64) float64 {
+	if possible <= 0 {
+		return 0
+	}
+	percent := math.Max(0, math.Min(100, earned/possible*100))
+	return math.Round(percent*100) / 100
+}
+
+// GetStudentAnalytics returns mastery and trend per topic, the weakest topics and
+// published tests that practise them, with tests the student has not taken first.
+func (s *analyticsService) GetStudentAnalytics(userID string) (*StudentAnalytics, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	masteries, err := s.analyticsRepo.FindMastery(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := s.analyticsRepo.FindSnapshots(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	trends := make(map[string][]TopicTrendPoint)
+	for _, snapshot := range snapshots {
+		trends[snapshot.Topic] = append(trends[snapshot.Topic], TopicTrendPoint{
+			TestResultID: snapshot.TestResultID,
+			TestID:       snapshot.TestID,
+			Score:        snapshot.Score,
+			Mastery:      snapshot.Mastery,
+			CompletedAt:  snapshot.CompletedAt,
+		})
+	}
+
+	analytics := &StudentAnalytics{Topics: []TopicAnalytics{}, WeakTopics: []TopicAnalytics{}, SuggestedTests: []SuggestedTest{}}
+	for _, mastery := range masteries {
+		trend := trends[mastery.Topic]
+		if len(trend) > trendLength {
+			trend = trend[len(trend)-trendLength:]
+		}
+		topic := TopicAnalytics{
+			Topic:     mastery.Topic,
+			Attempted: mastery.Attempted,
+			Correct:   mastery.Correct,
+			Wrong:     mastery.Wrong,
+			Blank:     mastery.Blank,
+			Mastery:   mastery.Mastery,
+			Direction: trendDirection(trend),
+			Trend:     trend,
+		}
+		if topic.Trend == nil {
+			topic.Trend = []TopicTrendPoint{}
+		}
+		analytics.Topics = append(analytics.Topics, topic)
+		if mastery.Attempted >= weakTopicMinQuestions && mastery.Mastery < weakTopicMastery {
+			analytics.WeakTopics = append(analytics.WeakTopics, topic)
+		}
+	}
+	sort.SliceStable(analytics.WeakTopics, func(i, j int) bool {
+		return analytics.WeakTopics[i].Mastery < analytics.WeakTopics[j].Mastery
+	})
+	if len(analytics.WeakTopics) > maxWeakTopics {
+		analytics.WeakTopics = analytics.WeakTopics[:maxWeakTopics]
+	}
+
+	suggested, err := s.suggestTests(userUUID, analytics.WeakTopics)
+	if err != nil {
+		return nil, err
+	}
+	analytics.SuggestedTests = suggested
+	return analytics, nil
+}
+
+func (s *analyticsService) suggestTests(userID uuid.UUID, weak []TopicAnalytics) ([]SuggestedTest, error) {
+	suggested := []SuggestedTest{}
+	if len(weak) == 0 {
+		return suggested, nil
+	}
+	topics := make([]string, 0, len(weak))
+	for _, topic := range weak {
+		topics = append(topics, topic.Topic)
+	}
+	counts, err := s.questionRepo.CountByTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+	tests, err := s.testRepo.FindAvailable(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.resultRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[uuid.UUID]bool, len(results))
+	for _, result := range results {
+		taken[result.TestID] = true
+	}
+
+	for _, test := range tests {
+		byTopic, ok := counts[test.ID]
+		if !ok {
+			continue
+		}
+		suggestion := SuggestedTest{
+			TestID:       test.ID,
+			Title:        test.Title,
+			Category:     test.Category,
+			Difficulty:   test.Difficulty,
+			IsPremium:    test.IsPremium,
+			AlreadyTaken: taken[test.ID],
+		}
+		// Keep the weakest-first order of the topics.
+		for _, topic := range topics {
+			if count := byTopic[topic]; count > 0 {
+				suggestion.Topics = append(suggestion.Topics, topic)
+				suggestion.MatchingQuestions += count
+			}
+		}
+		suggested = append(suggested, suggestion)
+	}
+	sort.SliceStable(suggested, func(i, j int) bool {
+		if suggested[i].AlreadyTaken != suggested[j].AlreadyTaken {
+			return !suggested[i].AlreadyTaken
+		}
+		return suggested[i].MatchingQuestions > suggested[j].MatchingQuestions
+	})
+	if len(suggested) > maxSuggestedTests {
+		suggested = suggested[:maxSuggestedTests]
+	}
+	return suggested, nil
+}
+
+// trendDirection compares the average score of the latest results on a topic with
+// the results just before them.
+func trendDirection(trend []TopicTrendPoint) string {
+	if len(trend) < 2 {
+		return TrendNew
+	}
+	window := trendWindow
+	if len(trend) < 2*window {
+		window = len(trend) / 2
+	}
+	recent := averageScore(trend[len(trend)-window:])
+	previous := averageScore(trend[len(trend)-2*window : len(trend)-window])
+	switch {
+	case recent-previous >= trendThreshold:
+		return TrendImproving
+	case previous-recent >= trendThreshold:
+		return TrendDeclining
+	default:
+		return TrendSteady
+	}
+}
+
+func averageScore(points []TopicTrendPoint) float64 {
+	total := 0.0
+	for _, point := range points {
+		total += point.Score
+	}
+	return total / float64(len(points))
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+// Progress of one student on an assignment. A student who has not finished by the
+// due date is overdue, whether or not they started.
+const (
+	AssignmentNotStarted = "not_started"
+	AssignmentStarted    = "started"
+	AssignmentFinished   = "finished"
+	AssignmentOverdue    = "overdue"
+)
+
+// StudentProgress is where one student stands on an assignment. The first result
+// completed after the assignment was made is the one that finishes it; Late is set
+// when that was after the due date.
+type StudentProgress struct {
+	UserID     uuid.UUID   `json:"user_id"`
+	User       *model.User `json:"user,omitempty"`
+	Status     string      `json:"status"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Late       bool        `json:"late"`
+	Score      *float64    `json:"score,omitempty"`
+	ResultID   *uuid.UUID  `json:"result_id,omitempty"`
+}
+
+// AssignmentProgress is the teacher's report on one assignment.
+type AssignmentProgress struct {
+	Assignment *model.Assignment `json:"assignment"`
+	NotStarted int               `json:"not_started"`
+	Started    int               `json:"started"`
+	Finished   int               `json:"finished"`
+	Overdue    int               `json:"overdue"`
+	Students   []StudentProgress `json:"students"`
+}
+
+// MyAssignment is an assignment as the student it was given to sees it.
+type MyAssignment struct {
+	Assignment model.Assignment `json:"assignment"`
+	Progress   StudentProgress  `json:"progress"`
+}
+
+// studentProgress works out a student's progress from their results and attempts on
+// the assignment's test, both already limited to work done since it was assigned.
+func studentProgress(assignment *model.Assignment, userID uuid.UUID, results []model.TestResult, attempts []model.Attempt, now time.Time) StudentProgress {
+	progress := StudentProgress{UserID: userID, Status: AssignmentNotStarted}
+	for _, attempt := range attempts {
+		if attempt.UserID == userID {
+			startedAt := attempt.StartedAt
+			progress.StartedAt = &startedAt
+			progress.Status = AssignmentStarted
+			break
+		}
+	}
+	for _, result := range results {
+		if result.UserID != userID {
+			continue
+		}
+		completedAt, score, resultID := result.CompletedAt, result.Score, result.ID
+		if progress.StartedAt == nil {
+			startedAt := completedAt.Add(-time.Duration(result.TimeSpent) * time.Second)
+			progress.StartedAt = &startedAt
+		}
+		progress.Status = AssignmentFinished
+		progress.FinishedAt = &completedAt
+		progress.Late = completedAt.After(assignment.DueAt)
+		progress.Score = &score
+		progress.ResultID = &resultID
+		return progress
+	}
+	if now.After(assignment.DueAt) {
+		progress.Status = AssignmentOverdue
+	}
+	return progress
+}
+
+// groupProgress works out the progress of every student of the assignment's group.
+func (s *organizationService) groupProgress(assignment *model.Assignment, now time.Time) ([]StudentProgress, error) {
+	members, err := s.orgRepo.FindGroupMembers(assignment.OrganizationID, assignment.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	results, attempts, err := s.orgRepo.FindAssignmentWork(assignment, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	students := make([]StudentProgress, len(members))
+	for i := range members {
+		students[i] = studentProgress(assignment, members[i].UserID, results, attempts, now)
+		students[i].User = &members[i].User
+	}
+	return students, nil
+}
+
+// GetAssignmentProgress reports who has started, finished or is overdue on one of
+// the teacher's assignments. Site admins can see any group's.
+func (s *organizationService) GetAssignmentProgress(userID, userRole, orgID, groupID, assignmentID string) (*AssignmentProgress, error) {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return nil, err
+	}
+	var group *model.Group
+	if access.isTeacher() {
+		group, err = s.ownGroup(access, groupID)
+	} else {
+		group, err = s.findGroup(access, groupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	assignmentUUID, err := uuid.Parse(assignmentID)
+	if err != nil {
+		return nil, errors.New("assignment not found")
+	}
+	assignment, err := s.orgRepo.FindAssignment(access.orgID, group.ID, assignmentUUID)
+	if err != nil {
+		return nil, errors.New("assignment not found")
+	}
+
+	students, err := s.groupProgress(assignment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	report := &AssignmentProgress{Assignment: assignment, Students: students}
+	for _, student := range students {
+		switch student.Status {
+		case AssignmentNotStarted:
+			report.NotStarted++
+		case AssignmentStarted:
+			report.Started++
+		case AssignmentFinished:
+			report.Finished++
+		case AssignmentOverdue:
+			report.Overdue++
+		}
+	}
+	return report, nil
+}
+
+// GetMyAssignments lists the assignments of every group the student is in, with
+// their own progress on each.
+func (s *organizationService) GetMyAssignments(userID string) ([]MyAssignment, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	assignments, err := s.orgRepo.FindAssignmentsByStudent(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	mine := make([]MyAssignment, 0, len(assignments))
+	for i := range assignments {
+		results, attempts, err := s.orgRepo.FindAssignmentWork(&assignments[i], []uuid.UUID{userUUID})
+		if err != nil {
+			return nil, err
+		}
+		mine = append(mine, MyAssignment{
+			Assignment: assignments[i],
+			Progress:   studentProgress(&assignments[i], userUUID, results, attempts, now),
+		})
+	}
+	return mine, nil
+}
+
+// notifyAssigned tells the students of a group about a new assignment.
+func (s *organizationService) notifyAssigned(assignment *model.Assignment) {
+	members, err := s.orgRepo.FindGroupMembers(assignment.OrganizationID, assignment.GroupID)
+	if err != nil {
+		log.Printf("assignments: listing students of group %s failed: %v", assignment.GroupID, err)
+		return
+	}
+	message := fmt.Sprintf("You have been assigned \"%s\". It is due on %s.", assignment.Test.Title, assignment.DueAt.Format("2 Jan 2006 15:04"))
+	for _, member := range members {
+		_ = s.notificationService.Notify(member.UserID, "assignment_created", "New assignment", message, "/test/"+assignment.TestID.String())
+	}
+}
+
+// RemindOverdue sends the reminders of every assignment that has passed its due
+// date: each student who has not finished it gets one, and the teacher who assigned
+// it gets a summary. Each assignment is claimed before its reminders go out, so it
+// is reminded once even when two runs overlap. An assignment that fails is logged
+// and skipped. It returns the number of assignments reminded.
+func (s *organizationService) RemindOverdue(now time.Time) (int, error) {
+	assignments, err := s.orgRepo.FindOverdueAssignments(now)
+	if err != nil {
+		return 0, err
+	}
+	reminded := 0
+	for i := range assignments {
+		assignment := &assignments[i]
+		students, err := s.groupProgress(assignment, now)
+		if err != nil {
+			log.Printf("assignments: could not load progress of assignment %s: %v", assignment.ID, err)
+			continue
+		}
+		claimed, err := s.orgRepo.ClaimAssignmentReminder(assignment, now)
+		if err != nil {
+			log.Printf("assignments: could not claim reminder of assignment %s: %v", assignment.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		reminded++
+
+		link := "/test/" + assignment.TestID.String()
+		overdue := 0
+		for _, student := range students {
+			if student.Status != AssignmentOverdue {
+				continue
+			}
+			overdue++
+			message := fmt.Sprintf("\"%s\" was due on %s and you have not finished it yet.", assignment.Test.Title, assignment.DueAt.Format("2 Jan 2006 15:04"))
+			_ = s.notificationService.Notify(student.UserID, "assignment_overdue", "Assignment overdue", message, link)
+		}
+		if overdue > 0 {
+			message := fmt.Sprintf("%d of %d students have not finished \"%s\" by its due date.", overdue, len(students), assignment.Test.Title)
+			_ = s.notificationService.Notify(assignment.AssignedBy, "assignment_overdue_summary", "Assignment overdue", message, "")
+		}
+	}
+	return reminded, nil
+}
+
+// StartReminderJob runs RemindOverdue every interval in the background.
+func (s *organizationService) StartReminderJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.RemindOverdue(time.Now()); err != nil {
+				log.Printf("assignments: sending overdue reminders failed: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// draftDirtyKey is the set of attempts whose Redis draft changed since it was last
+// written to MySQL.
+const draftDirtyKey = "attempts:draft:dirty"
+
+// draftFlushBatch is how many attempts one flush writes at most.
+const draftFlushBatch = 200
+
+func draftKey(attemptID uuid.UUID) string {
+	return fmt.Sprintf("attempt:%s:draft", attemptID)
+}
+
+// SavedAnswer is an autosaved answer. SelectedAnswer is -1 when no option is chosen;
+// Flagged marks the question for review and does not affect grading.
+type SavedAnswer struct {
+	QuestionID     string    `json:"question_id"`
+	SelectedAnswer int       `json:"selected_answer"`
+	AnswerText     string    `json:"answer_text,omitempty"`
+	TimeSpent      int       `json:"time_spent,omitempty"`
+	Flagged        bool      `json:"flagged"`
+	SavedAt        time.Time `json:"saved_at"`
+}
+
+// SaveAnswerRequest changes the saved state of one question. Fields left out keep
+// their saved value, so a review flag can be set without resending the answer.
+type SaveAnswerRequest struct {
+	QuestionID     string  `json:"question_id" validate:"required,uuid"`
+	SelectedAnswer *int    `json:"selected_answer"`
+	AnswerText     *string `json:"answer_text"`
+	TimeSpent      *int    `json:"time_spent"`
+	Flagged        *bool   `json:"flagged"`
+}
+
+type SaveAnswersRequest struct {
+	Answers []SaveAnswerRequest `json:"answers" validate:"required,min=1,dive"`
+}
+
+type SaveAnswersResponse struct {
+	Saved   int       `json:"saved"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SaveAnswers autosaves answers and review flags for questions of the open section.
+// Option indexes are the displayed ones. The draft goes to Redis and reaches MySQL
+// on the next flush; if Redis cannot be written it is stored in MySQL right away.
+func (s *attemptService) SaveAnswers(userID, attemptID string, req *SaveAnswersRequest) (*SaveAnswersResponse, error) {
+	attempt, err := s.findOwnAttempt(userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	test, paper, err := s.loadAttemptPaper(attempt)
+	if err != nil {
+		return nil, err
+	}
+	if test.Adaptive {
+		return nil, errors.New("adaptive attempts are answered one question at a time")
+	}
+
+	openSection := attempt.CurrentSection
+	if _, err := s.closeExpiredSections(attempt, test, paper); err != nil {
+		return nil, err
+	}
+	if attempt.Status != model.AttemptStatusInProgress || attempt.CurrentSection != openSection {
+		return nil, errors.New("section time limit has passed")
+	}
+
+	block := paper[openSection]
+	allowed := make(map[string]bool, len(block.questions))
+	for _, q := range block.questions {
+		allowed[q.ID.String()] = true
+	}
+	for _, answer := range req.Answers {
+		if !allowed[answer.QuestionID] {
+			return nil, errors.New("question is not in the open section")
+		}
+	}
+
+	draft, redisComplete := s.readDraft(attempt.ID)
+	now := time.Now()
+	changed := make(map[string]SavedAnswer, len(req.Answers))
+	for _, update := range req.Answers {
+		saved, ok := draft[update.QuestionID]
+		if !ok {
+			saved = SavedAnswer{QuestionID: update.QuestionID, SelectedAnswer: -1}
+		}
+		if update.SelectedAnswer != nil {
+			saved.SelectedAnswer = block.toOriginal(UserAnswer{QuestionID: update.QuestionID, SelectedAnswer: *update.SelectedAnswer}).SelectedAnswer
+		}
+		if update.AnswerText != nil {
+			saved.AnswerText = *update.AnswerText
+		}
+		if update.TimeSpent != nil {
+			saved.TimeSpent = *update.TimeSpent
+		}
+		if update.Flagged != nil {
+			saved.Flagged = *update.Flagged
+		}
+		saved.SavedAt = now
+		draft[update.QuestionID] = saved
+		changed[update.QuestionID] = saved
+	}
+
+	// A Redis draft that is missing answers gets the whole merged draft, so the next
+	// flush does not write the gaps over the MySQL copy.
+	toWrite := changed
+	if !redisComplete {
+		toWrite = draft
+	}
+	if err := s.writeDraft(attempt, toWrite); err != nil {
+		log.Printf("autosave: redis write for attempt %s failed, saving to MySQL: %v", attempt.ID, err)
+		if err := s.storeDraft(attempt.ID, draft); err != nil {
+			return nil, err
+		}
+	}
+	return &SaveAnswersResponse{Saved: len(changed), SavedAt: now}, nil
+}
+
+// writeDraft stores the changed answers in the Redis draft and marks it dirty. The
+// draft lives a day past the attempt deadline.
+func (s *attemptService) writeDraft(attempt *model.Attempt, changed map[string]SavedAnswer) error {
+	fields := make(map[string]interface{}, len(changed))
+	for questionID, saved := range changed {
+		encoded, err := json.Marshal(saved)
+		if err != nil {
+			return err
+		}
+		fields[questionID] = encoded
+	}
+	ctx := context.Background()
+	key := draftKey(attempt.ID)
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		pipe.ExpireAt(ctx, key, attempt.ExpiresAt.Add(24*time.Hour))
+		pipe.SAdd(ctx, draftDirtyKey, attempt.ID.String())
+		return nil
+	})
+	return err
+}
+
+// loadDraft returns the saved answers of an attempt by question ID.
+func (s *attemptService) loadDraft(attemptID uuid.UUID) map[string]SavedAnswer {
+	draft, _ := s.readDraft(attemptID)
+	return draft
+}
+
+// readDraft merges the Redis draft with the MySQL copy, the later save of each
+// question winning. Either side can hold answers the other lacks: Redis after its
+// copy expired or was lost, MySQL after a Redis write failed. redisComplete reports
+// whether Redis already had the merged draft.
+func (s *attemptService) readDraft(attemptID uuid.UUID) (draft map[string]SavedAnswer, redisComplete bool) {
+	draft, err := s.storedDraft(attemptID)
+	if err != nil {
+		log.Printf("autosave: reading the MySQL draft of attempt %s failed: %v", attemptID, err)
+		draft = make(map[string]SavedAnswer)
+	}
+	fields, err := s.redisClient.HGetAll(context.Background(), draftKey(attemptID)).Result()
+	if err != nil {
+		return draft, false
+	}
+	cached := decodeDraftFields(fields)
+	redisComplete = true
+	for questionID, saved := range draft {
+		if newer, ok := cached[questionID]; !ok || newer.SavedAt.Before(saved.SavedAt) {
+			redisComplete = false
+		}
+	}
+	mergeDraft(draft, cached)
+	return draft, redisComplete
+}
+
+// storedDraft reads the MySQL copy of a draft; it is empty when there is none.
+func (s *attemptService) storedDraft(attemptID uuid.UUID) (map[string]SavedAnswer, error) {
+	draft := make(map[string]SavedAnswer)
+	stored, err := s.attemptRepo.FindDraft(attemptID)
+	if err != nil || stored == nil {
+		return draft, err
+	}
+	var answers []SavedAnswer
+	if json.Unmarshal([]byte(stored.Answers), &answers) == nil {
+		for _, saved := range answers {
+			draft[saved.QuestionID] = saved
+		}
+	}
+	return draft, nil
+}
+
+func decodeDraftFields(fields map[string]string) map[string]SavedAnswer {
+	draft := make(map[string]SavedAnswer, len(fields))
+	for questionID, value := range fields {
+		var saved SavedAnswer
+		if json.Unmarshal([]byte(value), &saved) == nil {
+			draft[questionID] = saved
+		}
+	}
+	return draft
+}
+
+// mergeDraft copies the answers of from into draft where they were saved later.
+func mergeDraft(draft, from map[string]SavedAnswer) {
+	for questionID, saved := range from {
+		if current, ok := draft[questionID]; !ok || saved.SavedAt.After(current.SavedAt) {
+			draft[questionID] = saved
+		}
+	}
+}
+
+func (s *attemptService) storeDraft(attemptID uuid.UUID, draft map[string]SavedAnswer) error {
+	answers := make([]SavedAnswer, 0, len(draft))
+	for _, saved := range draft {
+		answers = append(answers, saved)
+	}
+	encoded, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+	return s.attemptRepo.SaveDraft(&model.AttemptDraft{AttemptID: attemptID, Answers: string(encoded), SavedAt: time.Now()})
+}
+
+// draftAnswers turns the saved answers of a block's questions into answers to grade.
+// Questions that are only flagged count as blank and are left out.
+func draftAnswers(draft map[string]SavedAnswer, block paperSection) []UserAnswer {
+	var answers []UserAnswer
+	for _, q := range block.questions {
+		saved, ok := draft[q.ID.String()]
+		if !ok || (saved.SelectedAnswer < 0 && saved.AnswerText == "") {
+			continue
+		}
+		answers = append(answers, UserAnswer{
+			QuestionID:     saved.QuestionID,
+			SelectedAnswer: saved.SelectedAnswer,
+			AnswerText:     saved.AnswerText,
+			TimeSpent:      saved.TimeSpent,
+		})
+	}
+	return answers
+}
+
+// restoreDraft lists the saved answers of the open section with displayed option
+// indexes, so a client can pick up where the student left off.
+func restoreDraft(draft map[string]SavedAnswer, block paperSection) []SavedAnswer {
+	restored := []SavedAnswer{}
+	for _, q := range block.questions {
+		saved, ok := draft[q.ID.String()]
+		if !ok {
+			continue
+		}
+		if order := block.optionOrders[q.ID]; len(order) > 0 && saved.SelectedAnswer >= 0 {
+			saved.SelectedAnswer = displayedOption(order, saved.SelectedAnswer)
+		}
+		restored = append(restored, saved)
+	}
+	return restored
+}
+
+// FlushDrafts writes the drafts changed since the last flush from Redis to MySQL and
+// returns how many it wrote. The Redis draft is merged into the MySQL copy rather
+// than replacing it, so answers only MySQL has are kept. Drafts that fail to write
+// stay marked for the next run.
+func (s *attemptService) FlushDrafts() (int, error) {
+	ctx := context.Background()
+	ids, err := s.redisClient.SPopN(ctx, draftDirtyKey, draftFlushBatch).Result()
+	if err != nil {
+		return 0, err
+	}
+	flushed := 0
+	for _, id := range ids {
+		attemptID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		fields, err := s.redisClient.HGetAll(ctx, draftKey(attemptID)).Result()
+		if err != nil {
+			s.redisClient.SAdd(ctx, draftDirtyKey, id)
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		draft, err := s.storedDraft(attemptID)
+		if err != nil {
+			s.redisClient.SAdd(ctx, draftDirtyKey, id)
+			continue
+		}
+		mergeDraft(draft, decodeDraftFields(fields))
+		if err := s.storeDraft(attemptID, draft); err != nil {
+			s.redisClient.SAdd(ctx, draftDirtyKey, id)
+			continue
+		}
+		flushed++
+	}
+	return flushed, nil
+}
+
+// StartDraftFlusher flushes autosaved drafts to MySQL every interval until the
+// process exits. Every instance may run one; SPOP hands each draft to one of them.
+func (s *attemptService) StartDraftFlusher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.FlushDrafts(); err != nil {
+				log.Printf("autosave: flushing drafts failed: %v", err)
+			}
+		}
+	}()
+}
+
+// clearDraft drops the Redis draft of a submitted attempt. The MySQL copy is kept.
+func (s *attemptService) clearDraft(attemptID uuid.UUID) {
+	ctx := context.Background()
+	s.redisClient.Del(ctx, draftKey(attemptID))
+	s.redisClient.SRem(ctx, draftDirtyKey, attemptID.String())
+}
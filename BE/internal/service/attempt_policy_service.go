@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// attemptLockTTL bounds how long starting or submitting an attempt may hold the
+// student's lock on a test.
+const attemptLockTTL = 30 * time.Second
+
+func attemptLockKey(testID, userID uuid.UUID) string {
+	return fmt.Sprintf("attempts:lock:%s:%s", testID, userID)
+}
+
+// AttemptAllowance tells a student how many attempts they have left on a test and
+// when they may start the next one. Remaining is nil when attempts are unlimited.
+type AttemptAllowance struct {
+	TestID        uuid.UUID  `json:"test_id"`
+	MaxAttempts   int        `json:"max_attempts"`
+	ExtraAttempts int        `json:"extra_attempts"`
+	Used          int        `json:"used"`
+	Remaining     *int       `json:"remaining"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CanStart      bool       `json:"can_start"`
+}
+
+type GrantAttemptsRequest struct {
+	UserID        string `json:"user_id" validate:"required,uuid"`
+	ExtraAttempts int    `json:"extra_attempts" validate:"required,gt=0"`
+	Reason        string `json:"reason" validate:"max=255"`
+}
+
+type AttemptPolicyService interface {
+	GetAllowance(userID, testID string) (*AttemptAllowance, error)
+	ReserveAttempt(userID uuid.UUID, test *model.Test) (release func(), err error)
+	GrantAttempts(testID, adminID string, req *GrantAttemptsRequest) (*model.AttemptGrant, error)
+	GetGrants(testID string) ([]model.AttemptGrant, error)
+}
+
+type attemptPolicyService struct {
+	testRepo    repository.TestRepository
+	attemptRepo repository.AttemptRepository
+	resultRepo  repository.TestResultRepository
+	grantRepo   repository.AttemptGrantRepository
+	userRepo    repository.UserRepository
+	redisClient *redis.Client
+}
+
+func NewAttemptPolicyService(testRepo repository.TestRepository, attemptRepo repository.AttemptRepository, resultRepo repository.TestResultRepository, grantRepo repository.AttemptGrantRepository, userRepo repository.UserRepository, redisClient *redis.Client) AttemptPolicyService {
+	return &attemptPolicyService{testRepo, attemptRepo, resultRepo, grantRepo, userRepo, redisClient}
+}
+
+func (s *attemptPolicyService) GetAllowance(userID, testID string) (*AttemptAllowance, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	test, err := s.testRepo.FindByID(testUUID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	return s.allowance(userUUID, test, time.Now())
+}
+
+// ReserveAttempt checks that the student may start another attempt on the test and
+// holds their lock on it until release is called. The caller stores the attempt or
+// result before releasing, so parallel requests cannot all pass the check before
+// any of them is counted. Tests without limits take no lock.
+func (s *attemptPolicyService) ReserveAttempt(userID uuid.UUID, test *model.Test) (func(), error) {
+	if test.MaxAttempts <= 0 && test.AttemptCooldown <= 0 {
+		return func() {}, nil
+	}
+	ctx := context.Background()
+	key := attemptLockKey(test.ID, userID)
+	acquired, err := s.redisClient.SetNX(ctx, key, time.Now().Unix(), attemptLockTTL).Result()
+	if err != nil {
+		return nil, errors.New("attempt limits cannot be checked right now")
+	}
+	if !acquired {
+		return nil, errors.New("another attempt is being started")
+	}
+	release := func() { s.redisClient.Del(ctx, key) }
+	if err := s.checkAllowed(userID, test); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// checkAllowed returns an error when the student has used up their attempts on the
+// test or is still inside the cooldown after the last one.
+func (s *attemptPolicyService) checkAllowed(userID uuid.UUID, test *model.Test) error {
+	allowance, err := s.allowance(userID, test, time.Now())
+	if err != nil {
+		return err
+	}
+	if allowance.Remaining != nil && *allowance.Remaining <= 0 {
+		return errors.New("maximum attempts reached")
+	}
+	if allowance.NextAttemptAt != nil {
+		return errors.New("attempt cooldown has not passed")
+	}
+	return nil
+}
+
+// allowance counts every started attempt, plus results submitted in one go without an
+// attempt. The cooldown runs from the end of the last attempt, or from its start
+// while it is still in progress.
+func (s *attemptPolicyService) allowance(userID uuid.UUID, test *model.Test, now time.Time) (*AttemptAllowance, error) {
+	attempts, err := s.attemptRepo.FindByUserAndTest(userID, test.ID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.resultRepo.FindByUserAndTest(userID, test.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowance := &AttemptAllowance{TestID: test.ID, MaxAttempts: test.MaxAttempts, Used: len(attempts), CanStart: true}
+	var last time.Time
+	for _, attempt := range attempts {
+		at := attempt.StartedAt
+		if attempt.SubmittedAt != nil {
+			at = *attempt.SubmittedAt
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	for _, result := range results {
+		if result.AttemptID != nil {
+			continue
+		}
+		allowance.Used++
+		if result.CompletedAt.After(last) {
+			last = result.CompletedAt
+		}
+	}
+
+	if test.MaxAttempts > 0 {
+		extra, err := s.grantRepo.SumExtra(test.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		allowance.ExtraAttempts = extra
+		remaining := test.MaxAttempts + extra - allowance.Used
+		if remaining < 0 {
+			remaining = 0
+		}
+		allowance.Remaining = &remaining
+		allowance.CanStart = remaining > 0
+	}
+	if test.AttemptCooldown > 0 && !last.IsZero() {
+		next := last.Add(time.Duration(test.AttemptCooldown) * time.Minute)
+		if now.Before(next) {
+			allowance.NextAttemptAt = &next
+			allowance.CanStart = false
+		}
+	}
+	return allowance, nil
+}
+
+func (s *attemptPolicyService) GrantAttempts(testID, adminID string, req *GrantAttemptsRequest) (*model.AttemptGrant, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	if _, err := s.testRepo.FindByID(testUUID); err != nil {
+		return nil, errors.New("test not found")
+	}
+	userUUID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	user, err := s.userRepo.FindUserByID(userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	adminUUID, _ := uuid.Parse(adminID)
+
+	grant := &model.AttemptGrant{
+		ID:            uuid.New(),
+		TestID:        testUUID,
+		UserID:        userUUID,
+		ExtraAttempts: req.ExtraAttempts,
+		Reason:        req.Reason,
+		GrantedBy:     adminUUID,
+	}
+	if err := s.grantRepo.Create(grant); err != nil {
+		return nil, err
+	}
+	grant.User = *user
+	return grant, nil
+}
+
+func (s *attemptPolicyService) GetGrants(testID string) ([]model.AttemptGrant, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.grantRepo.FindByTestID(testUUID)
+}
+
+// officialResult picks a student's official result from their completed results on
+// a test, oldest first, and the score it counts with.
+func officialResult(policy string, results []model.TestResult) (*model.TestResult, float64) {
+	if len(results) == 0 {
+		return nil, 0
+	}
+	switch policy {
+	case model.OfficialFirst:
+		return &results[0], results[0].Score
+	case model.OfficialLast:
+		last := &results[len(results)-1]
+		return last, last.Score
+	case model.OfficialAverage:
+		total := 0.0
+		for _, result := range results {
+			total += result.Score
+		}
+		return &results[len(results)-1], math.Round(total/float64(len(results))*100) / 100
+	default:
+		best := &results[0]
+		for i := range results {
+			if rankingScore(&results[i]) > rankingScore(best) {
+				best = &results[i]
+			}
+		}
+		return best, best.Score
+	}
+}
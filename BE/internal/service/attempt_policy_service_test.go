@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+// The fakes embed the repository interfaces and implement only what the attempt
+// policy reads; anything else panics.
+type fakePolicyTests struct {
+	repository.TestRepository
+	test *model.Test
+}
+
+func (f *fakePolicyTests) FindByID(id uuid.UUID) (*model.Test, error) {
+	return f.test, nil
+}
+
+type fakePolicyAttempts struct {
+	repository.AttemptRepository
+	attempts []model.Attempt
+}
+
+func (f *fakePolicyAttempts) FindByUserAndTest(userID, testID uuid.UUID) ([]model.Attempt, error) {
+	return f.attempts, nil
+}
+
+type fakePolicyResults struct {
+	repository.TestResultRepository
+}
+
+func (f *fakePolicyResults) FindByUserAndTest(userID, testID uuid.UUID) ([]model.TestResult, error) {
+	return nil, nil
+}
+
+type fakePolicyGrants struct {
+	repository.AttemptGrantRepository
+	grants []model.AttemptGrant
+}
+
+func (f *fakePolicyGrants) Create(grant *model.AttemptGrant) error {
+	f.grants = append(f.grants, *grant)
+	return nil
+}
+
+func (f *fakePolicyGrants) SumExtra(testID, userID uuid.UUID) (int, error) {
+	total := 0
+	for _, grant := range f.grants {
+		if grant.TestID == testID && grant.UserID == userID {
+			total += grant.ExtraAttempts
+		}
+	}
+	return total, nil
+}
+
+type fakePolicyUsers struct {
+	repository.UserRepository
+	user *model.User
+}
+
+func (f *fakePolicyUsers) FindUserByID(userID uuid.UUID) (*model.User, error) {
+	return f.user, nil
+}
+
+func TestGrantAttemptsRaisesTheLimit(t *testing.T) {
+	student := &model.User{ID: uuid.New(), Name: "Ana"}
+	test := &model.Test{ID: uuid.New(), MaxAttempts: 1}
+	grants := &fakePolicyGrants{}
+	s := &attemptPolicyService{
+		testRepo:    &fakePolicyTests{test: test},
+		attemptRepo: &fakePolicyAttempts{attempts: []model.Attempt{{UserID: student.ID, TestID: test.ID, StartedAt: time.Now().Add(-time.Hour)}}},
+		resultRepo:  &fakePolicyResults{},
+		grantRepo:   grants,
+		userRepo:    &fakePolicyUsers{user: student},
+	}
+
+	before, err := s.GetAllowance(student.ID.String(), test.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.CanStart || *before.Remaining != 0 {
+		t.Fatalf("before the grant: %+v, want no attempts left", before)
+	}
+
+	grant, err := s.GrantAttempts(test.ID.String(), uuid.NewString(), &GrantAttemptsRequest{UserID: student.ID.String(), ExtraAttempts: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grant.UserID != student.ID || grant.User.ID != student.ID {
+		t.Errorf("grant is for %s (user %s), want %s", grant.UserID, grant.User.ID, student.ID)
+	}
+	if stored := grants.grants[0]; stored.User.ID != uuid.Nil {
+		t.Errorf("grant was stored with its user %s attached", stored.User.ID)
+	}
+
+	after, err := s.GetAllowance(student.ID.String(), test.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.CanStart || after.ExtraAttempts != 2 || *after.Remaining != 2 {
+		t.Errorf("after the grant: %+v, want 2 attempts left", after)
+	}
+}
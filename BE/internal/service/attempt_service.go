@@ -0,0 +1,503 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// attemptGracePeriod absorbs network latency on the last submit before a deadline.
+const attemptGracePeriod = 30 * time.Second
+
+type StartAttemptRequest struct {
+	TestID string `json:"test_id" validate:"required,uuid"`
+}
+
+type SubmitSectionRequest struct {
+	Answers []UserAnswer `json:"answers"`
+}
+
+// AttemptQuestion is a question as shown to a student: without the key or explanation.
+type AttemptQuestion struct {
+	ID           uuid.UUID  `json:"id"`
+	SectionID    *uuid.UUID `json:"section_id,omitempty"`
+	Type         string     `json:"type"`
+	QuestionText string     `json:"question_text"`
+	Options      string     `json:"options"`
+	Points       float64    `json:"points"`
+}
+
+type AttemptSectionView struct {
+	ID       *uuid.UUID `json:"id,omitempty"`
+	Title    string     `json:"title"`
+	Index    int        `json:"index"`
+	Duration int        `json:"duration"`
+}
+
+// AttemptView is an attempt as the student sees it. Answers are the autosaved
+// answers of the open section.
+type AttemptView struct {
+	Attempt          *model.Attempt      `json:"attempt"`
+	TotalSections    int                 `json:"total_sections"`
+	Section          *AttemptSectionView `json:"section,omitempty"`
+	Questions        []AttemptQuestion   `json:"questions"`
+	RemainingSeconds int                 `json:"remaining_seconds"`
+	Result           *model.TestResult   `json:"result,omitempty"`
+	Adaptive         *AdaptiveProgress   `json:"adaptive,omitempty"`
+	Answers          []SavedAnswer       `json:"answers,omitempty"`
+}
+
+type AttemptService interface {
+	StartAttempt(userID string, req *StartAttemptRequest) (*AttemptView, error)
+	StartEventAttempt(userID uuid.UUID, event *model.TryoutEvent) (*AttemptView, error)
+	GetAttempt(userID, attemptID string) (*AttemptView, error)
+	SubmitSection(userID, attemptID string, req *SubmitSectionRequest) (*AttemptView, error)
+	NextQuestion(userID, attemptID string) (*AttemptView, error)
+	AnswerQuestion(userID, attemptID string, req *AnswerQuestionRequest) (*AttemptView, error)
+	SaveAnswers(userID, attemptID string, req *SaveAnswersRequest) (*SaveAnswersResponse, error)
+	FlushDrafts() (int, error)
+	StartDraftFlusher(interval time.Duration)
+}
+
+type attemptService struct {
+	attemptRepo       repository.AttemptRepository
+	testRepo          repository.TestRepository
+	questionRepo      repository.QuestionRepository
+	paramRepo         repository.ItemParameterRepository
+	eventRepo         repository.EventRepository
+	testResultService TestResultService
+	policyService     AttemptPolicyService
+	redisClient       *redis.Client
+}
+
+func NewAttemptService(attemptRepo repository.AttemptRepository, testRepo repository.TestRepository, questionRepo repository.QuestionRepository, paramRepo repository.ItemParameterRepository, eventRepo repository.EventRepository, testResultService TestResultService, policyService AttemptPolicyService, redisClient *redis.Client) AttemptService {
+	return &attemptService{attemptRepo, testRepo, questionRepo, paramRepo, eventRepo, testResultService, policyService, redisClient}
+}
+
+// paperSection is one timed block of an attempt. Tests without sections are served
+// as a single block that uses Test.Duration. optionOrders holds the option
+// permutation of every question whose options were shuffled.
+type paperSection struct {
+	section      *model.TestSection
+	questions    []model.Question
+	duration     int
+	optionOrders map[uuid.UUID][]int
+}
+
+func (p paperSection) toOriginal(answer UserAnswer) UserAnswer {
+	questionUUID, err := uuid.Parse(answer.QuestionID)
+	if err != nil {
+		return answer
+	}
+	if order, ok := p.optionOrders[questionUUID]; ok {
+		answer.SelectedAnswer = originalOption(order, answer.SelectedAnswer)
+	}
+	return answer
+}
+
+func servedQuestions(paper []paperSection) []model.Question {
+	var served []model.Question
+	for _, block := range paper {
+		served = append(served, block.questions...)
+	}
+	return served
+}
+
+func buildPaper(test *model.Test, questions []model.Question) []paperSection {
+	if len(test.Sections) == 0 {
+		ordered := append([]model.Question(nil), questions...)
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Position < ordered[j].Position })
+		return []paperSection{{questions: ordered, duration: test.Duration}}
+	}
+
+	paper := make([]paperSection, 0, len(test.Sections))
+	for i := range test.Sections {
+		section := &test.Sections[i]
+		block := paperSection{section: section, duration: section.Duration}
+		for _, q := range questions {
+			if q.SectionID != nil && *q.SectionID == section.ID {
+				block.questions = append(block.questions, q)
+			}
+		}
+		sort.SliceStable(block.questions, func(a, b int) bool { return block.questions[a].Position < block.questions[b].Position })
+		paper = append(paper, block)
+	}
+	return paper
+}
+
+func (s *attemptService) loadPaper(testID uuid.UUID) (*model.Test, []paperSection, error) {
+	test, err := s.testRepo.FindByID(testID)
+	if err != nil {
+		return nil, nil, errors.New("test not found")
+	}
+	questions, err := s.questionRepo.FindByTestID(testID)
+	if err != nil {
+		return nil, nil, errors.New("could not retrieve questions for the test")
+	}
+	return test, buildPaper(test, questions), nil
+}
+
+// loadAttemptPaper rebuilds the paper exactly as it was served to the attempt.
+func (s *attemptService) loadAttemptPaper(attempt *model.Attempt) (*model.Test, []paperSection, error) {
+	test, paper, err := s.loadPaper(attempt.TestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Attempts started before layouts were stored are served as authored.
+	if attempt.Layout == "" {
+		return test, paper, nil
+	}
+	var layout []model.AttemptLayoutItem
+	if err := json.Unmarshal([]byte(attempt.Layout), &layout); err != nil {
+		return nil, nil, errors.New("stored attempt layout is invalid")
+	}
+	return test, applyLayout(paper, layout), nil
+}
+
+func (s *attemptService) StartAttempt(userID string, req *StartAttemptRequest) (*AttemptView, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	testUUID, err := uuid.Parse(req.TestID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+
+	test, paper, err := s.loadPaper(testUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !test.IsAvailable(time.Now()) {
+		return nil, errors.New("test is not available")
+	}
+	// Event papers unlock for everyone at the start signal, through JoinEvent.
+	events, err := s.eventRepo.CountByTestID(test.ID)
+	if err != nil {
+		return nil, err
+	}
+	if events > 0 {
+		return nil, errors.New("test can only be taken in its tryout event")
+	}
+	release, err := s.policyService.ReserveAttempt(userUUID, test)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if test.Adaptive {
+		return s.startAdaptive(userUUID, test, time.Now(), nil)
+	}
+	attempt, paper, err := s.createAttempt(userUUID, test, paper, time.Now(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return buildAttemptView(attempt, paper, nil), nil
+}
+
+// StartEventAttempt starts an attempt on the event clock: its timers run from the
+// event start, so a late joiner gets the same deadlines as everyone else and lands
+// in whichever section is open by now.
+func (s *attemptService) StartEventAttempt(userID uuid.UUID, event *model.TryoutEvent) (*AttemptView, error) {
+	test, paper, err := s.loadPaper(event.TestID)
+	if err != nil {
+		return nil, err
+	}
+	if test.Adaptive {
+		return s.startAdaptive(userID, test, event.StartsAt, &event.ID)
+	}
+	attempt, paper, err := s.createAttempt(userID, test, paper, event.StartsAt, &event.ID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.closeExpiredSections(attempt, test, paper)
+	if err != nil {
+		return nil, err
+	}
+	return buildAttemptView(attempt, paper, result), nil
+}
+
+// createAttempt arranges a fresh paper for the student and stores an attempt whose
+// timers start at startedAt.
+func (s *attemptService) createAttempt(userUUID uuid.UUID, test *model.Test, paper []paperSection, startedAt time.Time, eventID *uuid.UUID) (*model.Attempt, []paperSection, error) {
+	seed := rand.Int63()
+	layout := arrangePaper(test, paper, seed)
+	layoutJSON, err := json.Marshal(layout)
+	if err != nil {
+		return nil, nil, err
+	}
+	paper = applyLayout(paper, layout)
+
+	totalDuration, totalQuestions := 0, 0
+	for _, block := range paper {
+		totalDuration += block.duration
+		totalQuestions += len(block.questions)
+	}
+	if totalQuestions == 0 {
+		return nil, nil, errors.New("test has no questions")
+	}
+
+	attempt := &model.Attempt{
+		ID:              uuid.New(),
+		TestID:          test.ID,
+		UserID:          userUUID,
+		Status:          model.AttemptStatusInProgress,
+		CurrentSection:  0,
+		SectionDeadline: startedAt.Add(time.Duration(paper[0].duration) * time.Minute),
+		Answers:         "[]",
+		Seed:            seed,
+		Layout:          string(layoutJSON),
+		StartedAt:       startedAt,
+		ExpiresAt:       startedAt.Add(time.Duration(totalDuration) * time.Minute),
+		EventID:         eventID,
+	}
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		return nil, nil, err
+	}
+	return attempt, paper, nil
+}
+
+func (s *attemptService) GetAttempt(userID, attemptID string) (*AttemptView, error) {
+	attempt, err := s.findOwnAttempt(userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	test, paper, err := s.loadAttemptPaper(attempt)
+	if err != nil {
+		return nil, err
+	}
+	if test.Adaptive {
+		view, err := s.continueAdaptive(attempt, test)
+		if err == errAttemptChanged {
+			return s.GetAttempt(userID, attemptID)
+		}
+		return view, err
+	}
+
+	result, err := s.closeExpiredSections(attempt, test, paper)
+	if err == errAttemptChanged {
+		// Another request locked the section first; show the attempt as it is now.
+		return s.GetAttempt(userID, attemptID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	view := buildAttemptView(attempt, paper, result)
+	if view.Section != nil {
+		view.Answers = restoreDraft(s.loadDraft(attempt.ID), paper[attempt.CurrentSection])
+	}
+	return view, nil
+}
+
+// SubmitSection stores the answers for the open section, locks it and opens the next
+// one. Submitting the last section grades the attempt. Autosaved answers count for
+// questions the request leaves out.
+func (s *attemptService) SubmitSection(userID, attemptID string, req *SubmitSectionRequest) (*AttemptView, error) {
+	attempt, err := s.findOwnAttempt(userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+	test, paper, err := s.loadAttemptPaper(attempt)
+	if err != nil {
+		return nil, err
+	}
+	if test.Adaptive {
+		return nil, errors.New("adaptive attempts are answered one question at a time")
+	}
+
+	openSection := attempt.CurrentSection
+	result, err := s.closeExpiredSections(attempt, test, paper)
+	if err == errAttemptChanged {
+		// Another request locked the expired section first.
+		return nil, errors.New("section time limit has passed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Status != model.AttemptStatusInProgress || attempt.CurrentSection != openSection {
+		return nil, errors.New("section time limit has passed")
+	}
+
+	// Only answers for the open section are accepted; locked and upcoming
+	// sections cannot be changed from here. Shuffled option indexes are
+	// mapped back to the authored ones before they are stored.
+	allowed := make(map[string]bool, len(paper[openSection].questions))
+	for _, q := range paper[openSection].questions {
+		allowed[q.ID.String()] = true
+	}
+	submitted := make(map[string]bool, len(req.Answers))
+	var accepted []UserAnswer
+	for _, answer := range req.Answers {
+		if allowed[answer.QuestionID] {
+			accepted = append(accepted, paper[openSection].toOriginal(answer))
+			submitted[answer.QuestionID] = true
+		}
+	}
+	for _, answer := range draftAnswers(s.loadDraft(attempt.ID), paper[openSection]) {
+		if !submitted[answer.QuestionID] {
+			accepted = append(accepted, answer)
+		}
+	}
+	if err := appendAttemptAnswers(attempt, accepted); err != nil {
+		return nil, err
+	}
+
+	result, err = s.advanceSection(attempt, test, paper, time.Now())
+	if err == errAttemptChanged {
+		// A submit sent at the same time, e.g. a retry, locked the section first.
+		return nil, errors.New("section has already been submitted")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildAttemptView(attempt, paper, result), nil
+}
+
+func (s *attemptService) findOwnAttempt(userID, attemptID string) (*model.Attempt, error) {
+	attemptUUID, err := uuid.Parse(attemptID)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	attempt, err := s.attemptRepo.FindByID(attemptUUID)
+	if err != nil || attempt.UserID.String() != userID {
+		return nil, errors.New("attempt not found")
+	}
+	return attempt, nil
+}
+
+// closeExpiredSections locks every section whose time ran out without a submit,
+// grading the attempt if that was the last one. Answers autosaved in a section are
+// kept when it locks.
+func (s *attemptService) closeExpiredSections(attempt *model.Attempt, test *model.Test, paper []paperSection) (*model.TestResult, error) {
+	var result *model.TestResult
+	var draft map[string]SavedAnswer
+	for attempt.Status == model.AttemptStatusInProgress && time.Now().After(attempt.SectionDeadline.Add(attemptGracePeriod)) {
+		if draft == nil {
+			draft = s.loadDraft(attempt.ID)
+		}
+		if attempt.CurrentSection < len(paper) {
+			if err := appendAttemptAnswers(attempt, draftAnswers(draft, paper[attempt.CurrentSection])); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		result, err = s.advanceSection(attempt, test, paper, attempt.SectionDeadline)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// errAttemptChanged is returned when another request moved the attempt on first.
+var errAttemptChanged = errors.New("attempt was changed by another request")
+
+// advanceSection locks the open section at the given moment and opens the next one,
+// whose timer starts at that moment.
+func (s *attemptService) advanceSection(attempt *model.Attempt, test *model.Test, paper []paperSection, lockedAt time.Time) (*model.TestResult, error) {
+	openSection := attempt.CurrentSection
+	attempt.CurrentSection++
+	if attempt.CurrentSection < len(paper) {
+		attempt.SectionDeadline = lockedAt.Add(time.Duration(paper[attempt.CurrentSection].duration) * time.Minute)
+		advanced, err := s.attemptRepo.Advance(attempt, model.AttemptStatusInProgress, openSection)
+		if err != nil {
+			return nil, err
+		}
+		if !advanced {
+			return nil, errAttemptChanged
+		}
+		return nil, nil
+	}
+
+	var answers []UserAnswer
+	if err := json.Unmarshal([]byte(attempt.Answers), &answers); err != nil {
+		return nil, errors.New("stored attempt answers are invalid")
+	}
+	// Only the questions this attempt was served count towards its score.
+	return s.finishAttempt(attempt, test, servedQuestions(paper), answers, openSection)
+}
+
+// finishAttempt marks the attempt submitted and grades it. The attempt is claimed
+// before grading, so when requests finish it at the same time only the one that
+// moved it out of openSection records a result.
+func (s *attemptService) finishAttempt(attempt *model.Attempt, test *model.Test, served []model.Question, answers []UserAnswer, openSection int) (*model.TestResult, error) {
+	now := time.Now()
+	attempt.Status = model.AttemptStatusSubmitted
+	attempt.SubmittedAt = &now
+	claimed, err := s.attemptRepo.Advance(attempt, model.AttemptStatusInProgress, openSection)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errAttemptChanged
+	}
+
+	result, err := s.testResultService.RecordAttempt(attempt, test, served, answers)
+	if err != nil {
+		// Reopen the attempt so the submit can be retried.
+		attempt.Status = model.AttemptStatusInProgress
+		attempt.SubmittedAt = nil
+		attempt.CurrentSection = openSection
+		_ = s.attemptRepo.Update(attempt)
+		return nil, err
+	}
+	attempt.TestResultID = &result.ID
+	if err := s.attemptRepo.Update(attempt); err != nil {
+		return nil, err
+	}
+	s.clearDraft(attempt.ID)
+	return result, nil
+}
+
+func appendAttemptAnswers(attempt *model.Attempt, answers []UserAnswer) error {
+	var stored []UserAnswer
+	if err := json.Unmarshal([]byte(attempt.Answers), &stored); err != nil {
+		return errors.New("stored attempt answers are invalid")
+	}
+	stored = append(stored, answers...)
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	attempt.Answers = string(encoded)
+	return nil
+}
+
+func buildAttemptView(attempt *model.Attempt, paper []paperSection, result *model.TestResult) *AttemptView {
+	view := &AttemptView{
+		Attempt:       attempt,
+		TotalSections: len(paper),
+		Questions:     []AttemptQuestion{},
+		Result:        result,
+	}
+	if attempt.Status != model.AttemptStatusInProgress || attempt.CurrentSection >= len(paper) {
+		return view
+	}
+
+	block := paper[attempt.CurrentSection]
+	view.Section = &AttemptSectionView{Index: attempt.CurrentSection, Duration: block.duration}
+	if block.section != nil {
+		view.Section.ID = &block.section.ID
+		view.Section.Title = block.section.Title
+	}
+	for _, q := range block.questions {
+		view.Questions = append(view.Questions, AttemptQuestion{
+			ID:           q.ID,
+			SectionID:    q.SectionID,
+			Type:         q.Type,
+			QuestionText: q.QuestionText,
+			Options:      shuffledOptions(q.Options, block.optionOrders[q.ID]),
+			Points:       q.Points,
+		})
+	}
+	if remaining := time.Until(attempt.SectionDeadline); remaining > 0 {
+		view.RemainingSeconds = int(remaining.Seconds())
+	}
+	return view
+}
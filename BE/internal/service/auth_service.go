@@ -25,12 +25,14 @@ type AuthService interface {
 
 type authService struct {
 	userRepo    repository.UserRepository
+	orgRepo     repository.OrganizationRepository
 	redisClient *redis.Client
 }
 
-func NewAuthService(userRepo repository.UserRepository, redisClient *redis.Client) AuthService {
+func NewAuthService(userRepo repository.UserRepository, orgRepo repository.OrganizationRepository, redisClient *redis.Client) AuthService {
 	return &authService{
 		userRepo:    userRepo,
+		orgRepo:     orgRepo,
 		redisClient: redisClient,
 	}
 }
@@ -69,7 +71,18 @@ func (s *authService) GetMe(userID string) (*model.User, error) {
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
-	return s.userRepo.FindUserByID(userUUID)
+	user, err := s.userRepo.FindUserByID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	// A seat in an organization with a running group license counts as premium. It is
+	// not stored on the user, so it ends with the license or the seat.
+	if !user.IsPremium {
+		if seated, err := s.orgRepo.HasActiveSeat(userUUID, time.Now()); err == nil && seated {
+			user.IsPremium = true
+		}
+	}
+	return user, nil
 }
 
 func (s *authService) Logout(userID string) error {
@@ -100,7 +113,7 @@ func (s *authService) UpdateUserRoleAndPremium(userID string, newRole string, is
 	}
 
 	// Validasi role
-	if newRole != "admin" && newRole != "user" {
+	if newRole != "admin" && newRole != "user" && newRole != "grader" {
 		return nil, errors.New("invalid role")
 	}
 
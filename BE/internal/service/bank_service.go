@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+type BankItemRequest struct {
+	Topic         string  `json:"topic"`
+	Tags          string  `json:"tags"`
+	Difficulty    string  `json:"difficulty"`
+	Type          string  `json:"type" validate:"omitempty,oneof=multiple_choice essay"`
+	QuestionText  string  `json:"question_text" validate:"required"`
+	Options       string  `json:"options" validate:"omitempty,json"`
+	CorrectAnswer int     `json:"correct_answer" validate:"gte=0"`
+	Explanation   string  `json:"explanation"`
+	Rubric        string  `json:"rubric" validate:"omitempty,json"`
+	MaxScore      float64 `json:"max_score" validate:"gte=0"`
+}
+
+type BankLinkRequest struct {
+	BankItemID    string  `json:"bank_item_id" validate:"required,uuid"`
+	PinnedVersion *int    `json:"pinned_version" validate:"omitempty,gt=0"`
+	SectionID     string  `json:"section_id" validate:"omitempty,uuid"`
+	Position      int     `json:"position" validate:"gte=0"`
+	Points        float64 `json:"points" validate:"gte=0"`
+	Penalty       float64 `json:"penalty" validate:"gte=0"`
+}
+
+type BankService interface {
+	CreateItem(authorID string, req *BankItemRequest) (*model.BankItem, error)
+	GetItems(filter repository.BankItemFilter) ([]model.BankItem, error)
+	GetItemByID(id string) (*model.BankItem, error)
+	UpdateItem(editorID, id string, req *BankItemRequest) (*model.BankItem, error)
+	DeleteItem(id string) error
+
+	GetTestLinks(testID string) ([]model.TestBankItem, error)
+	LinkItem(testID string, req *BankLinkRequest) (*model.TestBankItem, error)
+	UpdateLink(testID string, req *BankLinkRequest) (*model.TestBankItem, error)
+	UnlinkItem(testID, itemID string) error
+}
+
+type bankService struct {
+	repo        repository.BankRepository
+	testRepo    repository.TestRepository
+	sectionRepo repository.TestSectionRepository
+}
+
+func NewBankService(repo repository.BankRepository, testRepo repository.TestRepository, sectionRepo repository.TestSectionRepository) BankService {
+	return &bankService{repo, testRepo, sectionRepo}
+}
+
+// bankVersionFromRequest validates the content part of a request with the same rules
+// as a test question.
+func bankVersionFromRequest(req *BankItemRequest) (*model.BankItemVersion, error) {
+	question := &model.Question{
+		Type:          req.Type,
+		QuestionText:  req.QuestionText,
+		Options:       req.Options,
+		CorrectAnswer: req.CorrectAnswer,
+		Explanation:   req.Explanation,
+		Rubric:        req.Rubric,
+		MaxScore:      req.MaxScore,
+	}
+	if err := normalizeQuestion(question); err != nil {
+		return nil, err
+	}
+	return &model.BankItemVersion{
+		ID:            uuid.New(),
+		Type:          question.Type,
+		QuestionText:  question.QuestionText,
+		Options:       question.Options,
+		CorrectAnswer: question.CorrectAnswer,
+		Explanation:   question.Explanation,
+		Rubric:        question.Rubric,
+		MaxScore:      question.MaxScore,
+	}, nil
+}
+
+func sameContent(a, b *model.BankItemVersion) bool {
+	return a.Type == b.Type &&
+		a.QuestionText == b.QuestionText &&
+		a.Options == b.Options &&
+		a.CorrectAnswer == b.CorrectAnswer &&
+		a.Explanation == b.Explanation &&
+		a.Rubric == b.Rubric &&
+		a.MaxScore == b.MaxScore
+}
+
+func (s *bankService) CreateItem(authorID string, req *BankItemRequest) (*model.BankItem, error) {
+	authorUUID, err := uuid.Parse(authorID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	version, err := bankVersionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.BankItem{
+		ID:             uuid.New(),
+		Topic:          req.Topic,
+		Tags:           req.Tags,
+		Difficulty:     req.Difficulty,
+		AuthorID:       authorUUID,
+		CurrentVersion: 1,
+	}
+	version.BankItemID = item.ID
+	version.Version = 1
+	version.CreatedBy = authorUUID
+
+	if err := s.repo.CreateItem(item, version); err != nil {
+		return nil, err
+	}
+	item.Versions = []model.BankItemVersion{*version}
+	return item, nil
+}
+
+func (s *bankService) GetItems(filter repository.BankItemFilter) ([]model.BankItem, error) {
+	return s.repo.FindItems(filter)
+}
+
+func (s *bankService) GetItemByID(id string) (*model.BankItem, error) {
+	itemUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	item, err := s.repo.FindItemByID(itemUUID)
+	if err != nil {
+		return nil, errors.New("bank item not found")
+	}
+	return item, nil
+}
+
+// UpdateItem edits the metadata in place. A change to the content is stored as a new
+// version so answers given to an older version still point at what was shown.
+func (s *bankService) UpdateItem(editorID, id string, req *BankItemRequest) (*model.BankItem, error) {
+	editorUUID, err := uuid.Parse(editorID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	item, err := s.GetItemByID(id)
+	if err != nil {
+		return nil, err
+	}
+	version, err := bankVersionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Topic = req.Topic
+	item.Tags = req.Tags
+	item.Difficulty = req.Difficulty
+
+	var current *model.BankItemVersion
+	for i := range item.Versions {
+		if item.Versions[i].Version == item.CurrentVersion {
+			current = &item.Versions[i]
+		}
+	}
+
+	if current != nil && sameContent(current, version) {
+		if err := s.repo.UpdateItem(item); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+
+	latest := 0
+	for _, v := range item.Versions {
+		if v.Version > latest {
+			latest = v.Version
+		}
+	}
+	version.BankItemID = item.ID
+	version.Version = latest + 1
+	version.CreatedBy = editorUUID
+	if err := s.repo.AddVersion(item, version); err != nil {
+		return nil, err
+	}
+	item.Versions = append([]model.BankItemVersion{*version}, item.Versions...)
+	return item, nil
+}
+
+func (s *bankService) DeleteItem(id string) error {
+	itemUUID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+	links, err := s.repo.CountLinks(itemUUID)
+	if err != nil {
+		return err
+	}
+	if links > 0 {
+		return errors.New("bank item is still used by a test")
+	}
+	return s.repo.DeleteItem(itemUUID)
+}
+
+func (s *bankService) GetTestLinks(testID string) ([]model.TestBankItem, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.repo.FindLinksByTestID(testUUID)
+}
+
+func (s *bankService) LinkItem(testID string, req *BankLinkRequest) (*model.TestBankItem, error) {
+	link, err := s.linkFromRequest(testID, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindLink(link.TestID, link.BankItemID); err == nil {
+		return nil, errors.New("bank item is already linked to this test")
+	}
+
+	link.ID = uuid.New()
+	if err := s.repo.CreateLink(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *bankService) UpdateLink(testID string, req *BankLinkRequest) (*model.TestBankItem, error) {
+	updated, err := s.linkFromRequest(testID, req)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.repo.FindLink(updated.TestID, updated.BankItemID)
+	if err != nil {
+		return nil, errors.New("bank item is not linked to this test")
+	}
+
+	link.PinnedVersion = updated.PinnedVersion
+	link.SectionID = updated.SectionID
+	link.Position = updated.Position
+	link.Points = updated.Points
+	link.Penalty = updated.Penalty
+	if err := s.repo.UpdateLink(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *bankService) UnlinkItem(testID, itemID string) error {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return errors.New("invalid test id format")
+	}
+	itemUUID, err := uuid.Parse(itemID)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+	if err := s.repo.DeleteLink(testUUID, itemUUID); err != nil {
+		return errors.New("bank item is not linked to this test")
+	}
+	return nil
+}
+
+func (s *bankService) linkFromRequest(testID string, req *BankLinkRequest) (*model.TestBankItem, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	if _, err := s.testRepo.FindByID(testUUID); err != nil {
+		return nil, errors.New("test not found")
+	}
+	item, err := s.GetItemByID(req.BankItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PinnedVersion != nil {
+		found := false
+		for _, v := range item.Versions {
+			found = found || v.Version == *req.PinnedVersion
+		}
+		if !found {
+			return nil, errors.New("pinned version does not exist")
+		}
+	}
+
+	link := &model.TestBankItem{
+		TestID:        testUUID,
+		BankItemID:    item.ID,
+		PinnedVersion: req.PinnedVersion,
+		Position:      req.Position,
+		Points:        req.Points,
+		Penalty:       req.Penalty,
+	}
+	if link.Points <= 0 {
+		link.Points = 1
+	}
+	if req.SectionID != "" {
+		sectionUUID, err := uuid.Parse(req.SectionID)
+		if err != nil {
+			return nil, errors.New("invalid section id format")
+		}
+		section, err := s.sectionRepo.FindByID(sectionUUID)
+		if err != nil || section.TestID != testUUID {
+			return nil, errors.New("section not found in this test")
+		}
+		link.SectionID = &sectionUUID
+	}
+	return link, nil
+}
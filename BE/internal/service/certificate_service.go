@@ -0,0 +1,430 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/certificate"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/pdf"
+	"github.com/google/uuid"
+)
+
+// CertificateVerification is what the public verify endpoint tells a third party
+// about a serial. Details are only filled in for certificates that exist.
+type CertificateVerification struct {
+	Serial        string     `json:"serial"`
+	Valid         bool       `json:"valid"`
+	Status        string     `json:"status"`
+	Kind          string     `json:"kind,omitempty"`
+	RecipientName string     `json:"recipient_name,omitempty"`
+	TestTitle     string     `json:"test_title,omitempty"`
+	CourseTitle   string     `json:"course_title,omitempty"`
+	Score         *float64   `json:"score,omitempty"`
+	IssuedAt      *time.Time `json:"issued_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+const (
+	CertificateValid    = "valid"
+	CertificateRevoked  = "revoked"
+	CertificateNotFound = "not_found"
+	// CertificateTampered means the stored certificate no longer matches its serial.
+	CertificateTampered = "tampered"
+)
+
+// courseIncomplete is why a course certificate is revoked when the class it was
+// issued for is no longer completed. Only certificates revoked for this reason are
+// reinstated when the class is completed again.
+const courseIncomplete = "class no longer completed"
+
+// notOfficial is why a test certificate is revoked when a later result replaces the
+// one it was issued for as the official result. Only certificates revoked for this
+// reason are reinstated when their result becomes official again.
+const notOfficial = "result is no longer the official result"
+
+// Kinds of certificate: for passing a test or for completing a premium class.
+const (
+	CertificateKindTest   = "test"
+	CertificateKindCourse = "course"
+)
+
+type CertificateService interface {
+	IssueCertificate(resultID uuid.UUID) (*model.Certificate, error)
+	RenderCertificate(userID, userRole, resultID string) ([]byte, *model.Certificate, error)
+	VerifyCertificate(serial string) *CertificateVerification
+	IssueCourseCertificate(userID uuid.UUID, class *model.PremiumClass) (*model.CourseCertificate, error)
+	FindCourseCertificate(classID, userID uuid.UUID) (*model.CourseCertificate, error)
+	RevokeCourseCertificate(classID, userID uuid.UUID) error
+	RenderCourseCertificate(issued *model.CourseCertificate) ([]byte, error)
+	ResultListener
+}
+
+type certificateService struct {
+	certificateRepo repository.CertificateRepository
+	resultRepo      repository.TestResultRepository
+	userRepo        repository.UserRepository
+	signer          *certificate.Signer
+}
+
+func NewCertificateService(certificateRepo repository.CertificateRepository, resultRepo repository.TestResultRepository, userRepo repository.UserRepository, secret string) CertificateService {
+	return &certificateService{certificateRepo, resultRepo, userRepo, certificate.NewSigner(secret)}
+}
+
+// certificateDetails is the text a serial's signature covers.
+func certificateDetails(c *model.Certificate) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%.2f|%d", c.TestResultID, c.UserID, c.TestID, c.RecipientName, c.TestTitle, c.Score, c.IssuedAt.Unix())
+}
+
+// courseCertificateDetails is the text a course certificate serial's signature
+// covers. The leading kind keeps it from ever matching a test certificate's details.
+func courseCertificateDetails(c *model.CourseCertificate) string {
+	return fmt.Sprintf("course|%s|%s|%s|%s|%d", c.ClassID, c.UserID, c.RecipientName, c.CourseTitle, c.IssuedAt.Unix())
+}
+
+// ResultFinalized follows the student's official result on the test: the certificate
+// of a result that is no longer official is revoked, and a passing official result
+// gets one.
+func (s *certificateService) ResultFinalized(result *model.TestResult) {
+	s.followOfficial(result.UserID, result.TestID)
+}
+
+// ResultInvalidated revokes the certificate of an invalidated result. Another result
+// may have become official in its place.
+func (s *certificateService) ResultInvalidated(result *model.TestResult) {
+	if issued, err := s.certificateRepo.FindByResultID(result.ID); err == nil && issued.RevokedAt == nil {
+		now := time.Now()
+		issued.RevokedAt = &now
+		issued.RevokedReason = "result invalidated"
+		if err := s.certificateRepo.Update(issued); err != nil {
+			log.Printf("certificates: could not revoke certificate %s: %v", issued.Serial, err)
+		}
+	}
+	s.followOfficial(result.UserID, result.TestID)
+}
+
+// followOfficial leaves the student with a certificate on the test only for their
+// official result, and only if it passed.
+func (s *certificateService) followOfficial(userID, testID uuid.UUID) {
+	results, err := s.resultRepo.FindByUserAndTest(userID, testID)
+	if err != nil {
+		log.Printf("certificates: could not load results of user %s on test %s: %v", userID, testID, err)
+		return
+	}
+	var official *model.TestResult
+	for i := range results {
+		if results[i].IsOfficial {
+			official = &results[i]
+		}
+	}
+
+	certificates, err := s.certificateRepo.FindByUserAndTest(userID, testID)
+	if err != nil {
+		log.Printf("certificates: could not load certificates of user %s on test %s: %v", userID, testID, err)
+		return
+	}
+	for i := range certificates {
+		issued := &certificates[i]
+		if issued.RevokedAt != nil || (official != nil && issued.TestResultID == official.ID) {
+			continue
+		}
+		now := time.Now()
+		issued.RevokedAt = &now
+		issued.RevokedReason = notOfficial
+		if err := s.certificateRepo.Update(issued); err != nil {
+			log.Printf("certificates: could not revoke certificate %s: %v", issued.Serial, err)
+		}
+	}
+
+	if official == nil || official.Passed == nil || !*official.Passed {
+		return
+	}
+	if _, err := s.IssueCertificate(official.ID); err != nil {
+		log.Printf("certificates: could not issue certificate for result %s: %v", official.ID, err)
+	}
+}
+
+// IssueCertificate returns the certificate of a result, issuing it first if needed.
+// Only passing official results get one; a certificate revoked because its result
+// stopped being official is reinstated.
+func (s *certificateService) IssueCertificate(resultID uuid.UUID) (*model.Certificate, error) {
+	existing, findErr := s.certificateRepo.FindByResultID(resultID)
+	if findErr == nil && (existing.RevokedAt == nil || existing.RevokedReason != notOfficial) {
+		return existing, nil
+	}
+	result, err := s.resultRepo.FindByID(resultID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	if result.Status != model.ResultStatusCompleted || !result.IsOfficial || result.Passed == nil || !*result.Passed {
+		if findErr == nil {
+			return existing, nil
+		}
+		return nil, errors.New("certificates are only issued for passing official results")
+	}
+	if findErr == nil {
+		// The result is official again; its certificate counts again.
+		existing.RevokedAt = nil
+		existing.RevokedReason = ""
+		if err := s.certificateRepo.Update(existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	student, err := s.userRepo.FindUserByID(result.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	issued := &model.Certificate{
+		ID:            uuid.New(),
+		TestResultID:  result.ID,
+		UserID:        result.UserID,
+		TestID:        result.TestID,
+		RecipientName: student.Name,
+		TestTitle:     result.Test.Title,
+		Score:         result.Score,
+		// Stored to the second, so the signed details survive the database round trip.
+		IssuedAt: time.Now().Truncate(time.Second),
+	}
+	issued.Serial, err = s.signer.NewSerial(certificateDetails(issued))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.certificateRepo.Create(issued); err != nil {
+		// Another request may have issued it at the same moment.
+		if existing, findErr := s.certificateRepo.FindByResultID(resultID); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return issued, nil
+}
+
+// VerifyCertificate checks a serial against the stored certificate and its signature.
+func (s *certificateService) VerifyCertificate(serial string) *CertificateVerification {
+	serial = certificate.Normalize(serial)
+	verification := &CertificateVerification{Serial: serial, Status: CertificateNotFound}
+	issued, err := s.certificateRepo.FindBySerial(serial)
+	if err != nil {
+		return s.verifyCourseCertificate(verification)
+	}
+	verification.Kind = CertificateKindTest
+	if !s.signer.Valid(issued.Serial, certificateDetails(issued)) {
+		verification.Status = CertificateTampered
+		return verification
+	}
+
+	score, issuedAt := issued.Score, issued.IssuedAt
+	verification.RecipientName = issued.RecipientName
+	verification.TestTitle = issued.TestTitle
+	verification.Score = &score
+	verification.IssuedAt = &issuedAt
+	verification.RevokedAt = issued.RevokedAt
+	if issued.RevokedAt != nil {
+		verification.Status = CertificateRevoked
+		return verification
+	}
+	verification.Valid = true
+	verification.Status = CertificateValid
+	return verification
+}
+
+// verifyCourseCertificate looks a serial up among the course certificates.
+func (s *certificateService) verifyCourseCertificate(verification *CertificateVerification) *CertificateVerification {
+	issued, err := s.certificateRepo.FindCourseBySerial(verification.Serial)
+	if err != nil {
+		return verification
+	}
+	verification.Kind = CertificateKindCourse
+	if !s.signer.Valid(issued.Serial, courseCertificateDetails(issued)) {
+		verification.Status = CertificateTampered
+		return verification
+	}
+
+	issuedAt := issued.IssuedAt
+	verification.RecipientName = issued.RecipientName
+	verification.CourseTitle = issued.CourseTitle
+	verification.IssuedAt = &issuedAt
+	verification.RevokedAt = issued.RevokedAt
+	if issued.RevokedAt != nil {
+		verification.Status = CertificateRevoked
+		return verification
+	}
+	verification.Valid = true
+	verification.Status = CertificateValid
+	return verification
+}
+
+// IssueCourseCertificate returns the user's certificate for a class, issuing it
+// first if needed, or reinstating it if it was revoked because the class stopped
+// being completed. The caller decides that the class has been completed.
+func (s *certificateService) IssueCourseCertificate(userID uuid.UUID, class *model.PremiumClass) (*model.CourseCertificate, error) {
+	if issued, err := s.certificateRepo.FindCourse(class.ID, userID); err == nil {
+		if issued.RevokedAt != nil && issued.RevokedReason == courseIncomplete {
+			issued.RevokedAt = nil
+			issued.RevokedReason = ""
+			if err := s.certificateRepo.UpdateCourse(issued); err != nil {
+				return nil, err
+			}
+		}
+		return issued, nil
+	}
+	student, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	issued := &model.CourseCertificate{
+		ID:            uuid.New(),
+		ClassID:       class.ID,
+		UserID:        userID,
+		RecipientName: student.Name,
+		CourseTitle:   class.Title,
+		IssuedAt:      time.Now().Truncate(time.Second),
+	}
+	issued.Serial, err = s.signer.NewSerial(courseCertificateDetails(issued))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.certificateRepo.CreateCourse(issued); err != nil {
+		if existing, findErr := s.certificateRepo.FindCourse(class.ID, userID); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return issued, nil
+}
+
+func (s *certificateService) FindCourseCertificate(classID, userID uuid.UUID) (*model.CourseCertificate, error) {
+	return s.certificateRepo.FindCourse(classID, userID)
+}
+
+// RevokeCourseCertificate revokes the user's certificate for a class they no longer
+// have completed. It does nothing when there is no certificate to revoke.
+func (s *certificateService) RevokeCourseCertificate(classID, userID uuid.UUID) error {
+	issued, err := s.certificateRepo.FindCourse(classID, userID)
+	if err != nil || issued.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	issued.RevokedAt = &now
+	issued.RevokedReason = courseIncomplete
+	return s.certificateRepo.UpdateCourse(issued)
+}
+
+// RenderCourseCertificate draws the landscape certificate of a completed class.
+func (s *certificateService) RenderCourseCertificate(issued *model.CourseCertificate) ([]byte, error) {
+	if issued.RevokedAt != nil {
+		return nil, errors.New("certificate has been revoked")
+	}
+	return drawCertificate(certificateFace{
+		Serial:        issued.Serial,
+		RecipientName: issued.RecipientName,
+		Statement:     "has completed the course",
+		Title:         issued.CourseTitle,
+		IssuedAt:      issued.IssuedAt,
+	})
+}
+
+// RenderCertificate draws the landscape certificate of a passing result, issuing it
+// on first download. Only the student who took the result and admins can download
+// it; anyone else is told the result does not exist, whether it passed or not.
+func (s *certificateService) RenderCertificate(userID, userRole, resultID string) ([]byte, *model.Certificate, error) {
+	resultUUID, err := uuid.Parse(resultID)
+	if err != nil {
+		return nil, nil, errors.New("invalid id format")
+	}
+	result, err := s.resultRepo.FindByID(resultUUID)
+	if err != nil || (userRole != "admin" && result.UserID.String() != userID) {
+		return nil, nil, errors.New("test result not found")
+	}
+	issued, err := s.IssueCertificate(result.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if issued.RevokedAt != nil {
+		return nil, issued, errors.New("certificate has been revoked")
+	}
+
+	data, err := drawCertificate(certificateFace{
+		Serial:        issued.Serial,
+		RecipientName: issued.RecipientName,
+		Statement:     "has successfully passed",
+		Title:         issued.TestTitle,
+		Detail:        fmt.Sprintf("with a score of %.2f", issued.Score),
+		IssuedAt:      issued.IssuedAt,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, issued, nil
+}
+
+// certificateFace is the text printed on a certificate. Detail is an optional line
+// under the title.
+type certificateFace struct {
+	Serial        string
+	RecipientName string
+	Statement     string
+	Title         string
+	Detail        string
+	IssuedAt      time.Time
+}
+
+// drawCertificate renders the landscape certificate shared by tests and courses.
+func drawCertificate(face certificateFace) ([]byte, error) {
+	doc := pdf.New(pdf.A4Landscape, "Certificate "+face.Serial)
+	page := doc.AddPage()
+	size := doc.Size()
+	center := size.Width / 2
+
+	page.SetStrokeColor(brandColor)
+	page.SetLineWidth(6)
+	page.Rect(24, 24, size.Width-48, size.Height-48, false, true)
+	page.SetLineWidth(1)
+	page.Rect(36, 36, size.Width-72, size.Height-72, false, true)
+
+	page.SetFillColor(brandColor)
+	page.SetFont(pdf.HelveticaBold, 20)
+	page.Text(center, 100, pdf.AlignCenter, "EduTest+")
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 36)
+	page.Text(center, 170, pdf.AlignCenter, "Certificate of Completion")
+
+	page.SetFillColor(mutedColor)
+	page.SetFont(pdf.Helvetica, 14)
+	page.Text(center, 220, pdf.AlignCenter, "This certifies that")
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 30)
+	page.Text(center, 268, pdf.AlignCenter, fitText(page, face.RecipientName, size.Width-160))
+	page.SetStrokeColor(lineColor)
+	page.Line(center-200, 282, center+200, 282)
+
+	page.SetFillColor(mutedColor)
+	page.SetFont(pdf.Helvetica, 14)
+	page.Text(center, 318, pdf.AlignCenter, face.Statement)
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 20)
+	y := 354.0
+	for _, line := range page.WrapText(face.Title, size.Width-200) {
+		page.Text(center, y, pdf.AlignCenter, line)
+		y += 26
+	}
+	if face.Detail != "" {
+		page.SetFont(pdf.Helvetica, 14)
+		page.Text(center, y+8, pdf.AlignCenter, face.Detail)
+	}
+
+	page.SetFillColor(mutedColor)
+	page.SetFont(pdf.Helvetica, 11)
+	page.Text(72, size.Height-80, pdf.AlignLeft, "Issued "+face.IssuedAt.Format("2 January 2006"))
+	page.Text(size.Width-72, size.Height-80, pdf.AlignRight, "Serial "+face.Serial)
+	page.SetFont(pdf.Helvetica, 9)
+	page.Text(center, size.Height-60, pdf.AlignCenter, "Verify this certificate at /api/certificates/"+face.Serial+"/verify")
+
+	return doc.Bytes()
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+// itemPremiumClass is the order item type that buys one premium class for good.
+const itemPremiumClass = "premium_class"
+
+type ModuleRequest struct {
+	Title       string `json:"title" validate:"required,max=255"`
+	Description string `json:"description"`
+	Position    *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type LessonRequest struct {
+	Title          string                    `json:"title" validate:"required,max=255"`
+	Position       *int                      `json:"position" validate:"omitempty,min=0"`
+	VideoURL       string                    `json:"video_url" validate:"omitempty,url,max=500"`
+	VideoDuration  int                       `json:"video_duration" validate:"min=0"`
+	Content        string                    `json:"content"`
+	Attachments    []LessonAttachmentRequest `json:"attachments" validate:"dive"`
+	PracticeTestID string                    `json:"practice_test_id" validate:"omitempty,uuid"`
+}
+
+type LessonAttachmentRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	URL  string `json:"url" validate:"required,url"`
+}
+
+// LessonSummary is what anyone can see of a lesson before enrolling.
+type LessonSummary struct {
+	ID              uuid.UUID `json:"id"`
+	ModuleID        uuid.UUID `json:"module_id"`
+	Title           string    `json:"title"`
+	Position        int       `json:"position"`
+	HasVideo        bool      `json:"has_video"`
+	VideoDuration   int       `json:"video_duration"`
+	HasPracticeTest bool      `json:"has_practice_test"`
+}
+
+type ModuleOutline struct {
+	ID          uuid.UUID       `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Position    int             `json:"position"`
+	Lessons     []LessonSummary `json:"lessons"`
+}
+
+// PracticeTestSummary names the test linked to a lesson.
+type PracticeTestSummary struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+}
+
+// LessonView is a lesson's full content, served to enrolled users only.
+type LessonView struct {
+	*model.Lesson
+	Attachments  []model.LessonAttachment `json:"attachments"`
+	PracticeTest *PracticeTestSummary     `json:"practice_test,omitempty"`
+	Progress     *LessonProgressView      `json:"progress,omitempty"`
+}
+
+type ClassContentService interface {
+	GetOutline(classID string) ([]ModuleOutline, error)
+	GetLesson(userID, userRole, classID, lessonID string) (*LessonView, error)
+	GetClassDetail(userID, classID string) (*ClassDetail, error)
+
+	UpdateProgress(userID, classID, lessonID string, req *ProgressRequest) (*LessonProgressView, error)
+	CompleteLesson(userID, classID, lessonID string) (*LessonProgressView, error)
+	GetClassProgress(userID, classID string) (*ClassProgress, error)
+	RenderCourseCertificate(userID, classID string) ([]byte, *model.CourseCertificate, error)
+	ResultListener
+	ResultInvalidated(result *model.TestResult)
+
+	Enroll(userID, classID string) (*model.ClassEnrollment, error)
+	GetMyEnrollments(userID string) ([]model.ClassEnrollment, error)
+	GetEnrollments(classID string) ([]model.ClassEnrollment, error)
+
+	CreateModule(classID string, req *ModuleRequest) (*model.ClassModule, error)
+	UpdateModule(classID, moduleID string, req *ModuleRequest) (*model.ClassModule, error)
+	DeleteModule(classID, moduleID string) error
+	CreateLesson(classID, moduleID string, req *LessonRequest) (*LessonView, error)
+	UpdateLesson(classID, lessonID string, req *LessonRequest) (*LessonView, error)
+	DeleteLesson(classID, lessonID string) error
+}
+
+type classContentService struct {
+	contentRepo repository.ClassContentRepository
+	classRepo   repository.PremiumClassRepository
+	testRepo    repository.TestRepository
+	orderRepo   repository.OrderRepository
+	userRepo    repository.UserRepository
+	orgRepo     repository.OrganizationRepository
+	resultRepo  repository.TestResultRepository
+
+	certificateService CertificateService
+}
+
+func NewClassContentService(contentRepo repository.ClassContentRepository, classRepo repository.PremiumClassRepository, testRepo repository.TestRepository, orderRepo repository.OrderRepository, userRepo repository.UserRepository, orgRepo repository.OrganizationRepository, resultRepo repository.TestResultRepository, certificateService CertificateService) ClassContentService {
+	return &classContentService{contentRepo, classRepo, testRepo, orderRepo, userRepo, orgRepo, resultRepo, certificateService}
+}
+
+func (s *classContentService) findClass(classID string) (*model.PremiumClass, error) {
+	classUUID, err := uuid.Parse(classID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	class, err := s.classRepo.FindByID(classUUID)
+	if err != nil {
+		return nil, errors.New("class not found")
+	}
+	return class, nil
+}
+
+// hasPremium reports whether the user currently has premium access, either their
+// own membership or a seat in an organization with a running group license.
+func (s *classContentService) hasPremium(userID uuid.UUID, now time.Time) bool {
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return false
+	}
+	if user.IsPremium && (user.PremiumExpiresAt == nil || user.PremiumExpiresAt.After(now)) {
+		return true
+	}
+	seated, err := s.orgRepo.HasActiveSeat(userID, now)
+	return err == nil && seated
+}
+
+func (s *classContentService) GetOutline(classID string) ([]ModuleOutline, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := s.contentRepo.FindModules(class.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outline := make([]ModuleOutline, len(modules))
+	for i, module := range modules {
+		outline[i] = ModuleOutline{
+			ID:          module.ID,
+			Title:       module.Title,
+			Description: module.Description,
+			Position:    module.Position,
+			Lessons:     make([]LessonSummary, len(module.Lessons)),
+		}
+		for j, lesson := range module.Lessons {
+			outline[i].Lessons[j] = LessonSummary{
+				ID:              lesson.ID,
+				ModuleID:        lesson.ModuleID,
+				Title:           lesson.Title,
+				Position:        lesson.Position,
+				HasVideo:        lesson.VideoURL != "",
+				VideoDuration:   lesson.VideoDuration,
+				HasPracticeTest: lesson.PracticeTestID != nil,
+			}
+		}
+	}
+	return outline, nil
+}
+
+// checkAccess lets admins and enrolled users in. Enrollments made through a premium
+// membership stop giving access when the membership ends.
+func (s *classContentService) checkAccess(userID, userRole string, classID uuid.UUID) error {
+	if userRole == "admin" {
+		return nil
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID format")
+	}
+	enrollment, err := s.contentRepo.FindEnrollment(classID, userUUID)
+	if err != nil {
+		return errors.New("not enrolled in this class")
+	}
+	if enrollment.Source == model.EnrollmentSourcePremium && !s.hasPremium(userUUID, time.Now()) {
+		return errors.New("premium membership has expired")
+	}
+	return nil
+}
+
+// GetLesson serves a lesson's content to admins and enrolled users. For students it
+// also starts the lesson and includes their progress.
+func (s *classContentService) GetLesson(userID, userRole, classID, lessonID string) (*LessonView, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	lessonUUID, err := uuid.Parse(lessonID)
+	if err != nil {
+		return nil, errors.New("lesson not found")
+	}
+	lesson, err := s.contentRepo.FindLesson(class.ID, lessonUUID)
+	if err != nil {
+		return nil, errors.New("lesson not found")
+	}
+	if err := s.checkAccess(userID, userRole, class.ID); err != nil {
+		return nil, err
+	}
+	view := s.lessonView(lesson)
+	if userRole == "admin" {
+		return view, nil
+	}
+
+	// Opening a lesson starts it.
+	userUUID, _ := uuid.Parse(userID)
+	if _, err := s.startLesson(userUUID, lesson); err != nil {
+		return nil, err
+	}
+	if view.Progress, err = s.lessonProgressView(userUUID, class, lesson.ID); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+func (s *classContentService) lessonView(lesson *model.Lesson) *LessonView {
+	view := &LessonView{Lesson: lesson, Attachments: []model.LessonAttachment{}}
+	if lesson.Attachments != "" {
+		_ = json.Unmarshal([]byte(lesson.Attachments), &view.Attachments)
+	}
+	if lesson.PracticeTestID != nil {
+		if test, err := s.testRepo.FindByID(*lesson.PracticeTestID); err == nil {
+			view.PracticeTest = &PracticeTestSummary{ID: test.ID, Title: test.Title}
+		}
+	}
+	return view
+}
+
+// Enroll enrolls the user in a class they bought or, failing that, through their
+// premium membership. Enrolling again returns the existing enrollment, moved over to
+// the order if the class has since been bought.
+func (s *classContentService) Enroll(userID, classID string) (*model.ClassEnrollment, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderID *uuid.UUID
+	if order, err := s.orderRepo.FindCompleted(userUUID, itemPremiumClass, class.ID); err == nil {
+		orderID = &order.ID
+	}
+
+	if enrollment, err := s.contentRepo.FindEnrollment(class.ID, userUUID); err == nil {
+		if orderID != nil && enrollment.Source != model.EnrollmentSourceOrder {
+			enrollment.Source = model.EnrollmentSourceOrder
+			enrollment.OrderID = orderID
+			if err := s.contentRepo.UpdateEnrollment(enrollment); err != nil {
+				return nil, err
+			}
+		}
+		return enrollment, nil
+	}
+
+	enrollment := &model.ClassEnrollment{
+		ID:      uuid.New(),
+		ClassID: class.ID,
+		UserID:  userUUID,
+		Source:  model.EnrollmentSourceOrder,
+		OrderID: orderID,
+	}
+	if orderID == nil {
+		if !s.hasPremium(userUUID, time.Now()) {
+			return nil, errors.New("premium membership or class purchase required")
+		}
+		enrollment.Source = model.EnrollmentSourcePremium
+	}
+	if err := s.contentRepo.CreateEnrollment(enrollment); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (s *classContentService) GetMyEnrollments(userID string) ([]model.ClassEnrollment, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	return s.contentRepo.FindEnrollmentsByUser(userUUID)
+}
+
+func (s *classContentService) GetEnrollments(classID string) ([]model.ClassEnrollment, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	return s.contentRepo.FindEnrollments(class.ID)
+}
+
+func (s *classContentService) CreateModule(classID string, req *ModuleRequest) (*model.ClassModule, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	module := &model.ClassModule{
+		ID:          uuid.New(),
+		ClassID:     class.ID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+	}
+	if req.Position != nil {
+		module.Position = *req.Position
+	} else {
+		count, err := s.contentRepo.CountModules(class.ID)
+		if err != nil {
+			return nil, err
+		}
+		module.Position = int(count)
+	}
+	if err := s.contentRepo.CreateModule(module); err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+func (s *classContentService) findModule(classID, moduleID string) (*model.ClassModule, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	moduleUUID, err := uuid.Parse(moduleID)
+	if err != nil {
+		return nil, errors.New("module not found")
+	}
+	module, err := s.contentRepo.FindModule(class.ID, moduleUUID)
+	if err != nil {
+		return nil, errors.New("module not found")
+	}
+	return module, nil
+}
+
+func (s *classContentService) UpdateModule(classID, moduleID string, req *ModuleRequest) (*model.ClassModule, error) {
+	module, err := s.findModule(classID, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	module.Title = strings.TrimSpace(req.Title)
+	module.Description = req.Description
+	if req.Position != nil {
+		module.Position = *req.Position
+	}
+	if err := s.contentRepo.UpdateModule(module); err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+func (s *classContentService) DeleteModule(classID, moduleID string) error {
+	module, err := s.findModule(classID, moduleID)
+	if err != nil {
+		return err
+	}
+	return s.contentRepo.DeleteModule(module.ClassID, module.ID)
+}
+
+// applyLesson copies a request onto a lesson, checking the practice test exists.
+func (s *classContentService) applyLesson(lesson *model.Lesson, req *LessonRequest) error {
+	lesson.Title = strings.TrimSpace(req.Title)
+	lesson.VideoURL = req.VideoURL
+	lesson.VideoDuration = req.VideoDuration
+	lesson.Content = req.Content
+	if req.Position != nil {
+		lesson.Position = *req.Position
+	}
+
+	attachments := make([]model.LessonAttachment, len(req.Attachments))
+	for i, attachment := range req.Attachments {
+		attachments[i] = model.LessonAttachment{Name: attachment.Name, URL: attachment.URL}
+	}
+	encoded, err := json.Marshal(attachments)
+	if err != nil {
+		return err
+	}
+	lesson.Attachments = string(encoded)
+
+	lesson.PracticeTestID = nil
+	if req.PracticeTestID != "" {
+		testUUID, _ := uuid.Parse(req.PracticeTestID)
+		if _, err := s.testRepo.FindByID(testUUID); err != nil {
+			return errors.New("practice test not found")
+		}
+		lesson.PracticeTestID = &testUUID
+	}
+	return nil
+}
+
+func (s *classContentService) CreateLesson(classID, moduleID string, req *LessonRequest) (*LessonView, error) {
+	module, err := s.findModule(classID, moduleID)
+	if err != nil {
+		return nil, err
+	}
+	lesson := &model.Lesson{ID: uuid.New(), ClassID: module.ClassID, ModuleID: module.ID}
+	if req.Position == nil {
+		count, err := s.contentRepo.CountLessons(module.ID)
+		if err != nil {
+			return nil, err
+		}
+		lesson.Position = int(count)
+	}
+	if err := s.applyLesson(lesson, req); err != nil {
+		return nil, err
+	}
+	if err := s.contentRepo.CreateLesson(lesson); err != nil {
+		return nil, err
+	}
+	return s.lessonView(lesson), nil
+}
+
+func (s *classContentService) UpdateLesson(classID, lessonID string, req *LessonRequest) (*LessonView, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	lessonUUID, err := uuid.Parse(lessonID)
+	if err != nil {
+		return nil, errors.New("lesson not found")
+	}
+	lesson, err := s.contentRepo.FindLesson(class.ID, lessonUUID)
+	if err != nil {
+		return nil, errors.New("lesson not found")
+	}
+	if err := s.applyLesson(lesson, req); err != nil {
+		return nil, err
+	}
+	if err := s.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, err
+	}
+	return s.lessonView(lesson), nil
+}
+
+func (s *classContentService) DeleteLesson(classID, lessonID string) error {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return err
+	}
+	lessonUUID, err := uuid.Parse(lessonID)
+	if err != nil {
+		return errors.New("lesson not found")
+	}
+	return s.contentRepo.DeleteLesson(class.ID, lessonUUID)
+}
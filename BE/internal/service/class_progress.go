@@ -0,0 +1,435 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+// videoCompletionShare is how much of a lesson's video must have been watched for
+// the video to count as done.
+const videoCompletionShare = 0.9
+
+// watchAllowance is how many seconds further than the time since the last progress
+// update a student may get in a video, for buffering and clock drift. Skipping ahead
+// beyond that moves the position but does not count as watched.
+const watchAllowance = 15
+
+// What a lesson is still waiting for before it is complete.
+const (
+	LessonPendingVideo        = "video"
+	LessonPendingMarkDone     = "mark_done"
+	LessonPendingPracticeTest = "practice_test"
+)
+
+type ProgressRequest struct {
+	VideoPosition int `json:"video_position" validate:"min=0"`
+}
+
+// LessonProgressView is a lesson's progress with what it still needs. A lesson is
+// complete once its video has been watched (or, without a timed video, once it was
+// marked as done) and its practice test, if any, has been passed.
+type LessonProgressView struct {
+	LessonID      uuid.UUID  `json:"lesson_id"`
+	Started       bool       `json:"started"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	VideoPosition int        `json:"video_position"`
+	WatchedUntil  int        `json:"watched_until"`
+	Pending       []string   `json:"pending,omitempty"`
+}
+
+// ClassProgress is how far a user has got with a class. The final test, when the
+// class has one, counts as one more step next to the lessons.
+type ClassProgress struct {
+	ClassID           uuid.UUID            `json:"class_id"`
+	TotalLessons      int                  `json:"total_lessons"`
+	CompletedLessons  int                  `json:"completed_lessons"`
+	FinalTestID       *uuid.UUID           `json:"final_test_id,omitempty"`
+	FinalTestPassed   bool                 `json:"final_test_passed"`
+	Percent           int                  `json:"percent"`
+	Completed         bool                 `json:"completed"`
+	CertificateSerial string               `json:"certificate_serial,omitempty"`
+	Lessons           []LessonProgressView `json:"lessons"`
+}
+
+// ClassDetail is a class with the current user's progress, when they are enrolled.
+type ClassDetail struct {
+	*model.PremiumClass
+	Progress *ClassProgress `json:"progress,omitempty"`
+}
+
+// passedTest reports whether the user has a completed result for the test that
+// passed, or any completed result when the test has no passing score.
+func (s *classContentService) passedTest(userID, testID uuid.UUID, cache map[uuid.UUID]bool) bool {
+	if passed, ok := cache[testID]; ok {
+		return passed
+	}
+	results, err := s.resultRepo.FindByUserAndTest(userID, testID)
+	passed := false
+	if err == nil {
+		for _, result := range results {
+			if result.Status == model.ResultStatusCompleted && (result.Passed == nil || *result.Passed) {
+				passed = true
+				break
+			}
+		}
+	}
+	cache[testID] = passed
+	return passed
+}
+
+// pendingFor lists what a lesson still needs from the user.
+func (s *classContentService) pendingFor(lesson *model.Lesson, progress *model.LessonProgress, userID uuid.UUID, passed map[uuid.UUID]bool) []string {
+	var pending []string
+	if lesson.VideoURL != "" && lesson.VideoDuration > 0 {
+		if progress == nil || float64(progress.WatchedUntil) < videoCompletionShare*float64(lesson.VideoDuration) {
+			pending = append(pending, LessonPendingVideo)
+		}
+	} else if progress == nil || !progress.MarkedDone {
+		pending = append(pending, LessonPendingMarkDone)
+	}
+	if lesson.PracticeTestID != nil && !s.passedTest(userID, *lesson.PracticeTestID, passed) {
+		pending = append(pending, LessonPendingPracticeTest)
+	}
+	return pending
+}
+
+// refreshClass works out the user's progress in a class, completing the lessons
+// whose rules are now met and issuing the course certificate once the whole class
+// is done, as long as the user may still study the class.
+func (s *classContentService) refreshClass(userID uuid.UUID, class *model.PremiumClass, now time.Time) (*ClassProgress, error) {
+	modules, err := s.contentRepo.FindModules(class.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.contentRepo.FindClassProgress(userID, class.ID)
+	if err != nil {
+		return nil, err
+	}
+	byLesson := make(map[uuid.UUID]*model.LessonProgress, len(rows))
+	for i := range rows {
+		byLesson[rows[i].LessonID] = &rows[i]
+	}
+
+	passed := map[uuid.UUID]bool{}
+	report := &ClassProgress{ClassID: class.ID, FinalTestID: class.FinalTestID, Lessons: []LessonProgressView{}}
+	for _, module := range modules {
+		for i := range module.Lessons {
+			lesson := &module.Lessons[i]
+			progress := byLesson[lesson.ID]
+			view := LessonProgressView{LessonID: lesson.ID}
+			if progress != nil {
+				if progress.CompletedAt == nil {
+					if view.Pending = s.pendingFor(lesson, progress, userID, passed); len(view.Pending) == 0 {
+						completedAt := now
+						progress.CompletedAt = &completedAt
+						if err := s.contentRepo.SaveProgress(progress); err != nil {
+							return nil, err
+						}
+					}
+				}
+				startedAt := progress.StartedAt
+				view.Started = true
+				view.StartedAt = &startedAt
+				view.CompletedAt = progress.CompletedAt
+				view.VideoPosition = progress.VideoPosition
+				view.WatchedUntil = progress.WatchedUntil
+			} else {
+				view.Pending = s.pendingFor(lesson, nil, userID, passed)
+			}
+			report.TotalLessons++
+			if view.CompletedAt != nil {
+				report.CompletedLessons++
+			}
+			report.Lessons = append(report.Lessons, view)
+		}
+	}
+
+	steps, done := report.TotalLessons, report.CompletedLessons
+	if class.FinalTestID != nil {
+		report.FinalTestPassed = s.passedTest(userID, *class.FinalTestID, passed)
+		steps++
+		if report.FinalTestPassed {
+			done++
+		}
+	}
+	if steps > 0 {
+		report.Percent = done * 100 / steps
+		report.Completed = done == steps
+	}
+
+	if report.Completed && s.checkAccess(userID.String(), "", class.ID) == nil {
+		issued, err := s.certificateService.IssueCourseCertificate(userID, class)
+		if err != nil {
+			log.Printf("classes: could not issue course certificate for user %s in class %s: %v", userID, class.ID, err)
+		} else {
+			report.CertificateSerial = issued.Serial
+		}
+	}
+	return report, nil
+}
+
+// accessibleLesson loads a lesson of the class for a user who may study it.
+func (s *classContentService) accessibleLesson(userID, classID, lessonID string) (uuid.UUID, *model.PremiumClass, *model.Lesson, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, nil, nil, errors.New("invalid user ID format")
+	}
+	class, err := s.findClass(classID)
+	if err != nil {
+		return uuid.Nil, nil, nil, err
+	}
+	lessonUUID, err := uuid.Parse(lessonID)
+	if err != nil {
+		return uuid.Nil, nil, nil, errors.New("lesson not found")
+	}
+	lesson, err := s.contentRepo.FindLesson(class.ID, lessonUUID)
+	if err != nil {
+		return uuid.Nil, nil, nil, errors.New("lesson not found")
+	}
+	if err := s.checkAccess(userID, "", class.ID); err != nil {
+		return uuid.Nil, nil, nil, err
+	}
+	return userUUID, class, lesson, nil
+}
+
+// startLesson returns the user's progress on a lesson, starting it if needed.
+func (s *classContentService) startLesson(userID uuid.UUID, lesson *model.Lesson) (*model.LessonProgress, error) {
+	if progress, err := s.contentRepo.FindProgress(userID, lesson.ID); err == nil {
+		return progress, nil
+	}
+	progress := &model.LessonProgress{
+		ID:        uuid.New(),
+		UserID:    userID,
+		LessonID:  lesson.ID,
+		ClassID:   lesson.ClassID,
+		StartedAt: time.Now(),
+	}
+	if err := s.contentRepo.SaveProgress(progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// lessonProgressView refreshes the class and picks out one lesson's progress.
+func (s *classContentService) lessonProgressView(userID uuid.UUID, class *model.PremiumClass, lessonID uuid.UUID) (*LessonProgressView, error) {
+	report, err := s.refreshClass(userID, class, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range report.Lessons {
+		if report.Lessons[i].LessonID == lessonID {
+			return &report.Lessons[i], nil
+		}
+	}
+	return nil, errors.New("lesson not found")
+}
+
+// UpdateProgress records where the user is in a lesson's video. Scrubbing back does
+// not undo what has already been watched, and what counts as watched grows no faster
+// than the time since the last update.
+func (s *classContentService) UpdateProgress(userID, classID, lessonID string, req *ProgressRequest) (*LessonProgressView, error) {
+	userUUID, class, lesson, err := s.accessibleLesson(userID, classID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.startLesson(userUUID, lesson)
+	if err != nil {
+		return nil, err
+	}
+	position := req.VideoPosition
+	if lesson.VideoDuration > 0 && position > lesson.VideoDuration {
+		position = lesson.VideoDuration
+	}
+	progress.VideoPosition = position
+	watched := position
+	limit := progress.WatchedUntil + int(time.Since(progress.UpdatedAt).Seconds()) + watchAllowance
+	if watched > limit {
+		watched = limit
+	}
+	if watched > progress.WatchedUntil {
+		progress.WatchedUntil = watched
+	}
+	if err := s.contentRepo.SaveProgress(progress); err != nil {
+		return nil, err
+	}
+	return s.lessonProgressView(userUUID, class, lesson.ID)
+}
+
+// CompleteLesson marks a lesson as done by the user. It is complete once its other
+// rules are met too; the returned view lists what is still pending.
+func (s *classContentService) CompleteLesson(userID, classID, lessonID string) (*LessonProgressView, error) {
+	userUUID, class, lesson, err := s.accessibleLesson(userID, classID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.startLesson(userUUID, lesson)
+	if err != nil {
+		return nil, err
+	}
+	if !progress.MarkedDone {
+		progress.MarkedDone = true
+		if err := s.contentRepo.SaveProgress(progress); err != nil {
+			return nil, err
+		}
+	}
+	return s.lessonProgressView(userUUID, class, lesson.ID)
+}
+
+func (s *classContentService) GetClassProgress(userID, classID string) (*ClassProgress, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.contentRepo.FindEnrollment(class.ID, userUUID); err != nil {
+		return nil, errors.New("not enrolled in this class")
+	}
+	return s.refreshClass(userUUID, class, time.Now())
+}
+
+// GetClassDetail is a class as the current user sees it: with their progress when
+// they are logged in and enrolled. userID is empty for anonymous visitors.
+func (s *classContentService) GetClassDetail(userID, classID string) (*ClassDetail, error) {
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &ClassDetail{PremiumClass: class}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return detail, nil
+	}
+	if _, err := s.contentRepo.FindEnrollment(class.ID, userUUID); err != nil {
+		return detail, nil
+	}
+	if detail.Progress, err = s.refreshClass(userUUID, class, time.Now()); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// RenderCourseCertificate draws the user's certificate for a class they completed.
+func (s *classContentService) RenderCourseCertificate(userID, classID string) ([]byte, *model.CourseCertificate, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil, errors.New("invalid user ID format")
+	}
+	class, err := s.findClass(classID)
+	if err != nil {
+		return nil, nil, err
+	}
+	issued, err := s.certificateService.FindCourseCertificate(class.ID, userUUID)
+	if err != nil {
+		if err := s.checkAccess(userID, "", class.ID); err != nil {
+			return nil, nil, err
+		}
+		report, err := s.refreshClass(userUUID, class, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		if !report.Completed {
+			return nil, nil, errors.New("class not completed")
+		}
+		if issued, err = s.certificateService.FindCourseCertificate(class.ID, userUUID); err != nil {
+			return nil, nil, err
+		}
+	}
+	data, err := s.certificateService.RenderCourseCertificate(issued)
+	if err != nil {
+		return nil, issued, err
+	}
+	return data, issued, nil
+}
+
+// ResultFinalized re-checks the classes the student is enrolled in when they pass a
+// test, since it may be a practice test or a class's final test.
+func (s *classContentService) ResultFinalized(result *model.TestResult) {
+	if result.Status != model.ResultStatusCompleted || (result.Passed != nil && !*result.Passed) {
+		return
+	}
+	enrollments, err := s.contentRepo.FindEnrollmentsByUser(result.UserID)
+	if err != nil {
+		log.Printf("classes: listing enrollments of user %s failed: %v", result.UserID, err)
+		return
+	}
+	now := time.Now()
+	for i := range enrollments {
+		if _, err := s.refreshClass(result.UserID, &enrollments[i].Class, now); err != nil {
+			log.Printf("classes: refreshing progress in class %s failed: %v", enrollments[i].ClassID, err)
+		}
+	}
+}
+
+// ResultInvalidated re-checks the classes the student is enrolled in when one of
+// their results is invalidated. Lessons completed on the strength of that test are
+// reopened if it is no longer passed, and the course certificate is revoked where
+// the class is no longer completed.
+func (s *classContentService) ResultInvalidated(result *model.TestResult) {
+	enrollments, err := s.contentRepo.FindEnrollmentsByUser(result.UserID)
+	if err != nil {
+		log.Printf("classes: listing enrollments of user %s failed: %v", result.UserID, err)
+		return
+	}
+	now := time.Now()
+	for i := range enrollments {
+		class := &enrollments[i].Class
+		if err := s.reopenLessons(result.UserID, class.ID, result.TestID); err != nil {
+			log.Printf("classes: reopening lessons in class %s failed: %v", class.ID, err)
+			continue
+		}
+		report, err := s.refreshClass(result.UserID, class, now)
+		if err != nil {
+			log.Printf("classes: refreshing progress in class %s failed: %v", class.ID, err)
+			continue
+		}
+		if !report.Completed {
+			if err := s.certificateService.RevokeCourseCertificate(class.ID, result.UserID); err != nil {
+				log.Printf("classes: revoking course certificate in class %s failed: %v", class.ID, err)
+			}
+		}
+	}
+}
+
+// reopenLessons clears the completion of the user's lessons in a class whose
+// practice test is the given test, when that test is no longer passed.
+func (s *classContentService) reopenLessons(userID, classID, testID uuid.UUID) error {
+	if s.passedTest(userID, testID, map[uuid.UUID]bool{}) {
+		return nil
+	}
+	modules, err := s.contentRepo.FindModules(classID)
+	if err != nil {
+		return err
+	}
+	practice := map[uuid.UUID]bool{}
+	for _, module := range modules {
+		for _, lesson := range module.Lessons {
+			if lesson.PracticeTestID != nil && *lesson.PracticeTestID == testID {
+				practice[lesson.ID] = true
+			}
+		}
+	}
+	if len(practice) == 0 {
+		return nil
+	}
+	rows, err := s.contentRepo.FindClassProgress(userID, classID)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if practice[rows[i].LessonID] && rows[i].CompletedAt != nil {
+			rows[i].CompletedAt = nil
+			if err := s.contentRepo.SaveProgress(&rows[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// eventSignalChannel carries start and schedule signals for all events between
+// instances. Each instance holds one subscription and fans out to its own streams.
+const eventSignalChannel = "tryout:events:signals"
+
+const (
+	EventSignalStart    = "start"
+	EventSignalSchedule = "schedule"
+)
+
+// EventSignal is broadcast to every countdown stream of an event.
+type EventSignal struct {
+	Type     string    `json:"type"`
+	EventID  uuid.UUID `json:"event_id"`
+	StartsAt time.Time `json:"starts_at"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+type EventRequest struct {
+	TestID               string     `json:"test_id" validate:"required,uuid"`
+	Title                string     `json:"title" validate:"required,min=5"`
+	Description          string     `json:"description"`
+	StartsAt             time.Time  `json:"starts_at" validate:"required"`
+	LateJoinMinutes      int        `json:"late_join_minutes" validate:"gte=0"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	Capacity             int        `json:"capacity" validate:"gte=0"`
+}
+
+type EventView struct {
+	Event             *model.TryoutEvent `json:"event"`
+	Phase             string             `json:"phase"`
+	Registrations     int64              `json:"registrations"`
+	SecondsUntilStart int                `json:"seconds_until_start"`
+	ServerTime        time.Time          `json:"server_time"`
+}
+
+type EventService interface {
+	CreateEvent(adminID string, req *EventRequest) (*model.TryoutEvent, error)
+	UpdateEvent(id string, req *EventRequest) (*model.TryoutEvent, error)
+	DeleteEvent(id string) error
+	GetUpcomingEvents() ([]EventView, error)
+	GetAllEvents() ([]EventView, error)
+	GetEvent(id string) (*EventView, error)
+	Register(userID, eventID string) (*model.EventRegistration, error)
+	JoinEvent(userID, eventID string) (*AttemptView, error)
+	GetRanking(eventID string, page, limit int) (*RankingPage, error)
+	Subscribe(eventID uuid.UUID) (<-chan EventSignal, func())
+	SignalStart(event *model.TryoutEvent) error
+	ResultListener
+}
+
+type eventService struct {
+	eventRepo      repository.EventRepository
+	testRepo       repository.TestRepository
+	attemptRepo    repository.AttemptRepository
+	resultRepo     repository.TestResultRepository
+	attemptService AttemptService
+	redisClient    *redis.Client
+	hub            *eventHub
+}
+
+func NewEventService(eventRepo repository.EventRepository, testRepo repository.TestRepository, attemptRepo repository.AttemptRepository, resultRepo repository.TestResultRepository, attemptService AttemptService, redisClient *redis.Client) EventService {
+	return &eventService{
+		eventRepo:      eventRepo,
+		testRepo:       testRepo,
+		attemptRepo:    attemptRepo,
+		resultRepo:     resultRepo,
+		attemptService: attemptService,
+		redisClient:    redisClient,
+		hub:            &eventHub{redisClient: redisClient, subscribers: make(map[uuid.UUID]map[chan EventSignal]struct{})},
+	}
+}
+
+func eventStartedKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("tryout:event:%s:started", eventID)
+}
+
+func eventRankingKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("tryout:event:%s:ranking", eventID)
+}
+
+// testDuration is the full time allowed for a test in minutes.
+func testDuration(test *model.Test) int {
+	if len(test.Sections) == 0 {
+		return test.Duration
+	}
+	total := 0
+	for _, section := range test.Sections {
+		total += section.Duration
+	}
+	return total
+}
+
+func (s *eventService) CreateEvent(adminID string, req *EventRequest) (*model.TryoutEvent, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	event := &model.TryoutEvent{ID: uuid.New(), CreatedBy: adminUUID}
+	if err := s.applyRequest(event, req); err != nil {
+		return nil, err
+	}
+	if err := s.eventRepo.Create(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// UpdateEvent edits an event that has not started. Moving the start time is
+// broadcast so open countdowns follow it.
+func (s *eventService) UpdateEvent(id string, req *EventRequest) (*model.TryoutEvent, error) {
+	event, err := s.findEvent(id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Phase(time.Now()) != model.EventPhaseScheduled {
+		return nil, errors.New("event has already started")
+	}
+	previousStart := event.StartsAt
+	if err := s.applyRequest(event, req); err != nil {
+		return nil, err
+	}
+	if err := s.eventRepo.Update(event); err != nil {
+		return nil, err
+	}
+	if !event.StartsAt.Equal(previousStart) {
+		s.publish(EventSignal{Type: EventSignalSchedule, EventID: event.ID, StartsAt: event.StartsAt, SentAt: time.Now()})
+	}
+	return event, nil
+}
+
+func (s *eventService) applyRequest(event *model.TryoutEvent, req *EventRequest) error {
+	testUUID, err := uuid.Parse(req.TestID)
+	if err != nil {
+		return errors.New("invalid test id format")
+	}
+	test, err := s.testRepo.FindByID(testUUID)
+	if err != nil {
+		return errors.New("test not found")
+	}
+	// Event papers may stay out of the public listing (review), but drafts and
+	// archived tests cannot be scheduled.
+	if test.Status != model.TestStatusPublished && test.Status != model.TestStatusReview {
+		return errors.New("only published or in-review tests can be scheduled")
+	}
+	duration := testDuration(test)
+	if duration <= 0 {
+		return errors.New("test has no duration")
+	}
+	if !req.StartsAt.After(time.Now()) {
+		return errors.New("starts_at must be in the future")
+	}
+	if req.LateJoinMinutes >= duration {
+		return errors.New("late_join_minutes must be shorter than the test")
+	}
+	if req.RegistrationClosesAt != nil && req.RegistrationClosesAt.After(req.StartsAt.Add(time.Duration(req.LateJoinMinutes)*time.Minute)) {
+		return errors.New("registration must close before the late-join cutoff")
+	}
+
+	event.TestID = testUUID
+	event.Title = req.Title
+	event.Description = req.Description
+	event.StartsAt = req.StartsAt
+	event.LateJoinUntil = req.StartsAt.Add(time.Duration(req.LateJoinMinutes) * time.Minute)
+	event.EndsAt = req.StartsAt.Add(time.Duration(duration) * time.Minute)
+	event.RegistrationClosesAt = req.RegistrationClosesAt
+	event.Capacity = req.Capacity
+	return nil
+}
+
+func (s *eventService) DeleteEvent(id string) error {
+	event, err := s.findEvent(id)
+	if err != nil {
+		return err
+	}
+	if event.Phase(time.Now()) == model.EventPhaseRunning {
+		return errors.New("event is running")
+	}
+	return s.eventRepo.Delete(event.ID)
+}
+
+func (s *eventService) GetUpcomingEvents() ([]EventView, error) {
+	events, err := s.eventRepo.FindUpcoming(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.views(events)
+}
+
+func (s *eventService) GetAllEvents() ([]EventView, error) {
+	events, err := s.eventRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	return s.views(events)
+}
+
+func (s *eventService) GetEvent(id string) (*EventView, error) {
+	event, err := s.findEvent(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(event)
+}
+
+func (s *eventService) views(events []model.TryoutEvent) ([]EventView, error) {
+	views := make([]EventView, 0, len(events))
+	for i := range events {
+		view, err := s.view(&events[i])
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+func (s *eventService) view(event *model.TryoutEvent) (*EventView, error) {
+	count, err := s.eventRepo.CountRegistrations(event.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	view := &EventView{Event: event, Phase: event.Phase(now), Registrations: count, ServerTime: now}
+	if until := event.StartsAt.Sub(now); until > 0 {
+		view.SecondsUntilStart = int(until.Seconds())
+	}
+	return view, nil
+}
+
+func (s *eventService) Register(userID, eventID string) (*model.EventRegistration, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	event, err := s.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := s.eventRepo.FindRegistration(event.ID, userUUID); err == nil {
+		return existing, nil
+	}
+	if !event.RegistrationOpen(time.Now()) {
+		return nil, errors.New("registration is closed")
+	}
+
+	registration := &model.EventRegistration{ID: uuid.New(), EventID: event.ID, UserID: userUUID}
+	registered, err := s.eventRepo.RegisterWithinCapacity(registration, event.Capacity)
+	if err != nil {
+		// A second register sent at the same time hits the unique index.
+		if existing, findErr := s.eventRepo.FindRegistration(event.ID, userUUID); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	if !registered {
+		return nil, errors.New("event is full")
+	}
+	return registration, nil
+}
+
+// JoinEvent unlocks the paper for a registered student once the event has started,
+// or returns their attempt if they already joined.
+func (s *eventService) JoinEvent(userID, eventID string) (*AttemptView, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	event, err := s.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	registration, err := s.eventRepo.FindRegistration(event.ID, userUUID)
+	if err != nil {
+		return nil, errors.New("not registered for this event")
+	}
+	if registration.AttemptID != nil {
+		return s.attemptService.GetAttempt(userID, registration.AttemptID.String())
+	}
+
+	now := time.Now()
+	if !s.hasStarted(event, now) {
+		return nil, errors.New("event has not started")
+	}
+	if now.After(event.LateJoinUntil) {
+		return nil, errors.New("late-join cutoff has passed")
+	}
+
+	view, err := s.attemptService.StartEventAttempt(userUUID, event)
+	if err != nil {
+		// A join sent at the same time started the attempt first; the unique index
+		// on attempts refused a second one.
+		attempt, findErr := s.attemptRepo.FindByUserAndEvent(userUUID, event.ID)
+		if findErr != nil {
+			return nil, err
+		}
+		if view, err = s.attemptService.GetAttempt(userID, attempt.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+	registration.AttemptID = &view.Attempt.ID
+	if err := s.eventRepo.UpdateRegistration(registration); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// hasStarted trusts the start signal as well as the local clock, so an instance whose
+// clock runs slightly behind the one that sent the signal does not keep students waiting.
+func (s *eventService) hasStarted(event *model.TryoutEvent, now time.Time) bool {
+	if !now.Before(event.StartsAt) {
+		return true
+	}
+	started, err := s.redisClient.Exists(context.Background(), eventStartedKey(event.ID)).Result()
+	return err == nil && started > 0
+}
+
+// SignalStart broadcasts the start of an event exactly once across instances.
+// Every countdown stream calls it when its clock reaches StartsAt; the first to set
+// the started key publishes the signal.
+func (s *eventService) SignalStart(event *model.TryoutEvent) error {
+	ttl := time.Until(event.EndsAt) + time.Hour
+	won, err := s.redisClient.SetNX(context.Background(), eventStartedKey(event.ID), time.Now().Unix(), ttl).Result()
+	if err != nil || !won {
+		return err
+	}
+	return s.publish(EventSignal{Type: EventSignalStart, EventID: event.ID, StartsAt: event.StartsAt, SentAt: time.Now()})
+}
+
+func (s *eventService) publish(signal EventSignal) error {
+	payload, err := json.Marshal(signal)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Publish(context.Background(), eventSignalChannel, payload).Err()
+}
+
+func (s *eventService) Subscribe(eventID uuid.UUID) (<-chan EventSignal, func()) {
+	return s.hub.subscribe(eventID)
+}
+
+// GetRanking pages through the event ranking, best first. The sorted set is rebuilt
+// from MySQL when Redis has lost it.
+func (s *eventService) GetRanking(eventID string, page, limit int) (*RankingPage, error) {
+	event, err := s.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	key := eventRankingKey(event.ID)
+
+	total, err := s.redisClient.ZCard(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		results, err := s.resultRepo.FindFinalByEventID(event.ID)
+		if err != nil {
+			return nil, err
+		}
+		members := make([]*redis.Z, 0, len(results))
+		for i := range results {
+			members = append(members, &redis.Z{Score: rankingScore(&results[i]), Member: results[i].ID.String()})
+		}
+		if len(members) > 0 {
+			if err := s.redisClient.ZAdd(ctx, key, members...).Err(); err != nil {
+				return nil, err
+			}
+		}
+		total = int64(len(members))
+	}
+
+	start, stop := pageBounds(page, limit)
+	members, err := s.redisClient.ZRevRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		if id, err := uuid.Parse(member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	entries, err := rankingEntries(s.resultRepo, ids, start+1, false)
+	if err != nil {
+		return nil, err
+	}
+	return &RankingPage{Total: total, Page: page, Limit: limit, Entries: entries}, nil
+}
+
+// ResultFinalized adds results of event attempts to the event ranking.
+func (s *eventService) ResultFinalized(result *model.TestResult) {
+	if result.AttemptID == nil {
+		return
+	}
+	attempt, err := s.attemptRepo.FindByID(*result.AttemptID)
+	if err != nil || attempt.EventID == nil {
+		return
+	}
+	member := &redis.Z{Score: rankingScore(result), Member: result.ID.String()}
+	if err := s.redisClient.ZAdd(context.Background(), eventRankingKey(*attempt.EventID), member).Err(); err != nil {
+		log.Printf("event ranking: could not add result %s: %v", result.ID, err)
+	}
+}
+
+// ResultInvalidated takes an invalidated result off its event's ranking.
+func (s *eventService) ResultInvalidated(result *model.TestResult) {
+	if result.AttemptID == nil {
+		return
+	}
+	attempt, err := s.attemptRepo.FindByID(*result.AttemptID)
+	if err != nil || attempt.EventID == nil {
+		return
+	}
+	if err := s.redisClient.ZRem(context.Background(), eventRankingKey(*attempt.EventID), result.ID.String()).Err(); err != nil {
+		log.Printf("event ranking: could not remove result %s: %v", result.ID, err)
+	}
+}
+
+func (s *eventService) findEvent(id string) (*model.TryoutEvent, error) {
+	eventUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	event, err := s.eventRepo.FindByID(eventUUID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+	return event, nil
+}
+
+// eventHub relays signals from the shared Redis channel to the countdown streams
+// open on this instance. The Redis subscription starts with the first stream.
+type eventHub struct {
+	redisClient *redis.Client
+	once        sync.Once
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan EventSignal]struct{}
+}
+
+func (h *eventHub) subscribe(eventID uuid.UUID) (<-chan EventSignal, func()) {
+	h.once.Do(func() { go h.run() })
+
+	ch := make(chan EventSignal, 4)
+	h.mu.Lock()
+	if h.subscribers[eventID] == nil {
+		h.subscribers[eventID] = make(map[chan EventSignal]struct{})
+	}
+	h.subscribers[eventID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[eventID], ch)
+		if len(h.subscribers[eventID]) == 0 {
+			delete(h.subscribers, eventID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *eventHub) run() {
+	pubsub := h.redisClient.Subscribe(context.Background(), eventSignalChannel)
+	for msg := range pubsub.Channel() {
+		var signal EventSignal
+		if err := json.Unmarshal([]byte(msg.Payload), &signal); err != nil {
+			continue
+		}
+		h.mu.Lock()
+		for ch := range h.subscribers[signal.EventID] {
+			// A stream that is not keeping up still follows its own clock, so
+			// dropping a signal for it is safe.
+			select {
+			case ch <- signal:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+type GradeEssayRequest struct {
+	Score          *float64  `json:"score" validate:"omitempty,gte=0"`
+	CriteriaScores []float64 `json:"criteria_scores" validate:"omitempty,dive,gte=0"`
+	Feedback       string    `json:"feedback"`
+}
+
+type GradingService interface {
+	GetPendingAnswers(testID string) ([]model.EssayAnswer, error)
+	GradeAnswer(graderID, answerID string, req *GradeEssayRequest) (*model.EssayAnswer, error)
+}
+
+type gradingService struct {
+	essayRepo         repository.EssayAnswerRepository
+	resultRepo        repository.TestResultRepository
+	testResultService TestResultService
+}
+
+func NewGradingService(essayRepo repository.EssayAnswerRepository, resultRepo repository.TestResultRepository, testResultService TestResultService) GradingService {
+	return &gradingService{essayRepo, resultRepo, testResultService}
+}
+
+func (s *gradingService) GetPendingAnswers(testID string) ([]model.EssayAnswer, error) {
+	if testID == "" {
+		return s.essayRepo.FindPending(nil)
+	}
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.essayRepo.FindPending(&testUUID)
+}
+
+func (s *gradingService) GradeAnswer(graderID, answerID string, req *GradeEssayRequest) (*model.EssayAnswer, error) {
+	graderUUID, err := uuid.Parse(graderID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	answerUUID, err := uuid.Parse(answerID)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+
+	answer, err := s.essayRepo.FindByID(answerUUID)
+	if err != nil {
+		return nil, errors.New("essay answer not found")
+	}
+	// Once every essay is graded the result is scored and announced; a later
+	// grade would change the answer without changing the score.
+	result, err := s.resultRepo.FindByID(answer.TestResultID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	if result.Status != model.ResultStatusPendingReview {
+		return nil, errors.New("result has already been finalized")
+	}
+
+	score, err := scoreAgainstRubric(answer, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	answer.Score = &score
+	answer.Feedback = req.Feedback
+	answer.Status = model.EssayStatusGraded
+	answer.GradedBy = &graderUUID
+	answer.GradedAt = &now
+	if len(req.CriteriaScores) > 0 {
+		criteriaJSON, _ := json.Marshal(req.CriteriaScores)
+		answer.CriteriaScores = string(criteriaJSON)
+	}
+
+	if err := s.essayRepo.Update(answer); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.testResultService.FinalizeReviewedResult(answer.TestResultID); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// scoreAgainstRubric validates the grader's input. With a rubric every criterion must
+// be scored and the total is their sum; without one a single score is expected.
+func scoreAgainstRubric(answer *model.EssayAnswer, req *GradeEssayRequest) (float64, error) {
+	criteria, err := parseRubric(answer.Rubric)
+	if err != nil {
+		return 0, errors.New("stored rubric is invalid")
+	}
+
+	if len(criteria) == 0 {
+		if req.Score == nil {
+			return 0, errors.New("score is required")
+		}
+		if *req.Score > answer.MaxScore {
+			return 0, errors.New("score exceeds the maximum score")
+		}
+		return *req.Score, nil
+	}
+
+	if len(req.CriteriaScores) != len(criteria) {
+		return 0, errors.New("a score is required for every rubric criterion")
+	}
+	total := 0.0
+	for i, criterion := range criteria {
+		if req.CriteriaScores[i] > criterion.MaxScore {
+			return 0, errors.New("score exceeds the maximum for criterion: " + criterion.Criterion)
+		}
+		total += req.CriteriaScores[i]
+	}
+	return total, nil
+}
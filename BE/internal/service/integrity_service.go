@@ -0,0 +1,344 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxEventsPerAttempt caps what one attempt can store, so a broken or hostile
+	// client cannot fill the table.
+	maxEventsPerAttempt = 5000
+	// integrityFlagScore is the score at which an attempt goes to the review queue.
+	integrityFlagScore = 40
+	// heartbeatGap is the silence between heartbeats that counts as the client having
+	// been away, e.g. the page closed or the device switched off.
+	heartbeatGap = 2 * time.Minute
+	// minAnswerSeconds is the mean time per answered question below which a high
+	// score is treated as impossible.
+	minAnswerSeconds = 5
+)
+
+type AttemptEventInput struct {
+	Type       string    `json:"type" validate:"required,oneof=blur focus fullscreen_exit copy paste heartbeat"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Detail     string    `json:"detail" validate:"max=255"`
+}
+
+type RecordEventsRequest struct {
+	Events []AttemptEventInput `json:"events" validate:"required,min=1,max=100,dive"`
+}
+
+type RecordEventsResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// IntegrityFlag is one signal that raised an attempt's integrity score.
+type IntegrityFlag struct {
+	Signal string `json:"signal"`
+	Detail string `json:"detail"`
+	Points int    `json:"points"`
+}
+
+type IntegrityReview struct {
+	Report *model.IntegrityReport `json:"report"`
+	Flags  []IntegrityFlag        `json:"flags"`
+	Result *model.TestResult      `json:"result"`
+	Events []model.AttemptEvent   `json:"events"`
+}
+
+type IntegrityReviewPage struct {
+	Total   int64                   `json:"total"`
+	Page    int                     `json:"page"`
+	Limit   int                     `json:"limit"`
+	Reports []model.IntegrityReport `json:"reports"`
+}
+
+type IntegrityDecisionRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=clear invalidate"`
+	Note     string `json:"note" validate:"max=255"`
+}
+
+type IntegrityService interface {
+	RecordEvents(userID, attemptID, ip, userAgent string, req *RecordEventsRequest) (*RecordEventsResponse, error)
+	GetReviewQueue(status string, page, limit int) (*IntegrityReviewPage, error)
+	GetReview(attemptID string) (*IntegrityReview, error)
+	Decide(reviewerID, attemptID string, req *IntegrityDecisionRequest) (*model.IntegrityReport, error)
+	ResultListener
+}
+
+type integrityService struct {
+	integrityRepo     repository.IntegrityRepository
+	attemptRepo       repository.AttemptRepository
+	resultRepo        repository.TestResultRepository
+	testResultService TestResultService
+}
+
+func NewIntegrityService(integrityRepo repository.IntegrityRepository, attemptRepo repository.AttemptRepository, resultRepo repository.TestResultRepository, testResultService TestResultService) IntegrityService {
+	return &integrityService{integrityRepo, attemptRepo, resultRepo, testResultService}
+}
+
+// RecordEvents stores telemetry a client reported for its open attempt. The IP and
+// user agent come from the request, so a client cannot hide a change of device.
+func (s *integrityService) RecordEvents(userID, attemptID, ip, userAgent string, req *RecordEventsRequest) (*RecordEventsResponse, error) {
+	attemptUUID, err := uuid.Parse(attemptID)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	attempt, err := s.attemptRepo.FindByID(attemptUUID)
+	if err != nil || attempt.UserID.String() != userID {
+		return nil, errors.New("attempt not found")
+	}
+	if attempt.Status != model.AttemptStatusInProgress {
+		return nil, errors.New("attempt is not in progress")
+	}
+
+	stored, err := s.integrityRepo.CountEvents(attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+	room := maxEventsPerAttempt - int(stored)
+	if room <= 0 {
+		return &RecordEventsResponse{}, nil
+	}
+	inputs := req.Events
+	if len(inputs) > room {
+		inputs = inputs[:room]
+	}
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	now := time.Now()
+	events := make([]model.AttemptEvent, len(inputs))
+	for i, input := range inputs {
+		// Client clocks are not trusted past the attempt window.
+		occurredAt := input.OccurredAt
+		if occurredAt.IsZero() || occurredAt.After(now) || occurredAt.Before(attempt.StartedAt) {
+			occurredAt = now
+		}
+		events[i] = model.AttemptEvent{
+			ID:         uuid.New(),
+			AttemptID:  attempt.ID,
+			Type:       input.Type,
+			Detail:     input.Detail,
+			OccurredAt: occurredAt,
+			ReceivedAt: now,
+			IP:         ip,
+			UserAgent:  userAgent,
+		}
+	}
+	if err := s.integrityRepo.CreateEvents(events); err != nil {
+		return nil, err
+	}
+	return &RecordEventsResponse{Accepted: len(events)}, nil
+}
+
+// ResultFinalized scores the attempt behind a finished result and queues it for
+// review when the score reaches the flag threshold. Reports an admin has already
+// decided on are left alone. Results submitted in one go have no attempt and no
+// telemetry to score; only plain tests can still be taken that way.
+func (s *integrityService) ResultFinalized(result *model.TestResult) {
+	if result.AttemptID == nil {
+		return
+	}
+	if report, err := s.integrityRepo.FindReport(*result.AttemptID); err == nil &&
+		(report.Status == model.IntegrityCleared || report.Status == model.IntegrityInvalidated) {
+		return
+	}
+	attempt, err := s.attemptRepo.FindByID(*result.AttemptID)
+	if err != nil {
+		log.Printf("integrity: attempt %s not found: %v", *result.AttemptID, err)
+		return
+	}
+	events, err := s.integrityRepo.FindEvents(attempt.ID)
+	if err != nil {
+		log.Printf("integrity: could not load events of attempt %s: %v", attempt.ID, err)
+		return
+	}
+
+	flags := scoreIntegrity(attempt, result, events)
+	score := 0
+	for _, flag := range flags {
+		score += flag.Points
+	}
+	status := model.IntegrityClean
+	if score >= integrityFlagScore {
+		status = model.IntegrityFlagged
+	}
+	data, _ := json.Marshal(flags)
+	report := &model.IntegrityReport{
+		AttemptID:    attempt.ID,
+		TestResultID: result.ID,
+		TestID:       result.TestID,
+		UserID:       result.UserID,
+		Score:        score,
+		Flags:        string(data),
+		Status:       status,
+	}
+	if err := s.integrityRepo.SaveReport(report); err != nil {
+		log.Printf("integrity: could not save report for attempt %s: %v", attempt.ID, err)
+	}
+}
+
+// scoreIntegrity turns an attempt's telemetry and answer timings into weighted
+// signals. No single signal is proof; a few tab switches or one lost connection stay
+// below the flag threshold on their own.
+func scoreIntegrity(attempt *model.Attempt, result *model.TestResult, events []model.AttemptEvent) []IntegrityFlag {
+	var flags []IntegrityFlag
+	add := func(signal, detail string, points, limit int) {
+		if points <= 0 {
+			return
+		}
+		if points > limit {
+			points = limit
+		}
+		flags = append(flags, IntegrityFlag{Signal: signal, Detail: detail, Points: points})
+	}
+
+	counts := make(map[string]int)
+	ips := make(map[string]bool)
+	agents := make(map[string]bool)
+	var heartbeats []time.Time
+	for _, event := range events {
+		counts[event.Type]++
+		if event.IP != "" {
+			ips[event.IP] = true
+		}
+		if event.UserAgent != "" {
+			agents[event.UserAgent] = true
+		}
+		if event.Type == model.AttemptEventHeartbeat {
+			heartbeats = append(heartbeats, event.OccurredAt)
+		}
+	}
+
+	blurs := counts[model.AttemptEventBlur]
+	add("tab_switches", fmt.Sprintf("left the test tab %d times", blurs), (blurs-2)*5, 30)
+	exits := counts[model.AttemptEventFullscreenExit]
+	add("fullscreen_exits", fmt.Sprintf("left fullscreen %d times", exits), (exits-1)*5, 20)
+	clipboard := counts[model.AttemptEventCopy] + counts[model.AttemptEventPaste]
+	add("clipboard", fmt.Sprintf("%d copy and %d paste events", counts[model.AttemptEventCopy], counts[model.AttemptEventPaste]), clipboard*5, 25)
+	add("ip_changes", fmt.Sprintf("answered from %d IP addresses", len(ips)), (len(ips)-1)*15, 30)
+	add("device_changes", fmt.Sprintf("answered from %d browsers", len(agents)), (len(agents)-1)*20, 40)
+
+	if len(heartbeats) > 0 {
+		end := result.CompletedAt
+		if attempt.SubmittedAt != nil {
+			end = *attempt.SubmittedAt
+		}
+		gaps := 0
+		last := attempt.StartedAt
+		for _, at := range heartbeats {
+			if at.Sub(last) > heartbeatGap {
+				gaps++
+			}
+			if at.After(last) {
+				last = at
+			}
+		}
+		if end.Sub(last) > heartbeatGap {
+			gaps++
+		}
+		add("heartbeat_gaps", fmt.Sprintf("client went silent %d times", gaps), gaps*10, 20)
+	}
+
+	// Only the attempt's own clock is used for speed; the per-question times the
+	// client reports are easy to fake either way.
+	var answers []UserAnswer
+	_ = json.Unmarshal([]byte(result.Answers), &answers)
+	answered := 0
+	for _, answer := range answers {
+		if answer.SelectedAnswer >= 0 || answer.AnswerText != "" {
+			answered++
+		}
+	}
+	if answered >= 5 {
+		perAnswer := float64(result.TimeSpent) / float64(answered)
+		if result.TimeSpent > 0 && perAnswer < minAnswerSeconds && result.Score >= 80 {
+			add("impossible_speed", fmt.Sprintf("scored %.2f with %.1f seconds per answer", result.Score, perAnswer), 40, 40)
+		}
+	}
+	return flags
+}
+
+func (s *integrityService) GetReviewQueue(status string, page, limit int) (*IntegrityReviewPage, error) {
+	if status == "" {
+		status = model.IntegrityFlagged
+	}
+	reports, total, err := s.integrityRepo.FindReports(status, (page-1)*limit, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &IntegrityReviewPage{Total: total, Page: page, Limit: limit, Reports: reports}, nil
+}
+
+func (s *integrityService) GetReview(attemptID string) (*IntegrityReview, error) {
+	report, err := s.findReport(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	var flags []IntegrityFlag
+	_ = json.Unmarshal([]byte(report.Flags), &flags)
+	result, err := s.resultRepo.FindByID(report.TestResultID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	events, err := s.integrityRepo.FindEvents(report.AttemptID)
+	if err != nil {
+		return nil, err
+	}
+	return &IntegrityReview{Report: report, Flags: flags, Result: result, Events: events}, nil
+}
+
+// Decide closes a review. Invalidating voids the attempt's result through the result
+// service, so rankings and the official result follow.
+func (s *integrityService) Decide(reviewerID, attemptID string, req *IntegrityDecisionRequest) (*model.IntegrityReport, error) {
+	reviewerUUID, err := uuid.Parse(reviewerID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	report, err := s.findReport(attemptID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status == model.IntegrityInvalidated {
+		return nil, errors.New("result is already invalidated")
+	}
+
+	if req.Decision == "invalidate" {
+		if _, err := s.testResultService.InvalidateResult(report.TestResultID, req.Note); err != nil {
+			return nil, err
+		}
+		report.Status = model.IntegrityInvalidated
+	} else {
+		report.Status = model.IntegrityCleared
+	}
+	now := time.Now()
+	report.ReviewNote = req.Note
+	report.ReviewedBy = &reviewerUUID
+	report.ReviewedAt = &now
+	if err := s.integrityRepo.SaveReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *integrityService) findReport(attemptID string) (*model.IntegrityReport, error) {
+	attemptUUID, err := uuid.Parse(attemptID)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	report, err := s.integrityRepo.FindReport(attemptUUID)
+	if err != nil {
+		return nil, errors.New("integrity report not found")
+	}
+	return report, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/psychometrics"
+	"github.com/google/uuid"
+)
+
+// calibrationMinRespondents is the default number of results that must have been
+// served a question before its parameters are estimated.
+const calibrationMinRespondents = 100
+
+// SkippedItem is a question left out of a calibration run.
+type SkippedItem struct {
+	QuestionID  uuid.UUID `json:"question_id"`
+	Respondents int       `json:"respondents"`
+	Reason      string    `json:"reason"`
+}
+
+type CalibrationReport struct {
+	TestID      uuid.UUID             `json:"test_id"`
+	Model       string                `json:"model"`
+	Respondents int                   `json:"respondents"`
+	Cycles      int                   `json:"cycles"`
+	Converged   bool                  `json:"converged"`
+	Items       []model.ItemParameter `json:"items"`
+	Skipped     []SkippedItem         `json:"skipped"`
+}
+
+type IRTService interface {
+	CalibrateTest(testID uuid.UUID, irtModel string, minRespondents int) (*CalibrationReport, error)
+	GetItemParameters(testID string) ([]model.ItemParameter, error)
+}
+
+type irtService struct {
+	loader    *responseLoader
+	paramRepo repository.ItemParameterRepository
+}
+
+func NewIRTService(testRepo repository.TestRepository, questionRepo repository.QuestionRepository, resultRepo repository.TestResultRepository, attemptRepo repository.AttemptRepository, paramRepo repository.ItemParameterRepository) IRTService {
+	return &irtService{loader: &responseLoader{testRepo, questionRepo, resultRepo, attemptRepo}, paramRepo: paramRepo}
+}
+
+// CalibrateTest estimates IRT parameters for the multiple choice questions of a test
+// from its completed results and stores them, replacing earlier calibrations. Blank
+// answers count as incorrect; questions a result was never served are treated as
+// missing. Questions with fewer than minRespondents responses are skipped and keep
+// any parameters they already had.
+func (s *irtService) CalibrateTest(testID uuid.UUID, irtModel string, minRespondents int) (*CalibrationReport, error) {
+	if minRespondents <= 0 {
+		minRespondents = calibrationMinRespondents
+	}
+	responses, err := s.loader.load(testID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CalibrationReport{TestID: testID, Model: irtModel}
+	var included []model.Question
+	var columns []int
+	for j, q := range responses.questions {
+		respondents := 0
+		for _, row := range responses.selected {
+			if row[j] != responseNotServed {
+				respondents++
+			}
+		}
+		if respondents < minRespondents {
+			report.Skipped = append(report.Skipped, SkippedItem{QuestionID: q.ID, Respondents: respondents, Reason: "not enough respondents"})
+			continue
+		}
+		included = append(included, q)
+		columns = append(columns, j)
+	}
+	if len(included) == 0 {
+		return nil, errors.New("not enough responses to calibrate")
+	}
+
+	var matrix [][]int
+	respondents := make([]int, len(included))
+	for _, row := range responses.selected {
+		scored := make([]int, len(included))
+		served := false
+		for k, j := range columns {
+			switch row[j] {
+			case responseNotServed:
+				scored[k] = psychometrics.Missing
+				continue
+			case included[k].CorrectAnswer:
+				scored[k] = 1
+			}
+			served = true
+			respondents[k]++
+		}
+		if served {
+			matrix = append(matrix, scored)
+		}
+	}
+	report.Respondents = len(matrix)
+
+	calibration, err := psychometrics.Calibrate(matrix, len(included), psychometrics.CalibrationOptions{Model: irtModel})
+	if err != nil {
+		return nil, err
+	}
+	report.Cycles = calibration.Cycles
+	report.Converged = calibration.Converged
+
+	now := time.Now()
+	for k, q := range included {
+		item := calibration.Items[k]
+		report.Items = append(report.Items, model.ItemParameter{
+			QuestionID:   q.ID,
+			TestID:       testID,
+			Model:        irtModel,
+			A:            round3(item.A),
+			B:            round3(item.B),
+			C:            round3(item.C),
+			Respondents:  respondents[k],
+			CalibratedAt: now,
+		})
+	}
+	if err := s.paramRepo.Save(report.Items); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *irtService) GetItemParameters(testID string) ([]model.ItemParameter, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.paramRepo.FindByTestID(testUUID)
+}
+
+// estimateAbility scores a submission on the IRT scale. Only multiple choice
+// questions with calibrated parameters take part; a served question left blank counts
+// as incorrect. It returns nil when none of the questions is calibrated.
+func estimateAbility(test *model.Test, questions []model.Question, answers []UserAnswer, params []model.ItemParameter) *psychometrics.Ability {
+	byQuestion := make(map[uuid.UUID]model.ItemParameter, len(params))
+	for _, p := range params {
+		byQuestion[p.QuestionID] = p
+	}
+	selected := make(map[string]int, len(answers))
+	for _, answer := range answers {
+		selected[answer.QuestionID] = answer.SelectedAnswer
+	}
+
+	var items []psychometrics.ItemParams
+	var responses []int
+	for _, q := range questions {
+		p, ok := byQuestion[q.ID]
+		if !ok || q.IsEssay() {
+			continue
+		}
+		items = append(items, psychometrics.ItemParams{A: p.A, B: p.B, C: p.C})
+		choice, answered := selected[q.ID.String()]
+		if answered && choice == q.CorrectAnswer {
+			responses = append(responses, 1)
+		} else {
+			responses = append(responses, 0)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if test.AbilityEstimator == model.AbilityMLE {
+		if ability, ok := psychometrics.MLE(responses, items); ok {
+			return &ability
+		}
+	}
+	// EAP is also the fallback for perfect and zero scores, where MLE does not exist.
+	ability := psychometrics.EAP(responses, items, psychometrics.NormalQuadrature(61))
+	return &ability
+}
+
+// applyAbility stores the ability estimate on the result alongside its raw score.
+func applyAbility(result *model.TestResult, test *model.Test, ability *psychometrics.Ability) {
+	if ability == nil {
+		return
+	}
+	mean, sd := test.ScaleMean, test.ScaleSD
+	if sd <= 0 {
+		mean, sd = 500, 100
+	}
+	theta, se := round3(ability.Theta), round3(ability.SE)
+	scaled := math.Round((mean+sd*ability.Theta)*100) / 100
+	result.Theta, result.ThetaSE, result.ScaledScore = &theta, &se, &scaled
+}
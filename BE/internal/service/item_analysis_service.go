@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/psychometrics"
+	"github.com/google/uuid"
+)
+
+// Thresholds for item flags, following the usual classical test theory rules of thumb.
+const (
+	itemMinRespondents      = 20
+	itemTooEasy             = 0.90
+	itemTooHard             = 0.20
+	itemLowDiscrimination   = 0.20
+	itemGroupFraction       = 0.27
+	distractorMinProportion = 0.05
+)
+
+const (
+	ItemFlagInsufficientData       = "insufficient_data"
+	ItemFlagTooEasy                = "too_easy"
+	ItemFlagTooHard                = "too_hard"
+	ItemFlagLowDiscrimination      = "low_discrimination"
+	ItemFlagNegativeDiscrimination = "negative_discrimination"
+	ItemFlagPossibleMiskey         = "possible_miskey"
+	ItemFlagNonFunctioningOption   = "non_functioning_distractor"
+)
+
+// OptionStatistics describes how often an option was chosen overall and in the upper
+// and lower scoring groups.
+type OptionStatistics struct {
+	Index           int     `json:"index"`
+	Label           string  `json:"label"`
+	IsKey           bool    `json:"is_key"`
+	Count           int     `json:"count"`
+	Proportion      float64 `json:"proportion"`
+	UpperProportion float64 `json:"upper_proportion"`
+	LowerProportion float64 `json:"lower_proportion"`
+	MeanTotal       float64 `json:"mean_total"`
+	NonFunctioning  bool    `json:"non_functioning,omitempty"`
+}
+
+// ItemStatistics is the analysis of one multiple choice question. PValue is the
+// proportion answering correctly, PointBiserial the corrected item-total correlation
+// and Discrimination the upper minus lower group proportion correct.
+type ItemStatistics struct {
+	Number         int                `json:"number"`
+	QuestionID     uuid.UUID          `json:"question_id"`
+	QuestionText   string             `json:"question_text"`
+	Topic          string             `json:"topic,omitempty"`
+	Respondents    int                `json:"respondents"`
+	Blank          int                `json:"blank"`
+	PValue         float64            `json:"p_value"`
+	PointBiserial  float64            `json:"point_biserial"`
+	Discrimination float64            `json:"discrimination"`
+	Options        []OptionStatistics `json:"options"`
+	Flags          []string           `json:"flags"`
+}
+
+type ItemAnalysisReport struct {
+	TestID         uuid.UUID        `json:"test_id"`
+	Title          string           `json:"title"`
+	Results        int              `json:"results"`
+	MinRespondents int              `json:"min_respondents"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Items          []ItemStatistics `json:"items"`
+}
+
+type ItemAnalysisService interface {
+	AnalyzeTest(testID string) (*ItemAnalysisReport, error)
+	ExportCSV(report *ItemAnalysisReport) ([]byte, error)
+}
+
+type itemAnalysisService struct {
+	loader *responseLoader
+}
+
+func NewItemAnalysisService(testRepo repository.TestRepository, questionRepo repository.QuestionRepository, resultRepo repository.TestResultRepository, attemptRepo repository.AttemptRepository) ItemAnalysisService {
+	return &itemAnalysisService{&responseLoader{testRepo, questionRepo, resultRepo, attemptRepo}}
+}
+
+// AnalyzeTest computes classical item statistics for every multiple choice question
+// of a test from its completed results. Essays are not analysed.
+func (s *itemAnalysisService) AnalyzeTest(testID string) (*ItemAnalysisReport, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	responses, err := s.loader.load(testUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each respondent's total is the number of analysed questions they got right.
+	totals := make([]float64, len(responses.results))
+	for r, row := range responses.selected {
+		for q, selected := range row {
+			if selected == responses.questions[q].CorrectAnswer {
+				totals[r]++
+			}
+		}
+	}
+
+	report := &ItemAnalysisReport{
+		TestID:         responses.test.ID,
+		Title:          responses.test.Title,
+		Results:        len(responses.results),
+		MinRespondents: itemMinRespondents,
+		GeneratedAt:    time.Now(),
+		Items:          make([]ItemStatistics, 0, len(responses.questions)),
+	}
+	for q := range responses.questions {
+		report.Items = append(report.Items, analyzeItem(responses, q, totals))
+	}
+	return report, nil
+}
+
+func analyzeItem(responses *testResponses, q int, totals []float64) ItemStatistics {
+	question := responses.questions[q]
+	item := ItemStatistics{
+		Number:       q + 1,
+		QuestionID:   question.ID,
+		QuestionText: question.QuestionText,
+		Topic:        question.Topic,
+		Flags:        []string{},
+	}
+
+	// Respondents who were served the question, ordered by total score.
+	var respondents []int
+	for r, row := range responses.selected {
+		if row[q] != responseNotServed {
+			respondents = append(respondents, r)
+		}
+	}
+	sort.SliceStable(respondents, func(a, b int) bool { return totals[respondents[a]] < totals[respondents[b]] })
+	item.Respondents = len(respondents)
+
+	options := make([]OptionStatistics, optionCount(question.Options))
+	for i := range options {
+		options[i] = OptionStatistics{Index: i, Label: optionLabel(i), IsKey: i == question.CorrectAnswer}
+	}
+	if item.Respondents == 0 {
+		item.Options = options
+		item.Flags = append(item.Flags, ItemFlagInsufficientData)
+		return item
+	}
+
+	scores := make([]float64, len(respondents))
+	rest := make([]float64, len(respondents))
+	optionTotals := make([]float64, len(options))
+	for i, r := range respondents {
+		selected := responses.selected[r][q]
+		if selected == question.CorrectAnswer {
+			scores[i] = 1
+		}
+		rest[i] = totals[r] - scores[i]
+		switch {
+		case selected == responseBlank:
+			item.Blank++
+		case selected >= 0 && selected < len(options):
+			options[selected].Count++
+			optionTotals[selected] += totals[r]
+		}
+	}
+	item.PValue = round3(psychometrics.Mean(scores))
+	item.PointBiserial = round3(psychometrics.PointBiserial(scores, rest))
+
+	lowerEnd, upperStart := psychometrics.GroupBounds(len(respondents), itemGroupFraction)
+	lower, upper := respondents[:lowerEnd], respondents[upperStart:]
+	for i := range options {
+		option := &options[i]
+		option.Proportion = round3(float64(option.Count) / float64(item.Respondents))
+		option.UpperProportion = round3(chosenShare(responses, q, upper, i))
+		option.LowerProportion = round3(chosenShare(responses, q, lower, i))
+		if option.Count > 0 {
+			option.MeanTotal = round3(optionTotals[i] / float64(option.Count))
+		}
+		if !option.IsKey && option.Proportion < distractorMinProportion {
+			option.NonFunctioning = true
+		}
+	}
+	item.Options = options
+	if key := question.CorrectAnswer; key >= 0 && key < len(options) {
+		item.Discrimination = round3(options[key].UpperProportion - options[key].LowerProportion)
+	}
+
+	item.Flags = itemFlags(item, question.CorrectAnswer)
+	return item
+}
+
+func itemFlags(item ItemStatistics, key int) []string {
+	flags := []string{}
+	if item.Respondents < itemMinRespondents {
+		return append(flags, ItemFlagInsufficientData)
+	}
+	if item.PValue >= itemTooEasy {
+		flags = append(flags, ItemFlagTooEasy)
+	}
+	if item.PValue <= itemTooHard {
+		flags = append(flags, ItemFlagTooHard)
+	}
+	if item.PointBiserial < 0 {
+		flags = append(flags, ItemFlagNegativeDiscrimination)
+	} else if item.PointBiserial < itemLowDiscrimination {
+		flags = append(flags, ItemFlagLowDiscrimination)
+	}
+
+	// A distractor that the strongest students prefer over the key, and that
+	// discriminates positively, usually means the key is wrong.
+	if key >= 0 && key < len(item.Options) {
+		keyOption := item.Options[key]
+		for _, option := range item.Options {
+			if !option.IsKey && option.UpperProportion > keyOption.UpperProportion && option.UpperProportion > option.LowerProportion {
+				flags = append(flags, ItemFlagPossibleMiskey)
+				break
+			}
+		}
+	}
+	for _, option := range item.Options {
+		if option.NonFunctioning {
+			flags = append(flags, ItemFlagNonFunctioningOption)
+			break
+		}
+	}
+	return flags
+}
+
+func chosenShare(responses *testResponses, q int, group []int, option int) float64 {
+	if len(group) == 0 {
+		return 0
+	}
+	chosen := 0
+	for _, r := range group {
+		if responses.selected[r][q] == option {
+			chosen++
+		}
+	}
+	return float64(chosen) / float64(len(group))
+}
+
+// optionLabel turns an option index into A, B, C...
+func optionLabel(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return strconv.Itoa(i + 1)
+}
+
+func round3(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
+
+// ExportCSV writes one row per question, with the proportion choosing each option in
+// its own column.
+func (s *itemAnalysisService) ExportCSV(report *ItemAnalysisReport) ([]byte, error) {
+	maxOptions := 0
+	for _, item := range report.Items {
+		if len(item.Options) > maxOptions {
+			maxOptions = len(item.Options)
+		}
+	}
+
+	header := []string{"number", "question_id", "topic", "question_text", "respondents", "blank", "p_value", "point_biserial", "discrimination", "key"}
+	for i := 0; i < maxOptions; i++ {
+		header = append(header, "option_"+optionLabel(i))
+	}
+	header = append(header, "flags")
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, item := range report.Items {
+		key := ""
+		for _, option := range item.Options {
+			if option.IsKey {
+				key = option.Label
+			}
+		}
+		row := []string{
+			strconv.Itoa(item.Number),
+			item.QuestionID.String(),
+			item.Topic,
+			item.QuestionText,
+			strconv.Itoa(item.Respondents),
+			strconv.Itoa(item.Blank),
+			formatStat(item.PValue),
+			formatStat(item.PointBiserial),
+			formatStat(item.Discrimination),
+			key,
+		}
+		for i := 0; i < maxOptions; i++ {
+			if i < len(item.Options) {
+				row = append(row, formatStat(item.Options[i].Proportion))
+			} else {
+				row = append(row, "")
+			}
+		}
+		row = append(row, strings.Join(item.Flags, ";"))
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatStat(x float64) string {
+	return fmt.Sprintf("%.3f", x)
+}
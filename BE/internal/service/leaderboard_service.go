@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Each test has a sorted set of students scored by the officialRankingScore of their
+// official result, and a hash from student to that result.
+func leaderboardKey(testID uuid.UUID) string {
+	return fmt.Sprintf("leaderboard:test:%s", testID)
+}
+
+func leaderboardResultsKey(testID uuid.UUID) string {
+	return fmt.Sprintf("leaderboard:test:%s:results", testID)
+}
+
+type LeaderboardService interface {
+	GetLeaderboard(testID string, page, limit int) (*RankingPage, error)
+	AttachRanks(results []model.TestResult)
+	Rebuild(testID uuid.UUID) (int, error)
+	RebuildAll() (int, error)
+	ResultListener
+}
+
+type leaderboardService struct {
+	resultRepo  repository.TestResultRepository
+	testRepo    repository.TestRepository
+	redisClient *redis.Client
+}
+
+func NewLeaderboardService(resultRepo repository.TestResultRepository, testRepo repository.TestRepository, redisClient *redis.Client) LeaderboardService {
+	return &leaderboardService{resultRepo, testRepo, redisClient}
+}
+
+// ResultFinalized puts the student's official result on the test leaderboard. The
+// official result may be an earlier one, or its score may drop (under the last and
+// average policies), so the entry is replaced rather than only ever raised.
+func (s *leaderboardService) ResultFinalized(result *model.TestResult) {
+	official, err := s.resultRepo.FindOfficial(result.TestID, result.UserID)
+	if err != nil {
+		log.Printf("leaderboard: no official result for result %s: %v", result.ID, err)
+		return
+	}
+	ctx := context.Background()
+	user := official.UserID.String()
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, leaderboardKey(official.TestID), &redis.Z{Score: officialRankingScore(official), Member: user})
+		pipe.HSet(ctx, leaderboardResultsKey(official.TestID), user, official.ID.String())
+		return nil
+	})
+	if err != nil {
+		log.Printf("leaderboard: could not add result %s: %v", official.ID, err)
+	}
+}
+
+// ResultInvalidated moves the student to their newly selected official result, or
+// takes them off the leaderboard when no result of theirs counts any more.
+func (s *leaderboardService) ResultInvalidated(result *model.TestResult) {
+	if _, err := s.resultRepo.FindOfficial(result.TestID, result.UserID); err == nil {
+		s.ResultFinalized(result)
+		return
+	}
+	ctx := context.Background()
+	user := result.UserID.String()
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, leaderboardKey(result.TestID), user)
+		pipe.HDel(ctx, leaderboardResultsKey(result.TestID), user)
+		return nil
+	})
+	if err != nil {
+		log.Printf("leaderboard: could not remove result %s: %v", result.ID, err)
+	}
+}
+
+func (s *leaderboardService) GetLeaderboard(testID string, page, limit int) (*RankingPage, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	if _, err := s.testRepo.FindByID(testUUID); err != nil {
+		return nil, errors.New("test not found")
+	}
+	ctx := context.Background()
+
+	total, err := s.redisClient.ZCard(ctx, leaderboardKey(testUUID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	start, stop := pageBounds(page, limit)
+	users, err := s.redisClient.ZRevRange(ctx, leaderboardKey(testUUID), start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(users))
+	if len(users) > 0 {
+		resultIDs, err := s.redisClient.HMGet(ctx, leaderboardResultsKey(testUUID), users...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range resultIDs {
+			member, _ := value.(string)
+			if id, err := uuid.Parse(member); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	entries, err := rankingEntries(s.resultRepo, ids, start+1, true)
+	if err != nil {
+		return nil, err
+	}
+	return &RankingPage{Total: total, Page: page, Limit: limit, Entries: entries}, nil
+}
+
+// AttachRanks fills Rank, Percentile and RankedCount on completed results. A result
+// that is not the student's official one is placed where its score would rank
+// against everyone else's official result. Results are left unranked when Redis cannot be reached.
+func (s *leaderboardService) AttachRanks(results []model.TestResult) {
+	ctx := context.Background()
+	type lookup struct {
+		above, total *redis.IntCmd
+		own          *redis.FloatCmd
+		score        float64
+	}
+	lookups := make([]*lookup, len(results))
+
+	_, err := s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := range results {
+			if results[i].Status != model.ResultStatusCompleted {
+				continue
+			}
+			key := leaderboardKey(results[i].TestID)
+			score := officialRankingScore(&results[i])
+			lookups[i] = &lookup{
+				above: pipe.ZCount(ctx, key, fmt.Sprintf("(%f", score), "+inf"),
+				total: pipe.ZCard(ctx, key),
+				own:   pipe.ZScore(ctx, key, results[i].UserID.String()),
+				score: score,
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		log.Printf("leaderboard: could not rank results: %v", err)
+		return
+	}
+
+	for i, l := range lookups {
+		if l == nil {
+			continue
+		}
+		own, err := l.own.Result()
+		if err != nil {
+			// The student is not on the board yet (or Redis lost it); wait for
+			// the next rebuild rather than showing a wrong rank.
+			continue
+		}
+		above, total := l.above.Val(), l.total.Val()
+		if own > l.score {
+			above--
+		}
+		rank := above + 1
+		// Percentile is the share of ranked students placed at or below this result.
+		percentile := math.Round(float64(total-rank+1)/float64(total)*10000) / 100
+		results[i].Rank = &rank
+		results[i].Percentile = &percentile
+		results[i].RankedCount = total
+	}
+}
+
+// Rebuild replaces a test's leaderboard with one computed from the completed results
+// in MySQL and returns the number of students on it. Official results are picked
+// again by the test's current policy and re-marked where they changed, so a rebuild
+// also applies a policy change. The new board is written under temporary keys and
+// swapped in with RENAME, so readers never see it half built.
+func (s *leaderboardService) Rebuild(testID uuid.UUID) (int, error) {
+	test, err := s.testRepo.FindByID(testID)
+	if err != nil {
+		return 0, err
+	}
+	results, err := s.resultRepo.FindFinalByTestID(testID)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].CompletedAt.Before(results[j].CompletedAt) })
+	byUser := make(map[uuid.UUID][]model.TestResult)
+	for _, result := range results {
+		byUser[result.UserID] = append(byUser[result.UserID], result)
+	}
+
+	counted := make(map[uuid.UUID]*model.TestResult, len(byUser))
+	for userID, own := range byUser {
+		official, score := officialResult(test.OfficialResult, own)
+		marked := official.IsOfficial && official.OfficialScore != nil && *official.OfficialScore == score
+		for _, result := range own {
+			if result.IsOfficial && result.ID != official.ID {
+				marked = false
+			}
+		}
+		if !marked {
+			if err := s.resultRepo.SetOfficial(testID, userID, official.ID, score); err != nil {
+				return 0, err
+			}
+		}
+		official.IsOfficial, official.OfficialScore = true, &score
+		counted[userID] = official
+	}
+
+	ctx := context.Background()
+	key, resultsKey := leaderboardKey(testID), leaderboardResultsKey(testID)
+	if len(counted) == 0 {
+		return 0, s.redisClient.Del(ctx, key, resultsKey).Err()
+	}
+
+	tmpKey, tmpResultsKey := key+":rebuild", resultsKey+":rebuild"
+	members := make([]*redis.Z, 0, len(counted))
+	resultIDs := make(map[string]interface{}, len(counted))
+	for userID, result := range counted {
+		members = append(members, &redis.Z{Score: officialRankingScore(result), Member: userID.String()})
+		resultIDs[userID.String()] = result.ID.String()
+	}
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tmpKey, tmpResultsKey)
+		pipe.ZAdd(ctx, tmpKey, members...)
+		pipe.HSet(ctx, tmpResultsKey, resultIDs)
+		pipe.Rename(ctx, tmpKey, key)
+		pipe.Rename(ctx, tmpResultsKey, resultsKey)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(counted), nil
+}
+
+// RebuildAll rebuilds the leaderboard of every test and returns the number of tests.
+func (s *leaderboardService) RebuildAll() (int, error) {
+	tests, err := s.testRepo.FindAll()
+	if err != nil {
+		return 0, err
+	}
+	for _, test := range tests {
+		if _, err := s.Rebuild(test.ID); err != nil {
+			return 0, fmt.Errorf("test %s: %w", test.ID, err)
+		}
+	}
+	return len(tests), nil
+}
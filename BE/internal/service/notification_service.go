@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+type NotificationService interface {
+	Notify(userID uuid.UUID, notificationType, title, message, link string) error
+	GetNotifications(userID string) ([]model.Notification, error)
+	MarkAsRead(userID, notificationID string) error
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository) NotificationService {
+	return &notificationService{repo}
+}
+
+func (s *notificationService) Notify(userID uuid.UUID, notificationType, title, message, link string) error {
+	return s.repo.Create(&model.Notification{
+		ID:      uuid.New(),
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		Link:    link,
+	})
+}
+
+func (s *notificationService) GetNotifications(userID string) ([]model.Notification, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	return s.repo.FindByUserID(userUUID)
+}
+
+func (s *notificationService) MarkAsRead(userID, notificationID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.New("invalid user ID format")
+	}
+	notificationUUID, err := uuid.Parse(notificationID)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+	if err := s.repo.MarkAsRead(notificationUUID, userUUID); err != nil {
+		return errors.New("notification not found")
+	}
+	return nil
+}
@@ -18,19 +18,33 @@ type OrderService interface {
 }
 
 type orderService struct {
-	orderRepo repository.OrderRepository
-	userRepo  repository.UserRepository
+	orderRepo   repository.OrderRepository
+	userRepo    repository.UserRepository
+	orgRepo     repository.OrganizationRepository
+	contentRepo repository.ClassContentRepository
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, userRepo repository.UserRepository) OrderService {
-	return &orderService{orderRepo, userRepo}
+func NewOrderService(orderRepo repository.OrderRepository, userRepo repository.UserRepository, orgRepo repository.OrganizationRepository, contentRepo repository.ClassContentRepository) OrderService {
+	return &orderService{orderRepo, userRepo, orgRepo, contentRepo}
+}
+
+// Group licenses are bought by a teacher for their organization (the order's item_id)
+// and add Seats seats to it instead of making the buyer premium.
+const (
+	itemGroupLicenseMonthly = "group_license_monthly"
+	itemGroupLicenseYearly  = "group_license_yearly"
+)
+
+func isGroupLicense(itemType string) bool {
+	return itemType == itemGroupLicenseMonthly || itemType == itemGroupLicenseYearly
 }
 
 // DTO pembuatan order
 type CreateOrderRequest struct {
-	ItemType string  `json:"item_type" validate:"required"`     // "test", "premium_monthly", "premium_yearly"
+	ItemType string  `json:"item_type" validate:"required"` // "test", "premium_monthly", "premium_yearly", "premium_class", "group_license_monthly", "group_license_yearly"
 	ItemID   string  `json:"item_id" validate:"required,uuid"`
 	Amount   float64 `json:"amount" validate:"required,gt=0"`
+	Seats    int     `json:"seats" validate:"omitempty,gt=0"` // hanya untuk group license
 }
 
 // Membuat order baru
@@ -44,12 +58,25 @@ func (s *orderService) CreateOrder(userID string, req *CreateOrderRequest) (*mod
 		return nil, errors.New("invalid item id format")
 	}
 
+	seats := 0
+	if isGroupLicense(req.ItemType) {
+		if req.Seats <= 0 {
+			return nil, errors.New("seats must be greater than zero")
+		}
+		member, err := s.orgRepo.FindMember(itemUUID, userUUID)
+		if err != nil || member.Role != model.OrgRoleTeacher {
+			return nil, errors.New("only teachers of the organization can buy a group license")
+		}
+		seats = req.Seats
+	}
+
 	order := &model.Order{
 		ID:       uuid.New(),
 		UserID:   userUUID,
 		ItemType: req.ItemType,
 		ItemID:   itemUUID,
 		Amount:   req.Amount,
+		Seats:    seats,
 		Status:   "pending",
 	}
 
@@ -90,6 +117,13 @@ func (s *orderService) VerifyOrder(orderID string) error {
 		return errors.New("order not found")
 	}
 
+	if isGroupLicense(order.ItemType) {
+		return s.activateGroupLicense(order)
+	}
+	if order.ItemType == itemPremiumClass {
+		return s.enrollFromOrder(order)
+	}
+
 	// --- LOGIKA BARU ---
 	// 1. Dapatkan user
 	user, err := s.userRepo.FindUserByID(order.UserID)
@@ -126,6 +160,55 @@ func (s *orderService) VerifyOrder(orderID string) error {
 	return nil
 }
 
+// activateGroupLicense gives the organization the order's seats for a month or a year.
+// Verifying the same order twice, even at the same moment, does not add a second
+// license.
+func (s *orderService) activateGroupLicense(order *model.Order) error {
+	if order.Status == "completed" {
+		return nil
+	}
+	startsAt := time.Now()
+	expiresAt := startsAt.AddDate(0, 1, 0)
+	if order.ItemType == itemGroupLicenseYearly {
+		expiresAt = startsAt.AddDate(1, 0, 0)
+	}
+	license := &model.OrganizationLicense{
+		ID:             uuid.New(),
+		OrganizationID: order.ItemID,
+		OrderID:        order.ID,
+		Seats:          order.Seats,
+		StartsAt:       startsAt,
+		ExpiresAt:      expiresAt,
+	}
+	if _, err := s.orgRepo.ActivateLicense(license); err != nil {
+		return err
+	}
+	order.Status = "completed"
+	return nil
+}
+
+// enrollFromOrder enrolls the buyer in the premium class they paid for. An earlier
+// enrollment through premium membership is moved over to the order.
+func (s *orderService) enrollFromOrder(order *model.Order) error {
+	enrollment, err := s.contentRepo.FindEnrollment(order.ItemID, order.UserID)
+	if err != nil {
+		enrollment = &model.ClassEnrollment{ID: uuid.New(), ClassID: order.ItemID, UserID: order.UserID}
+	}
+	enrollment.Source = model.EnrollmentSourceOrder
+	enrollment.OrderID = &order.ID
+	if err != nil {
+		err = s.contentRepo.CreateEnrollment(enrollment)
+	} else {
+		err = s.contentRepo.UpdateEnrollment(enrollment)
+	}
+	if err != nil {
+		return err
+	}
+
+	order.Status = "completed"
+	return s.orderRepo.Update(order)
+}
+
 // Dapatkan semua order milik user
 func (s *orderService) GetOrdersByUserID(userID string) ([]model.Order, error) {
 	userUUID, err := uuid.Parse(userID)
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=teacher student"`
+}
+
+type SetSeatRequest struct {
+	HasSeat *bool `json:"has_seat" validate:"required"`
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type AddGroupMembersRequest struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
+}
+
+type CreateAssignmentRequest struct {
+	TestID string    `json:"test_id" validate:"required,uuid"`
+	DueAt  time.Time `json:"due_at" validate:"required"`
+}
+
+// OrganizationSummary is an organization as one of its members (or a site admin)
+// sees it. Role is empty for site admins who are not members.
+type OrganizationSummary struct {
+	Organization *model.Organization         `json:"organization"`
+	Role         string                      `json:"role,omitempty"`
+	Seats        int64                       `json:"seats"`
+	SeatsUsed    int64                       `json:"seats_used"`
+	Licenses     []model.OrganizationLicense `json:"licenses"`
+}
+
+type OrganizationService interface {
+	CreateOrganization(req *CreateOrganizationRequest) (*model.Organization, error)
+	GetAllOrganizations() ([]model.Organization, error)
+	GetMyOrganizations(userID string) ([]model.OrganizationMember, error)
+	GetOrganization(userID, userRole, orgID string) (*OrganizationSummary, error)
+
+	AddMember(userID, userRole, orgID string, req *AddMemberRequest) (*model.OrganizationMember, error)
+	GetMembers(userID, userRole, orgID string) ([]model.OrganizationMember, error)
+	RemoveMember(userID, userRole, orgID, memberID string) error
+	SetSeat(userID, userRole, orgID, memberID string, req *SetSeatRequest) (*model.OrganizationMember, error)
+
+	CreateGroup(userID, orgID string, req *CreateGroupRequest) (*model.Group, error)
+	GetGroups(userID, userRole, orgID string) ([]model.Group, error)
+	GetGroupMembers(userID, userRole, orgID, groupID string) ([]model.GroupMember, error)
+	AddGroupMembers(userID, orgID, groupID string, req *AddGroupMembersRequest) ([]model.GroupMember, error)
+	RemoveGroupMember(userID, orgID, groupID, memberID string) error
+
+	CreateAssignment(userID, orgID, groupID string, req *CreateAssignmentRequest) (*model.Assignment, error)
+	GetAssignments(userID, userRole, orgID, groupID string) ([]model.Assignment, error)
+	GetAssignmentProgress(userID, userRole, orgID, groupID, assignmentID string) (*AssignmentProgress, error)
+	GetMyAssignments(userID string) ([]MyAssignment, error)
+	GetStudentResults(userID, orgID, groupID, testID string) ([]model.TestResult, error)
+
+	RemindOverdue(now time.Time) (int, error)
+	StartReminderJob(interval time.Duration)
+}
+
+type organizationService struct {
+	orgRepo             repository.OrganizationRepository
+	userRepo            repository.UserRepository
+	testRepo            repository.TestRepository
+	notificationService NotificationService
+}
+
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, testRepo repository.TestRepository, notificationService NotificationService) OrganizationService {
+	return &organizationService{orgRepo, userRepo, testRepo, notificationService}
+}
+
+// orgAccess is the caller's standing in an organization. member is nil for site
+// admins who are not members themselves.
+type orgAccess struct {
+	orgID  uuid.UUID
+	userID uuid.UUID
+	admin  bool
+	member *model.OrganizationMember
+}
+
+func (a *orgAccess) isTeacher() bool {
+	return a.member != nil && a.member.Role == model.OrgRoleTeacher
+}
+
+// access resolves the caller's membership. Non-members get "organization not found",
+// so the existence of other organizations is not revealed.
+func (s *organizationService) access(userID, userRole, orgID string) (*orgAccess, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	access := &orgAccess{orgID: orgUUID, userID: userUUID, admin: userRole == "admin"}
+	if member, err := s.orgRepo.FindMember(orgUUID, userUUID); err == nil {
+		access.member = member
+	} else if !access.admin {
+		return nil, errors.New("organization not found")
+	}
+	if access.admin {
+		if _, err := s.orgRepo.FindByID(orgUUID); err != nil {
+			return nil, errors.New("organization not found")
+		}
+	}
+	return access, nil
+}
+
+// teacherAccess is access for actions only the organization's teachers (or site
+// admins, when allowAdmin is set) may take.
+func (s *organizationService) teacherAccess(userID, userRole, orgID string, allowAdmin bool) (*orgAccess, error) {
+	access, err := s.access(userID, userRole, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.isTeacher() && !(allowAdmin && access.admin) {
+		return nil, errors.New("access denied")
+	}
+	return access, nil
+}
+
+// ownGroup loads a group of the organization that the caller teaches.
+func (s *organizationService) ownGroup(access *orgAccess, groupID string) (*model.Group, error) {
+	groupUUID, err := uuid.Parse(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	group, err := s.orgRepo.FindGroup(access.orgID, groupUUID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	if group.TeacherID != access.userID {
+		return nil, errors.New("access denied")
+	}
+	return group, nil
+}
+
+func (s *organizationService) CreateOrganization(req *CreateOrganizationRequest) (*model.Organization, error) {
+	org := &model.Organization{ID: uuid.New(), Name: strings.TrimSpace(req.Name)}
+	if err := s.orgRepo.Create(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) GetAllOrganizations() ([]model.Organization, error) {
+	return s.orgRepo.FindAll()
+}
+
+func (s *organizationService) GetMyOrganizations(userID string) ([]model.OrganizationMember, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	return s.orgRepo.FindMembershipsByUser(userUUID)
+}
+
+func (s *organizationService) GetOrganization(userID, userRole, orgID string) (*OrganizationSummary, error) {
+	access, err := s.access(userID, userRole, orgID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.orgRepo.FindByID(access.orgID)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	summary := &OrganizationSummary{Organization: org}
+	if access.member != nil {
+		summary.Role = access.member.Role
+	}
+	now := time.Now()
+	if summary.Seats, err = s.orgRepo.ActiveSeats(access.orgID, now); err != nil {
+		return nil, err
+	}
+	if summary.SeatsUsed, err = s.orgRepo.CountSeated(access.orgID); err != nil {
+		return nil, err
+	}
+	if summary.Licenses, err = s.orgRepo.FindLicenses(access.orgID); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// AddMember adds an existing user to the organization by email. Teachers may add
+// students; site admins may also add teachers.
+func (s *organizationService) AddMember(userID, userRole, orgID string, req *AddMemberRequest) (*model.OrganizationMember, error) {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == model.OrgRoleTeacher && !access.admin {
+		return nil, errors.New("access denied")
+	}
+	user, err := s.userRepo.FindUserByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if _, err := s.orgRepo.FindMember(access.orgID, user.ID); err == nil {
+		return nil, errors.New("user is already a member")
+	}
+
+	member := &model.OrganizationMember{
+		ID:             uuid.New(),
+		OrganizationID: access.orgID,
+		UserID:         user.ID,
+		Role:           req.Role,
+	}
+	if err := s.orgRepo.AddMember(member); err != nil {
+		return nil, err
+	}
+	member.User = *user
+	return member, nil
+}
+
+func (s *organizationService) GetMembers(userID, userRole, orgID string) ([]model.OrganizationMember, error) {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.orgRepo.FindMembers(access.orgID, "")
+}
+
+// findManagedMember loads the member with the given user ID for a change by the
+// caller. Teachers may only change students; site admins may change anyone.
+func (s *organizationService) findManagedMember(access *orgAccess, memberID string) (*model.OrganizationMember, error) {
+	memberUUID, err := uuid.Parse(memberID)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	member, err := s.orgRepo.FindMember(access.orgID, memberUUID)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	if member.Role != model.OrgRoleStudent && !access.admin {
+		return nil, errors.New("access denied")
+	}
+	return member, nil
+}
+
+func (s *organizationService) RemoveMember(userID, userRole, orgID, memberID string) error {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return err
+	}
+	member, err := s.findManagedMember(access, memberID)
+	if err != nil {
+		return err
+	}
+	return s.orgRepo.RemoveMember(access.orgID, member.UserID)
+}
+
+// SetSeat gives a student one of the organization's licensed seats, or frees it. A
+// seat can only be given while the active licenses have one to spare.
+func (s *organizationService) SetSeat(userID, userRole, orgID, memberID string, req *SetSeatRequest) (*model.OrganizationMember, error) {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.findManagedMember(access, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != model.OrgRoleStudent {
+		return nil, errors.New("seats are only given to students")
+	}
+	if member.HasSeat == *req.HasSeat {
+		return member, nil
+	}
+	if *req.HasSeat {
+		seated, err := s.orgRepo.SeatMember(member, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if !seated {
+			return nil, errors.New("no licensed seats left")
+		}
+		return member, nil
+	}
+	member.HasSeat = false
+	if err := s.orgRepo.UpdateMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// CreateGroup creates a group led by the calling teacher.
+func (s *organizationService) CreateGroup(userID, orgID string, req *CreateGroupRequest) (*model.Group, error) {
+	access, err := s.teacherAccess(userID, "", orgID, false)
+	if err != nil {
+		return nil, err
+	}
+	group := &model.Group{
+		ID:             uuid.New(),
+		OrganizationID: access.orgID,
+		TeacherID:      access.userID,
+		Name:           strings.TrimSpace(req.Name),
+	}
+	if err := s.orgRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetGroups lists the groups a teacher leads or a student is in; site admins see all.
+func (s *organizationService) GetGroups(userID, userRole, orgID string) ([]model.Group, error) {
+	access, err := s.access(userID, userRole, orgID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case access.isTeacher():
+		return s.orgRepo.FindGroupsByTeacher(access.orgID, access.userID)
+	case access.member != nil:
+		return s.orgRepo.FindGroupsByStudent(access.orgID, access.userID)
+	default:
+		return s.orgRepo.FindGroups(access.orgID)
+	}
+}
+
+func (s *organizationService) GetGroupMembers(userID, userRole, orgID, groupID string) ([]model.GroupMember, error) {
+	access, err := s.teacherAccess(userID, userRole, orgID, true)
+	if err != nil {
+		return nil, err
+	}
+	var group *model.Group
+	if access.isTeacher() {
+		group, err = s.ownGroup(access, groupID)
+	} else {
+		group, err = s.findGroup(access, groupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.orgRepo.FindGroupMembers(access.orgID, group.ID)
+}
+
+func (s *organizationService) findGroup(access *orgAccess, groupID string) (*model.Group, error) {
+	groupUUID, err := uuid.Parse(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	group, err := s.orgRepo.FindGroup(access.orgID, groupUUID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+// AddGroupMembers adds students of the organization to one of the teacher's groups.
+func (s *organizationService) AddGroupMembers(userID, orgID, groupID string, req *AddGroupMembersRequest) ([]model.GroupMember, error) {
+	access, err := s.teacherAccess(userID, "", orgID, false)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.ownGroup(access, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]model.GroupMember, 0, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		studentUUID, _ := uuid.Parse(id)
+		student, err := s.orgRepo.FindMember(access.orgID, studentUUID)
+		if err != nil || student.Role != model.OrgRoleStudent {
+			return nil, errors.New("only students of the organization can join a group")
+		}
+		members = append(members, model.GroupMember{
+			ID:             uuid.New(),
+			OrganizationID: access.orgID,
+			GroupID:        group.ID,
+			UserID:         studentUUID,
+		})
+	}
+	if err := s.orgRepo.AddGroupMembers(members); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.FindGroupMembers(access.orgID, group.ID)
+}
+
+func (s *organizationService) RemoveGroupMember(userID, orgID, groupID, memberID string) error {
+	access, err := s.teacherAccess(userID, "", orgID, false)
+	if err != nil {
+		return err
+	}
+	group, err := s.ownGroup(access, groupID)
+	if err != nil {
+		return err
+	}
+	memberUUID, err := uuid.Parse(memberID)
+	if err != nil {
+		return errors.New("member not found")
+	}
+	return s.orgRepo.RemoveGroupMember(access.orgID, group.ID, memberUUID)
+}
+
+// CreateAssignment assigns a published test to one of the teacher's groups and lets
+// its students know.
+func (s *organizationService) CreateAssignment(userID, orgID, groupID string, req *CreateAssignmentRequest) (*model.Assignment, error) {
+	access, err := s.teacherAccess(userID, "", orgID, false)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.ownGroup(access, groupID)
+	if err != nil {
+		return nil, err
+	}
+	testUUID, _ := uuid.Parse(req.TestID)
+	test, err := s.testRepo.FindByID(testUUID)
+	if err != nil || test.Status != model.TestStatusPublished {
+		return nil, errors.New("test not found")
+	}
+	if !req.DueAt.After(time.Now()) {
+		return nil, errors.New("due date must be in the future")
+	}
+
+	assignment := &model.Assignment{
+		ID:             uuid.New(),
+		OrganizationID: access.orgID,
+		GroupID:        group.ID,
+		TestID:         test.ID,
+		AssignedBy:     access.userID,
+		DueAt:          req.DueAt,
+	}
+	if err := s.orgRepo.CreateAssignment(assignment); err != nil {
+		return nil, err
+	}
+	assignment.Test = *test
+	s.notifyAssigned(assignment)
+	return assignment, nil
+}
+
+// GetAssignments lists a group's assignments for its teacher, its students and site
+// admins.
+func (s *organizationService) GetAssignments(userID, userRole, orgID, groupID string) ([]model.Assignment, error) {
+	access, err := s.access(userID, userRole, orgID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.findGroup(access, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if access.isTeacher() && group.TeacherID != access.userID {
+		return nil, errors.New("access denied")
+	}
+	if access.member != nil && !access.isTeacher() {
+		members, err := s.orgRepo.FindGroupMembers(access.orgID, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		inGroup := false
+		for _, member := range members {
+			inGroup = inGroup || member.UserID == access.userID
+		}
+		if !inGroup {
+			return nil, errors.New("group not found")
+		}
+	}
+	return s.orgRepo.FindAssignments(access.orgID, group.ID)
+}
+
+// GetStudentResults is the teacher dashboard: results of the students in the
+// teacher's own groups, optionally for one group and one test.
+func (s *organizationService) GetStudentResults(userID, orgID, groupID, testID string) ([]model.TestResult, error) {
+	access, err := s.teacherAccess(userID, "", orgID, false)
+	if err != nil {
+		return nil, err
+	}
+	var groupFilter, testFilter *uuid.UUID
+	if groupID != "" {
+		group, err := s.ownGroup(access, groupID)
+		if err != nil {
+			return nil, err
+		}
+		groupFilter = &group.ID
+	}
+	if testID != "" {
+		testUUID, err := uuid.Parse(testID)
+		if err != nil {
+			return nil, errors.New("invalid test id format")
+		}
+		testFilter = &testUUID
+	}
+	return s.orgRepo.FindStudentResults(access.orgID, access.userID, groupFilter, testFilter)
+}
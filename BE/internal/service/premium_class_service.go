@@ -27,6 +27,7 @@ type CreateClassRequest struct {
 	ImageURL    string  `json:"image_url" validate:"url"`
 	Category    string  `json:"category"`
 	Duration    string  `json:"duration"` // <-- DITAMBAH
+	FinalTestID string  `json:"final_test_id" validate:"omitempty,uuid"`
 }
 
 type UpdateClassRequest struct {
@@ -37,6 +38,7 @@ type UpdateClassRequest struct {
 	ImageURL    string  `json:"image_url" validate:"url"`
 	Category    string  `json:"category"`
 	Duration    string  `json:"duration"` // <-- DITAMBAH
+	FinalTestID string  `json:"final_test_id" validate:"omitempty,uuid"`
 }
 
 
@@ -54,6 +56,7 @@ func (s *premiumClassService) CreateClass(request *CreateClassRequest) (*model.P
 		ImageURL:    request.ImageURL,
 		Category:    request.Category,
 		Duration:    request.Duration, // <-- DITAMBAH
+		FinalTestID: optionalUUID(request.FinalTestID),
 	}
 
 	err := s.repo.Create(class)
@@ -93,6 +96,7 @@ func (s *premiumClassService) UpdateClass(id string, request *UpdateClassRequest
 	existingClass.ImageURL = request.ImageURL
 	existingClass.Category = request.Category
 	existingClass.Duration = request.Duration // <-- DITAMBAH
+	existingClass.FinalTestID = optionalUUID(request.FinalTestID)
 
 	err = s.repo.Update(existingClass)
 	if err != nil {
@@ -107,4 +111,16 @@ func (s *premiumClassService) DeleteClass(id string) error {
 		return errors.New("invalid id format")
 	}
 	return s.repo.Delete(classUUID)
-}
\ No newline at end of file
+}
+
+// optionalUUID parses an already validated, possibly empty UUID field.
+func optionalUUID(value string) *uuid.UUID {
+	if value == "" {
+		return nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+)
+
+// ChecklistItem is one publish check. Problems lists the offending questions or
+// sections so the author can find them.
+type ChecklistItem struct {
+	Check    string   `json:"check"`
+	Passed   bool     `json:"passed"`
+	Message  string   `json:"message"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// publishChecklist checks that a test can be taken: it has questions, every multiple
+// choice question has usable options and an answer key that points at one of them,
+// essays can be scored, every section has questions and the window is valid.
+func publishChecklist(test *model.Test, questions []model.Question) []ChecklistItem {
+	questions = scoredQuestions(test, questions)
+
+	hasQuestions := ChecklistItem{Check: "has_questions", Passed: len(questions) > 0, Message: "The test has at least one question"}
+	if !hasQuestions.Passed {
+		hasQuestions.Message = "The test has no questions"
+	}
+
+	options := ChecklistItem{Check: "valid_options", Message: "Every multiple choice question has at least two options and a valid answer key"}
+	essays := ChecklistItem{Check: "essay_scoring", Message: "Every essay question has a maximum score"}
+	for i, q := range questions {
+		label := fmt.Sprintf("question %d", i+1)
+		if q.IsEssay() {
+			if q.MaxScore <= 0 {
+				essays.Problems = append(essays.Problems, label+": max_score must be greater than zero")
+			}
+			continue
+		}
+		if problem := optionProblem(q); problem != "" {
+			options.Problems = append(options.Problems, label+": "+problem)
+		}
+	}
+	options.Passed = len(options.Problems) == 0
+	essays.Passed = len(essays.Problems) == 0
+
+	sections := ChecklistItem{Check: "sections_have_questions", Message: "Every section has at least one question"}
+	counts := make(map[string]int)
+	for _, q := range questions {
+		if q.SectionID != nil {
+			counts[q.SectionID.String()]++
+		}
+	}
+	for _, section := range test.Sections {
+		if counts[section.ID.String()] == 0 {
+			sections.Problems = append(sections.Problems, fmt.Sprintf("section %q has no questions", section.Title))
+		}
+	}
+	sections.Passed = len(sections.Problems) == 0
+
+	window := ChecklistItem{Check: "availability_window", Passed: checkWindow(test) == nil, Message: "The availability window is valid"}
+	if !window.Passed {
+		window.Message = "available_until must be after available_from"
+	}
+
+	return []ChecklistItem{hasQuestions, options, essays, sections, window}
+}
+
+func optionProblem(q model.Question) string {
+	var options []string
+	if err := json.Unmarshal([]byte(q.Options), &options); err != nil {
+		return "options are not a JSON array of strings"
+	}
+	if len(options) < 2 {
+		return "needs at least two options"
+	}
+	for i, option := range options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Sprintf("option %d is empty", i+1)
+		}
+	}
+	if q.CorrectAnswer < 0 || q.CorrectAnswer >= len(options) {
+		return "correct_answer does not match any option"
+	}
+	return ""
+}
+
+func checklistPassed(checklist []ChecklistItem) bool {
+	for _, item := range checklist {
+		if !item.Passed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/questionimport"
+	"github.com/google/uuid"
+)
+
+// ImportReport is returned by both the dry run and the real import. Questions are the
+// rows that passed validation, in file order.
+type ImportReport struct {
+	Format    string                    `json:"format"`
+	DryRun    bool                      `json:"dry_run"`
+	Valid     int                       `json:"valid"`
+	Imported  int                       `json:"imported"`
+	Errors    []questionimport.RowError `json:"errors"`
+	Questions []model.Question          `json:"questions"`
+}
+
+// ImportQuestions validates every row of an import file. Nothing is written on a dry
+// run or when any row has an error; otherwise all questions are inserted in one
+// transaction.
+func (s *questionService) ImportQuestions(testID, format string, file io.Reader, dryRun bool) (*ImportReport, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	if _, err := s.testRepo.FindByID(testUUID); err != nil {
+		return nil, errors.New("test not found")
+	}
+	sections, err := s.sectionRepo.FindByTestID(testUUID)
+	if err != nil {
+		return nil, err
+	}
+	sectionsByTitle := make(map[string]uuid.UUID, len(sections))
+	for _, section := range sections {
+		sectionsByTitle[strings.ToLower(section.Title)] = section.ID
+	}
+
+	// Imported questions go after the ones already on the paper.
+	existing, err := s.repo.FindByTestID(testUUID)
+	if err != nil {
+		return nil, errors.New("could not retrieve questions for the test")
+	}
+	nextPosition := 0
+	for _, q := range existing {
+		if q.Position >= nextPosition {
+			nextPosition = q.Position + 1
+		}
+	}
+
+	items, rowErrors, err := questionimport.Parse(format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{Format: format, DryRun: dryRun, Errors: rowErrors, Questions: []model.Question{}}
+	for i, item := range items {
+		optionsJSON, _ := json.Marshal(item.Options)
+		question := model.Question{
+			ID:            uuid.New(),
+			TestID:        testUUID,
+			Position:      nextPosition + i,
+			Type:          item.Type,
+			QuestionText:  item.QuestionText,
+			Options:       string(optionsJSON),
+			CorrectAnswer: item.CorrectAnswer,
+			Explanation:   item.Explanation,
+			MaxScore:      item.MaxScore,
+			Points:        item.Points,
+			Penalty:       item.Penalty,
+			Tags:          item.Tags,
+			Difficulty:    item.Difficulty,
+			Topic:         item.Topic,
+		}
+		if item.Section != "" {
+			sectionID, ok := sectionsByTitle[strings.ToLower(item.Section)]
+			if !ok {
+				report.Errors = append(report.Errors, questionimport.RowError{Row: item.Row, Field: "section", Message: "no section with this title in the test"})
+				continue
+			}
+			question.SectionID = &sectionID
+		}
+		if err := normalizeQuestion(&question); err != nil {
+			report.Errors = append(report.Errors, questionimport.RowError{Row: item.Row, Message: err.Error()})
+			continue
+		}
+		report.Questions = append(report.Questions, question)
+	}
+	report.Valid = len(report.Questions)
+
+	if dryRun || len(report.Errors) > 0 || len(report.Questions) == 0 {
+		return report, nil
+	}
+	if err := s.repo.CreateBatch(report.Questions); err != nil {
+		return nil, err
+	}
+	report.Imported = len(report.Questions)
+	return report, nil
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"errors" // <-- PASTIKAN "errors" DI-IMPORT
+	"io"
+	"time"
+
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
 	"github.com/google/uuid"
@@ -10,20 +13,31 @@ import (
 type QuestionService interface {
 	CreateQuestion(question *model.Question) error
 	GetQuestionsByTestID(testID string) ([]model.Question, error)
+	GetPublicQuestions(testID string) ([]AttemptQuestion, error)
 	GetQuestionByID(id string) (*model.Question, error) // <-- TAMBAHKAN BARIS INI
 	UpdateQuestion(id string, questionData *model.Question) (*model.Question, error)
 	DeleteQuestion(id string) error
+	ImportQuestions(testID, format string, file io.Reader, dryRun bool) (*ImportReport, error)
 }
 
 type questionService struct {
-	repo repository.QuestionRepository
+	repo        repository.QuestionRepository
+	testRepo    repository.TestRepository
+	sectionRepo repository.TestSectionRepository
+	eventRepo   repository.EventRepository
 }
 
-func NewQuestionService(repo repository.QuestionRepository) QuestionService {
-	return &questionService{repo}
+func NewQuestionService(repo repository.QuestionRepository, testRepo repository.TestRepository, sectionRepo repository.TestSectionRepository, eventRepo repository.EventRepository) QuestionService {
+	return &questionService{repo, testRepo, sectionRepo, eventRepo}
 }
 
 func (s *questionService) CreateQuestion(question *model.Question) error {
+	if err := normalizeQuestion(question); err != nil {
+		return err
+	}
+	if err := s.checkSection(question); err != nil {
+		return err
+	}
 	question.ID = uuid.New()
 	return s.repo.Create(question)
 }
@@ -36,6 +50,32 @@ func (s *questionService) GetQuestionsByTestID(testID string) ([]model.Question,
 	return s.repo.FindByTestID(testUUID)
 }
 
+// GetPublicQuestions is the paper of a plain test without its keys, for students
+// who take it in one go. Tests that are not available, and tests only served
+// through an attempt, do not list their questions.
+func (s *questionService) GetPublicQuestions(testID string) ([]AttemptQuestion, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	test, err := s.testRepo.FindByID(testUUID)
+	if err != nil || !test.IsAvailable(time.Now()) {
+		return nil, errors.New("test not found")
+	}
+	only, err := attemptOnly(test, s.eventRepo)
+	if err != nil {
+		return nil, err
+	}
+	if only {
+		return nil, errors.New("test must be taken through an attempt")
+	}
+	questions, err := s.repo.FindByTestID(testUUID)
+	if err != nil {
+		return nil, err
+	}
+	return publicQuestions(questions), nil
+}
+
 // --- TAMBAHKAN FUNGSI BARU INI ---
 func (s *questionService) GetQuestionByID(id string) (*model.Question, error) {
 	questionUUID, err := uuid.Parse(id)
@@ -61,6 +101,23 @@ func (s *questionService) UpdateQuestion(id string, questionData *model.Question
 	existingQuestion.Options = questionData.Options
 	existingQuestion.CorrectAnswer = questionData.CorrectAnswer
 	existingQuestion.Explanation = questionData.Explanation
+	existingQuestion.Type = questionData.Type
+	existingQuestion.Rubric = questionData.Rubric
+	existingQuestion.MaxScore = questionData.MaxScore
+	existingQuestion.Points = questionData.Points
+	existingQuestion.Penalty = questionData.Penalty
+	existingQuestion.SectionID = questionData.SectionID
+	existingQuestion.Position = questionData.Position
+	existingQuestion.Tags = questionData.Tags
+	existingQuestion.Difficulty = questionData.Difficulty
+	existingQuestion.Topic = questionData.Topic
+
+	if err := normalizeQuestion(existingQuestion); err != nil {
+		return nil, err
+	}
+	if err := s.checkSection(existingQuestion); err != nil {
+		return nil, err
+	}
 
 	err = s.repo.Update(existingQuestion)
 	return existingQuestion, err
@@ -72,4 +129,69 @@ func (s *questionService) DeleteQuestion(id string) error {
 		return err
 	}
 	return s.repo.Delete(questionUUID)
-}
\ No newline at end of file
+}
+
+// checkSection makes sure a question is only placed in a section of its own test.
+func (s *questionService) checkSection(question *model.Question) error {
+	if question.SectionID == nil {
+		return nil
+	}
+	section, err := s.sectionRepo.FindByID(*question.SectionID)
+	if err != nil {
+		return errors.New("section not found")
+	}
+	if section.TestID != question.TestID {
+		return errors.New("section belongs to a different test")
+	}
+	return nil
+}
+
+// normalizeQuestion applies the per-type rules: multiple choice needs options, an essay
+// needs a maximum score, which defaults to the sum of its rubric criteria. Points
+// default to one so tests switched to weighted scoring keep their old behaviour.
+func normalizeQuestion(question *model.Question) error {
+	if question.Type == "" {
+		question.Type = model.QuestionTypeMultipleChoice
+	}
+	if question.Points <= 0 {
+		question.Points = 1
+	}
+	if question.Penalty < 0 {
+		return errors.New("penalty must not be negative")
+	}
+
+	switch question.Type {
+	case model.QuestionTypeMultipleChoice:
+		if question.Options == "" {
+			return errors.New("options are required for multiple choice questions")
+		}
+		question.Rubric = ""
+		question.MaxScore = 0
+	case model.QuestionTypeEssay:
+		criteria, err := parseRubric(question.Rubric)
+		if err != nil {
+			return errors.New("rubric must be a JSON array of criteria")
+		}
+		if len(criteria) > 0 {
+			total := 0.0
+			for _, criterion := range criteria {
+				if criterion.MaxScore <= 0 {
+					return errors.New("every rubric criterion needs a positive max_score")
+				}
+				total += criterion.MaxScore
+			}
+			question.MaxScore = total
+		}
+		if question.MaxScore <= 0 {
+			return errors.New("essay questions need a max_score or a rubric")
+		}
+		if question.Options == "" {
+			question.Options = "[]"
+		}
+		question.CorrectAnswer = 0
+		question.Penalty = 0
+	default:
+		return errors.New("unknown question type")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+// arrangePaper draws, orders and shuffles the questions of every block according to
+// the test settings. The same seed and pool always give the same layout.
+func arrangePaper(test *model.Test, paper []paperSection, seed int64) []model.AttemptLayoutItem {
+	rng := rand.New(rand.NewSource(seed))
+
+	var layout []model.AttemptLayoutItem
+	for _, block := range paper {
+		pool := make([]model.Question, 0, len(block.questions))
+		for _, q := range block.questions {
+			if test.DrawTag != "" && !q.HasTag(test.DrawTag) {
+				continue
+			}
+			if test.DrawDifficulty != "" && q.Difficulty != test.DrawDifficulty {
+				continue
+			}
+			pool = append(pool, q)
+		}
+
+		picked := pool
+		if test.DrawCount > 0 && test.DrawCount < len(pool) {
+			// Keep the authored order of the drawn questions unless the test
+			// also asks for shuffling.
+			indexes := rng.Perm(len(pool))[:test.DrawCount]
+			chosen := make(map[int]bool, len(indexes))
+			for _, i := range indexes {
+				chosen[i] = true
+			}
+			picked = make([]model.Question, 0, test.DrawCount)
+			for i, q := range pool {
+				if chosen[i] {
+					picked = append(picked, q)
+				}
+			}
+		}
+
+		if test.ShuffleQuestions {
+			rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+		}
+
+		for _, q := range picked {
+			item := model.AttemptLayoutItem{QuestionID: q.ID}
+			if test.ShuffleOptions && !q.IsEssay() {
+				if count := optionCount(q.Options); count > 1 {
+					item.OptionOrder = rng.Perm(count)
+				}
+			}
+			layout = append(layout, item)
+		}
+	}
+	return layout
+}
+
+// applyLayout narrows and reorders the blocks of a freshly built paper to the stored
+// layout. An empty layout, e.g. a draw that matched nothing, leaves no questions.
+func applyLayout(paper []paperSection, layout []model.AttemptLayoutItem) []paperSection {
+	position := make(map[uuid.UUID]int, len(layout))
+	for i, item := range layout {
+		position[item.QuestionID] = i
+	}
+
+	arranged := make([]paperSection, len(paper))
+	for b, block := range paper {
+		arranged[b] = paperSection{section: block.section, duration: block.duration, optionOrders: map[uuid.UUID][]int{}}
+		ordered := make([]model.Question, 0, len(block.questions))
+		for _, q := range block.questions {
+			if _, served := position[q.ID]; served {
+				ordered = append(ordered, q)
+			}
+		}
+		sort.SliceStable(ordered, func(i, j int) bool { return position[ordered[i].ID] < position[ordered[j].ID] })
+		for _, q := range ordered {
+			if order := layout[position[q.ID]].OptionOrder; len(order) > 0 {
+				arranged[b].optionOrders[q.ID] = order
+			}
+		}
+		arranged[b].questions = ordered
+	}
+	return arranged
+}
+
+// originalOption maps an option index as displayed back to the authored index.
+func originalOption(order []int, displayed int) int {
+	if displayed < 0 || displayed >= len(order) {
+		return displayed
+	}
+	return order[displayed]
+}
+
+// displayedOption maps an authored option index to where it is shown.
+func displayedOption(order []int, authored int) int {
+	for displayed, original := range order {
+		if original == authored {
+			return displayed
+		}
+	}
+	return authored
+}
+
+// shuffledOptions re-encodes the option list in display order.
+func shuffledOptions(options string, order []int) string {
+	if len(order) == 0 {
+		return options
+	}
+	var authored []json.RawMessage
+	if err := json.Unmarshal([]byte(options), &authored); err != nil || len(authored) != len(order) {
+		return options
+	}
+	displayed := make([]json.RawMessage, len(order))
+	for i, original := range order {
+		displayed[i] = authored[original]
+	}
+	encoded, err := json.Marshal(displayed)
+	if err != nil {
+		return options
+	}
+	return string(encoded)
+}
+
+func optionCount(options string) int {
+	var list []json.RawMessage
+	if err := json.Unmarshal([]byte(options), &list); err != nil {
+		return 0
+	}
+	return len(list)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+func randomizationPaper() []paperSection {
+	questions := make([]model.Question, 8)
+	for i := range questions {
+		questions[i] = model.Question{
+			ID:       uuid.New(),
+			Position: i,
+			Options:  `["a","b","c","d"]`,
+			Tags:     "algebra",
+		}
+		if i%2 == 1 {
+			questions[i].Tags = "geometry, Algebra"
+			questions[i].Difficulty = "hard"
+		}
+	}
+	return []paperSection{{questions: questions}}
+}
+
+func layoutIDs(layout []model.AttemptLayoutItem) []uuid.UUID {
+	ids := make([]uuid.UUID, len(layout))
+	for i, item := range layout {
+		ids[i] = item.QuestionID
+	}
+	return ids
+}
+
+func TestArrangePaper(t *testing.T) {
+	paper := randomizationPaper()
+	authored := make(map[uuid.UUID]int)
+	for i, q := range paper[0].questions {
+		authored[q.ID] = i
+	}
+
+	tests := []struct {
+		name         string
+		test         model.Test
+		wantCount    int
+		wantAuthored bool
+		wantOptions  bool
+	}{
+		{"no settings keeps the paper", model.Test{}, 8, true, false},
+		{"draw keeps authored order", model.Test{DrawCount: 3}, 3, true, false},
+		{"draw filters by tag", model.Test{DrawTag: "geometry"}, 4, true, false},
+		{"draw filters by tag and difficulty", model.Test{DrawTag: "ALGEBRA", DrawDifficulty: "hard", DrawCount: 2}, 2, true, false},
+		{"draw count above the pool takes the pool", model.Test{DrawTag: "geometry", DrawCount: 10}, 4, true, false},
+		{"shuffled questions", model.Test{ShuffleQuestions: true}, 8, false, false},
+		{"shuffled options", model.Test{ShuffleOptions: true}, 8, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := arrangePaper(&tt.test, paper, 42)
+			if len(layout) != tt.wantCount {
+				t.Fatalf("layout has %d items, want %d", len(layout), tt.wantCount)
+			}
+			if again := arrangePaper(&tt.test, paper, 42); !reflect.DeepEqual(layout, again) {
+				t.Errorf("same seed gave a different layout")
+			}
+
+			positions := make([]int, len(layout))
+			for i, item := range layout {
+				position, ok := authored[item.QuestionID]
+				if !ok {
+					t.Fatalf("layout serves unknown question %s", item.QuestionID)
+				}
+				positions[i] = position
+				if tt.test.DrawTag != "" && !paper[0].questions[position].HasTag(tt.test.DrawTag) {
+					t.Errorf("question %d does not carry tag %q", position, tt.test.DrawTag)
+				}
+				if tt.wantOptions {
+					order := append([]int(nil), item.OptionOrder...)
+					sort.Ints(order)
+					if !reflect.DeepEqual(order, []int{0, 1, 2, 3}) {
+						t.Errorf("option order %v is not a permutation of 4 options", item.OptionOrder)
+					}
+				} else if item.OptionOrder != nil {
+					t.Errorf("option order %v set without ShuffleOptions", item.OptionOrder)
+				}
+			}
+			if sorted := sort.IntsAreSorted(positions); tt.wantAuthored && !sorted {
+				t.Errorf("positions %v are not in authored order", positions)
+			}
+		})
+	}
+}
+
+func TestArrangePaperSeedChangesShuffle(t *testing.T) {
+	paper := randomizationPaper()
+	test := &model.Test{ShuffleQuestions: true}
+	first := layoutIDs(arrangePaper(test, paper, 1))
+	for seed := int64(2); seed < 10; seed++ {
+		if !reflect.DeepEqual(first, layoutIDs(arrangePaper(test, paper, seed))) {
+			return
+		}
+	}
+	t.Errorf("eight different seeds all gave the same order")
+}
+
+func TestApplyLayout(t *testing.T) {
+	paper := randomizationPaper()
+	questions := paper[0].questions
+	layout := []model.AttemptLayoutItem{
+		{QuestionID: questions[5].ID, OptionOrder: []int{2, 0, 3, 1}},
+		{QuestionID: questions[1].ID},
+		{QuestionID: questions[6].ID, OptionOrder: []int{3, 2, 1, 0}},
+	}
+
+	arranged := applyLayout(paper, layout)
+	if got, want := layoutIDs(layoutOf(arranged)), layoutIDs(layout); !reflect.DeepEqual(got, want) {
+		t.Fatalf("arranged questions = %v, want %v", got, want)
+	}
+	if order := arranged[0].optionOrders[questions[5].ID]; !reflect.DeepEqual(order, []int{2, 0, 3, 1}) {
+		t.Errorf("option order = %v, want [2 0 3 1]", order)
+	}
+	if _, ok := arranged[0].optionOrders[questions[1].ID]; ok {
+		t.Errorf("unshuffled question has an option order")
+	}
+
+	answer := arranged[0].toOriginal(UserAnswer{QuestionID: questions[5].ID.String(), SelectedAnswer: 0})
+	if answer.SelectedAnswer != 2 {
+		t.Errorf("displayed option 0 mapped to %d, want 2", answer.SelectedAnswer)
+	}
+
+	for b, block := range applyLayout(paper, nil) {
+		if len(block.questions) != 0 {
+			t.Errorf("empty layout left %d questions in block %d", len(block.questions), b)
+		}
+	}
+}
+
+// layoutOf lists the questions of arranged blocks as layout items.
+func layoutOf(paper []paperSection) []model.AttemptLayoutItem {
+	var items []model.AttemptLayoutItem
+	for _, q := range servedQuestions(paper) {
+		items = append(items, model.AttemptLayoutItem{QuestionID: q.ID})
+	}
+	return items
+}
+
+func TestOptionMapping(t *testing.T) {
+	order := []int{2, 0, 3, 1}
+	tests := []struct {
+		displayed, authored int
+	}{
+		{0, 2},
+		{1, 0},
+		{2, 3},
+		{3, 1},
+	}
+	for _, tt := range tests {
+		if got := originalOption(order, tt.displayed); got != tt.authored {
+			t.Errorf("originalOption(%d) = %d, want %d", tt.displayed, got, tt.authored)
+		}
+		if got := displayedOption(order, tt.authored); got != tt.displayed {
+			t.Errorf("displayedOption(%d) = %d, want %d", tt.authored, got, tt.displayed)
+		}
+	}
+
+	// Blank answers and indexes outside the order pass through unchanged.
+	for _, index := range []int{-1, 4} {
+		if got := originalOption(order, index); got != index {
+			t.Errorf("originalOption(%d) = %d, want it unchanged", index, got)
+		}
+		if got := displayedOption(order, index); got != index {
+			t.Errorf("displayedOption(%d) = %d, want it unchanged", index, got)
+		}
+	}
+}
+
+func TestShuffledOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		order   []int
+		want    string
+	}{
+		{"no order", `["a","b","c"]`, nil, `["a","b","c"]`},
+		{"reordered", `["a","b","c"]`, []int{2, 0, 1}, `["c","a","b"]`},
+		{"objects keep their content", `[{"text":"x"},{"text":"y"}]`, []int{1, 0}, `[{"text":"y"},{"text":"x"}]`},
+		{"length mismatch is left alone", `["a","b","c"]`, []int{1, 0}, `["a","b","c"]`},
+		{"invalid json is left alone", `not json`, []int{1, 0}, `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shuffledOptions(tt.options, tt.order); got != tt.want {
+				t.Errorf("shuffledOptions = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+// rankingTimeSlots is how many distinct TimeSpent values (in seconds) a ranking score
+// can tell apart. Anything slower ties with the slowest slot.
+const rankingTimeSlots = 1000000
+
+// rankingScore packs a result into a single sorted-set score so that ZREVRANGE orders
+// by Score (to two decimals) and then by the shorter TimeSpent. Only attempts are
+// timed by the server; results submitted in one go report their own TimeSpent, so
+// they tie with the slowest slot instead of breaking ties.
+func rankingScore(result *model.TestResult) float64 {
+	timeSpent := result.TimeSpent
+	if result.AttemptID == nil {
+		timeSpent = rankingTimeSlots - 1
+	}
+	if timeSpent < 0 {
+		timeSpent = 0
+	}
+	if timeSpent > rankingTimeSlots-1 {
+		timeSpent = rankingTimeSlots - 1
+	}
+	return math.Round(result.Score*100)*rankingTimeSlots + float64(rankingTimeSlots-1-timeSpent)
+}
+
+type RankingEntry struct {
+	Rank        int64     `json:"rank"`
+	ResultID    uuid.UUID `json:"result_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Score       float64   `json:"score"`
+	TimeSpent   int       `json:"time_spent"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type RankingPage struct {
+	Total   int64          `json:"total"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+	Entries []RankingEntry `json:"entries"`
+}
+
+// officialRankingScore is rankingScore with the result's official score in place of
+// its own, for rankings that follow Test.OfficialResult.
+func officialRankingScore(result *model.TestResult) float64 {
+	if result.OfficialScore == nil {
+		return rankingScore(result)
+	}
+	counted := *result
+	counted.Score = *result.OfficialScore
+	return rankingScore(&counted)
+}
+
+// rankingEntries loads the results behind one page of a ranking. resultIDs are in
+// rank order and the first of them has rank firstRank; results that no longer exist
+// are skipped without renumbering the rest. With official set, entries show the
+// official score instead of the result's own.
+func rankingEntries(resultRepo repository.TestResultRepository, resultIDs []uuid.UUID, firstRank int64, official bool) ([]RankingEntry, error) {
+	results, err := resultRepo.FindByIDs(resultIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*model.TestResult, len(results))
+	for i := range results {
+		byID[results[i].ID] = &results[i]
+	}
+
+	entries := make([]RankingEntry, 0, len(resultIDs))
+	for i, id := range resultIDs {
+		result, ok := byID[id]
+		if !ok {
+			continue
+		}
+		score := result.Score
+		if official && result.OfficialScore != nil {
+			score = *result.OfficialScore
+		}
+		entries = append(entries, RankingEntry{
+			Rank:        firstRank + int64(i),
+			ResultID:    result.ID,
+			UserID:      result.UserID,
+			Name:        result.User.Name,
+			Score:       score,
+			TimeSpent:   result.TimeSpent,
+			CompletedAt: result.CompletedAt,
+		})
+	}
+	return entries, nil
+}
+
+// pageBounds turns a 1-based page into ZRANGE start and stop indexes.
+func pageBounds(page, limit int) (int64, int64) {
+	start := int64(page-1) * int64(limit)
+	return start, start + int64(limit) - 1
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/pdf"
+)
+
+var (
+	brandColor = pdf.Color{R: 37, G: 99, B: 235}
+	inkColor   = pdf.Color{R: 31, G: 41, B: 55}
+	mutedColor = pdf.Color{R: 107, G: 114, B: 128}
+	lineColor  = pdf.Color{R: 229, G: 231, B: 235}
+	passColor  = pdf.Color{R: 22, G: 163, B: 74}
+	failColor  = pdf.Color{R: 220, G: 38, B: 38}
+	white      = pdf.Color{R: 255, G: 255, B: 255}
+)
+
+const reportMargin = 48.0
+
+type ReportService interface {
+	RenderResultReport(userID, userRole, resultID string) ([]byte, *model.TestResult, error)
+}
+
+type reportService struct {
+	testResultService  TestResultService
+	leaderboardService LeaderboardService
+	userRepo           repository.UserRepository
+}
+
+func NewReportService(testResultService TestResultService, leaderboardService LeaderboardService, userRepo repository.UserRepository) ReportService {
+	return &reportService{testResultService, leaderboardService, userRepo}
+}
+
+// topicScore is a student's share of the points on one topic of a result.
+type topicScore struct {
+	topic            string
+	earned, possible float64
+}
+
+// RenderResultReport draws a printable score report: the score, the section
+// breakdown, the student's place on the leaderboard and a bar per topic. Only the
+// student who took the result and admins get one; nothing is loaded for anyone else.
+func (s *reportService) RenderResultReport(userID, userRole, resultID string) ([]byte, *model.TestResult, error) {
+	review, err := s.testResultService.GetResultReviewFor(userID, userRole, resultID)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := review.Result
+	ranked := []model.TestResult{*result}
+	s.leaderboardService.AttachRanks(ranked)
+	result = &ranked[0]
+	student, err := s.userRepo.FindUserByID(result.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+
+	doc := pdf.New(pdf.A4, "Score report - "+result.Test.Title)
+	page := doc.AddPage()
+	width := doc.Size().Width
+
+	page.SetFillColor(brandColor)
+	page.Rect(0, 0, width, 80, true, false)
+	page.SetFillColor(white)
+	page.SetFont(pdf.HelveticaBold, 24)
+	page.Text(reportMargin, 48, pdf.AlignLeft, "EduTest+")
+	page.SetFont(pdf.Helvetica, 12)
+	page.Text(width-reportMargin, 48, pdf.AlignRight, "Score Report")
+
+	y := 120.0
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 18)
+	for _, line := range page.WrapText(result.Test.Title, width-2*reportMargin) {
+		page.Text(reportMargin, y, pdf.AlignLeft, line)
+		y += 22
+	}
+	page.SetFont(pdf.Helvetica, 11)
+	page.SetFillColor(mutedColor)
+	page.Text(reportMargin, y, pdf.AlignLeft, fmt.Sprintf("%s  (%s)", student.Name, student.Email))
+	y += 16
+	page.Text(reportMargin, y, pdf.AlignLeft, "Completed "+result.CompletedAt.Format("2 January 2006 15:04")+"  -  Result "+result.ID.String())
+	y += 30
+
+	y = drawScoreSummary(page, result, y, width)
+	if len(result.SectionScores) > 0 {
+		y = drawSectionTable(page, result.SectionScores, y+28, width)
+	}
+
+	topics := topicScores(review.Questions)
+	if len(topics) > 0 {
+		if y+60+float64(len(topics))*22 > doc.Size().Height-70 {
+			drawReportFooter(page, doc.Size())
+			page = doc.AddPage()
+			y = reportMargin
+		}
+		drawTopicChart(page, topics, y+28, width)
+	}
+	drawReportFooter(page, doc.Size())
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, result, nil
+}
+
+func drawScoreSummary(page *pdf.Page, result *model.TestResult, y, width float64) float64 {
+	boxHeight := 110.0
+	page.SetStrokeColor(lineColor)
+	page.SetLineWidth(1)
+	page.Rect(reportMargin, y, width-2*reportMargin, boxHeight, false, true)
+
+	left := reportMargin + 20
+	page.SetFillColor(mutedColor)
+	page.SetFont(pdf.Helvetica, 10)
+	page.Text(left, y+24, pdf.AlignLeft, "SCORE")
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 36)
+	page.Text(left, y+66, pdf.AlignLeft, fmt.Sprintf("%.2f", result.Score))
+	if result.Passed != nil {
+		label, color := "NOT PASSED", failColor
+		if *result.Passed {
+			label, color = "PASSED", passColor
+		}
+		page.SetFillColor(color)
+		page.SetFont(pdf.HelveticaBold, 12)
+		page.Text(left, y+90, pdf.AlignLeft, label)
+	}
+	if result.Status != model.ResultStatusCompleted {
+		page.SetFillColor(failColor)
+		page.SetFont(pdf.HelveticaBold, 10)
+		page.Text(left+110, y+90, pdf.AlignLeft, statusLabel(result.Status))
+	}
+
+	facts := [][2]string{
+		{"Correct / wrong / blank", fmt.Sprintf("%d / %d / %d of %d", result.CorrectAnswers, result.WrongAnswers, result.BlankAnswers, result.TotalQuestions)},
+		{"Points", fmt.Sprintf("%.2f of %.2f", result.RawPoints, result.MaxPoints)},
+		{"Time spent", (time.Duration(result.TimeSpent) * time.Second).String()},
+	}
+	if result.Rank != nil && result.Percentile != nil {
+		facts = append(facts, [2]string{"Rank", fmt.Sprintf("#%d of %d (percentile %.1f)", *result.Rank, result.RankedCount, *result.Percentile)})
+	}
+	if result.ScaledScore != nil {
+		facts = append(facts, [2]string{"Scaled score", fmt.Sprintf("%.0f", *result.ScaledScore)})
+	}
+	factX := width/2 - 20
+	for i, fact := range facts {
+		factY := y + 24 + float64(i)*19
+		page.SetFont(pdf.Helvetica, 10)
+		page.SetFillColor(mutedColor)
+		page.Text(factX, factY, pdf.AlignLeft, fact[0])
+		page.SetFont(pdf.HelveticaBold, 10)
+		page.SetFillColor(inkColor)
+		page.Text(width-reportMargin-20, factY, pdf.AlignRight, fact[1])
+	}
+	return y + boxHeight
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case model.ResultStatusPendingReview:
+		return "PROVISIONAL - ESSAYS AWAITING REVIEW"
+	case model.ResultStatusInvalidated:
+		return "INVALIDATED"
+	}
+	return ""
+}
+
+func drawSectionTable(page *pdf.Page, sections []model.SectionScore, y, width float64) float64 {
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 13)
+	page.Text(reportMargin, y, pdf.AlignLeft, "Sections")
+	y += 18
+
+	columns := []struct {
+		title string
+		x     float64
+		align pdf.Align
+	}{
+		{"Section", reportMargin + 8, pdf.AlignLeft},
+		{"Correct", width - reportMargin - 250, pdf.AlignRight},
+		{"Wrong", width - reportMargin - 195, pdf.AlignRight},
+		{"Blank", width - reportMargin - 140, pdf.AlignRight},
+		{"Score", width - reportMargin - 80, pdf.AlignRight},
+		{"Status", width - reportMargin - 8, pdf.AlignRight},
+	}
+	page.SetFillColor(lineColor)
+	page.Rect(reportMargin, y, width-2*reportMargin, 20, true, false)
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 9)
+	for _, column := range columns {
+		page.Text(column.x, y+14, column.align, column.title)
+	}
+	y += 20
+
+	page.SetFont(pdf.Helvetica, 10)
+	page.SetStrokeColor(lineColor)
+	for _, section := range sections {
+		status := "-"
+		if section.Passed != nil {
+			status = "Not passed"
+			if *section.Passed {
+				status = "Passed"
+			}
+		}
+		title := fitText(page, section.Title, columns[1].x-columns[0].x-50)
+		values := []string{title, fmt.Sprint(section.CorrectAnswers), fmt.Sprint(section.WrongAnswers), fmt.Sprint(section.BlankAnswers), fmt.Sprintf("%.2f", section.Score), status}
+		for i, column := range columns {
+			page.Text(column.x, y+14, column.align, values[i])
+		}
+		y += 20
+		page.Line(reportMargin, y, width-reportMargin, y)
+	}
+	return y
+}
+
+// topicScores totals the scored questions of a review per topic, in name order.
+// Questions without a topic, deleted questions and ungraded essays are left out.
+func topicScores(questions []QuestionReview) []topicScore {
+	byTopic := make(map[string]*topicScore)
+	for _, q := range questions {
+		if q.Topic == "" || q.Missing || q.Outcome == EssayOutcomePending || q.Points <= 0 {
+			continue
+		}
+		score, ok := byTopic[q.Topic]
+		if !ok {
+			score = &topicScore{topic: q.Topic}
+			byTopic[q.Topic] = score
+		}
+		score.earned += q.PointsEarned
+		score.possible += q.Points
+	}
+	scores := make([]topicScore, 0, len(byTopic))
+	for _, score := range byTopic {
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].topic < scores[j].topic })
+	return scores
+}
+
+func drawTopicChart(page *pdf.Page, topics []topicScore, y, width float64) float64 {
+	page.SetFillColor(inkColor)
+	page.SetFont(pdf.HelveticaBold, 13)
+	page.Text(reportMargin, y, pdf.AlignLeft, "Score by topic")
+	y += 16
+
+	labelWidth := 150.0
+	barX := reportMargin + labelWidth
+	barWidth := width - 2*reportMargin - labelWidth - 50
+	for _, topic := range topics {
+		percent := 0.0
+		if topic.possible > 0 {
+			percent = topic.earned / topic.possible * 100
+		}
+		if percent < 0 {
+			percent = 0
+		}
+		page.SetFont(pdf.Helvetica, 10)
+		label := fitText(page, topic.topic, labelWidth-10)
+		page.SetFillColor(inkColor)
+		page.Text(reportMargin, y+13, pdf.AlignLeft, label)
+
+		page.SetFillColor(lineColor)
+		page.Rect(barX, y+3, barWidth, 12, true, false)
+		page.SetFillColor(brandColor)
+		page.Rect(barX, y+3, barWidth*percent/100, 12, true, false)
+		page.SetFillColor(inkColor)
+		page.SetFont(pdf.HelveticaBold, 10)
+		page.Text(width-reportMargin, y+13, pdf.AlignRight, fmt.Sprintf("%.0f%%", percent))
+		y += 22
+	}
+	return y
+}
+
+// fitText shortens s with a trailing "..." until it fits width in the page's font.
+func fitText(page *pdf.Page, s string, width float64) string {
+	if page.TextWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && page.TextWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func drawReportFooter(page *pdf.Page, size pdf.Size) {
+	page.SetFillColor(mutedColor)
+	page.SetFont(pdf.Helvetica, 8)
+	page.Text(reportMargin, size.Height-30, pdf.AlignLeft, "Generated by EduTest+ on "+time.Now().Format("2 January 2006 15:04 MST"))
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// responseBlank marks a served question that was left unanswered.
+	responseBlank = -1
+	// responseNotServed marks a question the respondent never saw, e.g. because it
+	// was not drawn for their paper or was added after they took the test.
+	responseNotServed = -2
+)
+
+// testResponses are the multiple choice answers of every completed result on a
+// test. selected[r][q] is the authored option index that results[r] chose for
+// questions[q], or responseBlank / responseNotServed.
+type testResponses struct {
+	test      *model.Test
+	questions []model.Question
+	results   []model.TestResult
+	selected  [][]int
+}
+
+// responseLoader collects answers from stored results for the psychometric reports.
+type responseLoader struct {
+	testRepo     repository.TestRepository
+	questionRepo repository.QuestionRepository
+	resultRepo   repository.TestResultRepository
+	attemptRepo  repository.AttemptRepository
+}
+
+// load builds the response matrix of a test. Questions are the test's current multiple
+// choice questions in paper order, followed by earlier questions (such as replaced
+// bank versions) that still appear in answers. A result counts a question as served
+// if its attempt layout lists it, or, for submissions without an attempt, if the
+// question is on the test now.
+func (l *responseLoader) load(testID uuid.UUID) (*testResponses, error) {
+	test, err := l.testRepo.FindByID(testID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	current, err := l.questionRepo.FindByTestID(testID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := l.resultRepo.FindFinalByTestID(testID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attemptIDs []uuid.UUID
+	for _, result := range results {
+		if result.AttemptID != nil {
+			attemptIDs = append(attemptIDs, *result.AttemptID)
+		}
+	}
+	attempts, err := l.attemptRepo.FindByIDs(attemptIDs)
+	if err != nil {
+		return nil, err
+	}
+	layouts := make(map[uuid.UUID]map[uuid.UUID]bool, len(attempts))
+	for _, attempt := range attempts {
+		var layout []model.AttemptLayoutItem
+		if attempt.Layout == "" || json.Unmarshal([]byte(attempt.Layout), &layout) != nil {
+			continue
+		}
+		served := make(map[uuid.UUID]bool, len(layout))
+		for _, item := range layout {
+			served[item.QuestionID] = true
+		}
+		layouts[attempt.ID] = served
+	}
+
+	answers := make([]map[uuid.UUID]UserAnswer, len(results))
+	onTest := make(map[uuid.UUID]bool, len(current))
+	var order []uuid.UUID
+	for _, q := range servedQuestions(buildPaper(test, scoredQuestions(test, current))) {
+		onTest[q.ID] = true
+		order = append(order, q.ID)
+	}
+	known := make(map[uuid.UUID]bool, len(order))
+	for _, id := range order {
+		known[id] = true
+	}
+	for i, result := range results {
+		var stored []UserAnswer
+		if result.Answers != "" {
+			_ = json.Unmarshal([]byte(result.Answers), &stored)
+		}
+		answers[i] = make(map[uuid.UUID]UserAnswer, len(stored))
+		for _, answer := range stored {
+			questionUUID, err := uuid.Parse(answer.QuestionID)
+			if err != nil {
+				continue
+			}
+			answers[i][questionUUID] = answer
+			if !known[questionUUID] {
+				known[questionUUID] = true
+				order = append(order, questionUUID)
+			}
+		}
+	}
+
+	resolved, err := l.questionRepo.FindByIDs(testID, order)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]model.Question, len(resolved))
+	for _, q := range resolved {
+		byID[q.ID] = q
+	}
+
+	responses := &testResponses{test: test, results: results}
+	for _, id := range order {
+		if q, ok := byID[id]; ok && !q.IsEssay() {
+			responses.questions = append(responses.questions, q)
+		}
+	}
+
+	responses.selected = make([][]int, len(results))
+	for i, result := range results {
+		row := make([]int, len(responses.questions))
+		var served map[uuid.UUID]bool
+		if result.AttemptID != nil {
+			served = layouts[*result.AttemptID]
+		}
+		for j, q := range responses.questions {
+			answer, answered := answers[i][q.ID]
+			switch {
+			case answered && answer.SelectedAnswer >= 0:
+				row[j] = answer.SelectedAnswer
+			case served != nil && served[q.ID], served == nil && onTest[q.ID]:
+				row[j] = responseBlank
+			case answered:
+				row[j] = responseBlank
+			default:
+				row[j] = responseNotServed
+			}
+		}
+		responses.selected[i] = row
+	}
+	return responses, nil
+}
+
+// subset returns the responses of the results keep accepts, sharing the question list.
+func (r *testResponses) subset(keep func(result *model.TestResult) bool) *testResponses {
+	sub := &testResponses{test: r.test, questions: r.questions}
+	for i := range r.results {
+		if keep(&r.results[i]) {
+			sub.results = append(sub.results, r.results[i])
+			sub.selected = append(sub.selected, r.selected[i])
+		}
+	}
+	return sub
+}
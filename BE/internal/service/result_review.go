@@ -0,0 +1,246 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+// QuestionReview is one question of a result as the student answered it. Options are
+// in authored order and SelectedAnswer/CorrectAnswer index into them; OptionOrder is
+// the order the options were shown in when they were shuffled. Outcome is one of the
+// Answer* values, with "pending_review" and "graded" for essays.
+type QuestionReview struct {
+	Number         int        `json:"number"`
+	QuestionID     uuid.UUID  `json:"question_id"`
+	SectionID      *uuid.UUID `json:"section_id,omitempty"`
+	Type           string     `json:"type"`
+	Topic          string     `json:"topic,omitempty"`
+	QuestionText   string     `json:"question_text"`
+	Options        string     `json:"options"`
+	OptionOrder    []int      `json:"option_order,omitempty"`
+	SelectedAnswer *int       `json:"selected_answer"`
+	AnswerText     string     `json:"answer_text,omitempty"`
+	CorrectAnswer  *int       `json:"correct_answer"`
+	Outcome        string     `json:"outcome"`
+	Points         float64    `json:"points"`
+	PointsEarned   float64    `json:"points_earned"`
+	Explanation    string     `json:"explanation"`
+	TimeSpent      *int       `json:"time_spent,omitempty"`
+	EssayScore     *float64   `json:"essay_score,omitempty"`
+	EssayMaxScore  float64    `json:"essay_max_score,omitempty"`
+	Feedback       string     `json:"feedback,omitempty"`
+	// Missing is set when the question was deleted after the result was recorded.
+	Missing bool `json:"missing,omitempty"`
+}
+
+type ResultReview struct {
+	Result    *model.TestResult `json:"result"`
+	Questions []QuestionReview  `json:"questions"`
+}
+
+const (
+	EssayOutcomePending = "pending_review"
+	EssayOutcomeGraded  = "graded"
+)
+
+func (s *testResultService) findResult(resultID string) (*model.TestResult, error) {
+	resultUUID, err := uuid.Parse(resultID)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	result, err := s.resultRepo.FindByID(resultUUID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	return result, nil
+}
+
+// GetResultReview joins a result's stored answers with its questions. Attempts are
+// reviewed in the order they were served; legacy submissions in the test's order.
+// It does not check who is asking; requests go through GetResultReviewFor.
+func (s *testResultService) GetResultReview(resultID string) (*ResultReview, error) {
+	result, err := s.findResult(resultID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildReview(result)
+}
+
+// GetResultReviewFor is GetResultReview for a user: only the student who took the
+// result and admins get past the ownership check, which runs before the questions
+// are loaded.
+func (s *testResultService) GetResultReviewFor(userID, userRole, resultID string) (*ResultReview, error) {
+	result, err := s.findResult(resultID)
+	if err != nil {
+		return nil, err
+	}
+	if userRole != "admin" && result.UserID.String() != userID {
+		return nil, errors.New("access denied")
+	}
+	return s.buildReview(result)
+}
+
+func (s *testResultService) buildReview(result *model.TestResult) (*ResultReview, error) {
+	test := &result.Test
+
+	var answers []UserAnswer
+	if result.Answers != "" {
+		if err := json.Unmarshal([]byte(result.Answers), &answers); err != nil {
+			return nil, errors.New("stored answers are invalid")
+		}
+	}
+	answersByQuestion := make(map[uuid.UUID]UserAnswer, len(answers))
+	for _, answer := range answers {
+		if questionUUID, err := uuid.Parse(answer.QuestionID); err == nil {
+			answersByQuestion[questionUUID] = answer
+		}
+	}
+
+	order, optionOrders, err := s.reviewOrder(result, test, answersByQuestion)
+	if err != nil {
+		return nil, err
+	}
+	// Answers to questions that are no longer on the paper still get reviewed.
+	inOrder := make(map[uuid.UUID]bool, len(order))
+	for _, id := range order {
+		inOrder[id] = true
+	}
+	for _, answer := range answers {
+		if questionUUID, err := uuid.Parse(answer.QuestionID); err == nil && !inOrder[questionUUID] {
+			order = append(order, questionUUID)
+			inOrder[questionUUID] = true
+		}
+	}
+
+	questions, err := s.questionRepo.FindByIDs(test.ID, order)
+	if err != nil {
+		return nil, err
+	}
+	questionsByID := make(map[uuid.UUID]*model.Question, len(questions))
+	for i := range questions {
+		questionsByID[questions[i].ID] = &questions[i]
+	}
+
+	essays, err := s.essayRepo.FindByResultID(result.ID)
+	if err != nil {
+		return nil, err
+	}
+	essaysByQuestion := make(map[uuid.UUID]*model.EssayAnswer, len(essays))
+	for i := range essays {
+		essaysByQuestion[essays[i].QuestionID] = &essays[i]
+	}
+
+	review := &ResultReview{Result: result, Questions: make([]QuestionReview, 0, len(order))}
+	for i, id := range order {
+		answer, answered := answersByQuestion[id]
+		item := QuestionReview{Number: i + 1, QuestionID: id, OptionOrder: optionOrders[id]}
+		if answered {
+			if answer.TimeSpent > 0 {
+				timeSpent := answer.TimeSpent
+				item.TimeSpent = &timeSpent
+			}
+			item.AnswerText = answer.AnswerText
+		}
+
+		q, ok := questionsByID[id]
+		if !ok {
+			item.Missing = true
+			item.Outcome = AnswerBlank
+			if answered && answer.SelectedAnswer >= 0 {
+				selected := answer.SelectedAnswer
+				item.SelectedAnswer = &selected
+			}
+			review.Questions = append(review.Questions, item)
+			continue
+		}
+
+		item.SectionID = q.SectionID
+		item.Type = q.Type
+		item.Topic = q.Topic
+		item.QuestionText = q.QuestionText
+		item.Options = q.Options
+		item.Explanation = q.Explanation
+		item.Points, _ = questionWeights(test, q)
+		item.Outcome, item.PointsEarned = gradeAnswer(test, q, answer, answered)
+
+		if !q.IsEssay() {
+			correct := q.CorrectAnswer
+			item.CorrectAnswer = &correct
+			if answered && answer.SelectedAnswer >= 0 {
+				selected := answer.SelectedAnswer
+				item.SelectedAnswer = &selected
+			}
+		} else if essay, ok := essaysByQuestion[id]; ok && item.Outcome == AnswerEssay {
+			item.Outcome = EssayOutcomePending
+			item.EssayMaxScore = essay.MaxScore
+			if essay.Status == model.EssayStatusGraded {
+				item.Outcome = EssayOutcomeGraded
+				item.EssayScore = essay.Score
+				item.PointsEarned = essayPoints(essay)
+				item.Feedback = essay.Feedback
+			}
+		}
+		review.Questions = append(review.Questions, item)
+	}
+	return review, nil
+}
+
+// reviewOrder returns the question IDs of the paper a result was graded on, and the
+// shuffled option order of each question that had one.
+func (s *testResultService) reviewOrder(result *model.TestResult, test *model.Test, answers map[uuid.UUID]UserAnswer) ([]uuid.UUID, map[uuid.UUID][]int, error) {
+	optionOrders := make(map[uuid.UUID][]int)
+	if result.AttemptID != nil {
+		attempt, err := s.attemptRepo.FindByID(*result.AttemptID)
+		if err == nil && attempt.Layout != "" {
+			var layout []model.AttemptLayoutItem
+			if err := json.Unmarshal([]byte(attempt.Layout), &layout); err != nil {
+				return nil, nil, errors.New("stored attempt layout is invalid")
+			}
+			order := make([]uuid.UUID, 0, len(layout))
+			for _, item := range layout {
+				order = append(order, item.QuestionID)
+				if len(item.OptionOrder) > 0 {
+					optionOrders[item.QuestionID] = item.OptionOrder
+				}
+			}
+			return order, optionOrders, nil
+		}
+	}
+
+	// Without an attempt the answers name the questions as they were when the result
+	// was recorded. A bank item answered in an older version is reviewed in that
+	// version, in the place its current version has on the paper.
+	answeredIDs := make([]uuid.UUID, 0, len(answers))
+	for id := range answers {
+		answeredIDs = append(answeredIDs, id)
+	}
+	answered, err := s.questionRepo.FindByIDs(test.ID, answeredIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	answeredVersion := make(map[uuid.UUID]uuid.UUID, len(answered))
+	for _, q := range answered {
+		if q.BankItemID != nil {
+			answeredVersion[*q.BankItemID] = q.ID
+		}
+	}
+
+	questions, err := s.questionRepo.FindByTestID(test.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var order []uuid.UUID
+	for _, q := range servedQuestions(buildPaper(test, scoredQuestions(test, questions))) {
+		id := q.ID
+		if _, ok := answers[id]; !ok && q.BankItemID != nil {
+			if version, ok := answeredVersion[*q.BankItemID]; ok {
+				id = version
+			}
+		}
+		order = append(order, id)
+	}
+	return order, optionOrders, nil
+}
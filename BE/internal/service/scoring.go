@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+// gradedSubmission is the auto-graded part of a submission. Essay answers are
+// returned ungraded so they can be queued for manual review; their points are
+// already part of MaxPoints but not of RawPoints.
+type gradedSubmission struct {
+	TotalQuestions int
+	CorrectCount   int
+	WrongCount     int
+	BlankCount     int
+	RawPoints      float64
+	MaxPoints      float64
+	Essays         []model.EssayAnswer
+}
+
+// questionWeights returns the points a question is worth and the penalty for a wrong
+// answer under the test's scoring policy.
+func questionWeights(test *model.Test, q *model.Question) (float64, float64) {
+	if test.ScoringPolicy != model.ScoringWeighted {
+		return 1, 0
+	}
+	return q.Points, q.Penalty
+}
+
+func blankPenalty(test *model.Test) float64 {
+	if test.ScoringPolicy != model.ScoringWeighted {
+		return 0
+	}
+	return test.BlankPenalty
+}
+
+func gradeSubmission(test *model.Test, questions []model.Question, answers []UserAnswer) gradedSubmission {
+	answersByQuestion := make(map[uuid.UUID]UserAnswer, len(answers))
+	for _, answer := range answers {
+		questionUUID, err := uuid.Parse(answer.QuestionID)
+		if err != nil {
+			continue
+		}
+		answersByQuestion[questionUUID] = answer
+	}
+
+	graded := gradedSubmission{TotalQuestions: len(questions)}
+	for i := range questions {
+		q := &questions[i]
+		points, _ := questionWeights(test, q)
+		graded.MaxPoints += points
+
+		answer, answered := answersByQuestion[q.ID]
+		outcome, earned := gradeAnswer(test, q, answer, answered)
+		graded.RawPoints += earned
+		switch outcome {
+		case AnswerCorrect:
+			graded.CorrectCount++
+		case AnswerWrong:
+			graded.WrongCount++
+		case AnswerBlank:
+			graded.BlankCount++
+		case AnswerEssay:
+			graded.Essays = append(graded.Essays, model.EssayAnswer{
+				ID:           uuid.New(),
+				TestID:       q.TestID,
+				QuestionID:   q.ID,
+				QuestionText: q.QuestionText,
+				Rubric:       q.Rubric,
+				AnswerText:   answer.AnswerText,
+				SectionID:    q.SectionID,
+				MaxScore:     q.MaxScore,
+				Points:       points,
+				Status:       model.EssayStatusPending,
+			})
+		}
+	}
+	return graded
+}
+
+// Outcomes of a single answer. AnswerEssay is an essay waiting for a grader.
+const (
+	AnswerCorrect = "correct"
+	AnswerWrong   = "wrong"
+	AnswerBlank   = "blank"
+	AnswerEssay   = "essay"
+)
+
+// gradeAnswer grades one question and returns the points it adds to (or, for
+// penalties, takes from) the raw total. Answered essays earn nothing here.
+func gradeAnswer(test *model.Test, q *model.Question, answer UserAnswer, answered bool) (string, float64) {
+	points, penalty := questionWeights(test, q)
+	if q.IsEssay() {
+		// A blank essay scores like any other blank and does not need a grader.
+		if !answered || strings.TrimSpace(answer.AnswerText) == "" {
+			return AnswerBlank, -blankPenalty(test)
+		}
+		return AnswerEssay, 0
+	}
+
+	switch {
+	case !answered || answer.SelectedAnswer < 0:
+		return AnswerBlank, -blankPenalty(test)
+	case answer.SelectedAnswer == q.CorrectAnswer:
+		return AnswerCorrect, points
+	default:
+		return AnswerWrong, -penalty
+	}
+}
+
+// essayPoints converts a grader's rubric score into the question's points.
+func essayPoints(essay *model.EssayAnswer) float64 {
+	if essay.Score == nil || essay.MaxScore <= 0 {
+		return 0
+	}
+	return *essay.Score / essay.MaxScore * essay.Points
+}
+
+// gradeSections grades each section on its own. Pending essays are left out of the
+// section raw points the same way they are left out of the total.
+func gradeSections(test *model.Test, questions []model.Question, answers []UserAnswer) []model.SectionScore {
+	scores := make([]model.SectionScore, 0, len(test.Sections))
+	for _, section := range test.Sections {
+		var sectionQuestions []model.Question
+		for _, q := range questions {
+			if q.SectionID != nil && *q.SectionID == section.ID {
+				sectionQuestions = append(sectionQuestions, q)
+			}
+		}
+		graded := gradeSubmission(test, sectionQuestions, answers)
+		scores = append(scores, model.SectionScore{
+			ID:             uuid.New(),
+			SectionID:      section.ID,
+			Title:          section.Title,
+			Position:       section.Position,
+			TotalQuestions: graded.TotalQuestions,
+			CorrectAnswers: graded.CorrectCount,
+			WrongAnswers:   graded.WrongCount,
+			BlankAnswers:   graded.BlankCount,
+			RawPoints:      graded.RawPoints,
+			MaxPoints:      graded.MaxPoints,
+			PassingScore:   section.PassingScore,
+		})
+	}
+	return scores
+}
+
+// applyScore derives the reported 0-100 scores and the pass/fail flags from the raw
+// points already stored on the result and its sections. Negative totals are
+// reported as zero but RawPoints keeps the real value. Missing any section
+// threshold fails the whole test, as in CPNS passing grades.
+func applyScore(result *model.TestResult, test *model.Test) {
+	result.Score = percentOf(result.RawPoints, result.MaxPoints)
+
+	var passed *bool
+	if test.PassingScore != nil {
+		overall := result.RawPoints >= *test.PassingScore
+		passed = &overall
+	}
+
+	for i := range result.SectionScores {
+		section := &result.SectionScores[i]
+		section.Score = percentOf(section.RawPoints, section.MaxPoints)
+		section.Passed = nil
+		if section.PassingScore == nil {
+			continue
+		}
+		sectionPassed := section.RawPoints >= *section.PassingScore
+		section.Passed = &sectionPassed
+		overall := sectionPassed && (passed == nil || *passed)
+		passed = &overall
+	}
+	result.Passed = passed
+}
+
+func percentOf(rawPoints, maxPoints float64) float64 {
+	if maxPoints <= 0 || rawPoints <= 0 {
+		return 0
+	}
+	return rawPoints / maxPoints * 100
+}
+
+// scoredQuestions drops questions that are not in any section when the test is
+// sectioned, since an attempt never shows them.
+func scoredQuestions(test *model.Test, questions []model.Question) []model.Question {
+	if len(test.Sections) == 0 {
+		return questions
+	}
+	scored := make([]model.Question, 0, len(questions))
+	for _, q := range questions {
+		if q.SectionID != nil {
+			scored = append(scored, q)
+		}
+	}
+	return scored
+}
+
+func parseRubric(rubric string) ([]model.RubricCriterion, error) {
+	if strings.TrimSpace(rubric) == "" {
+		return nil, nil
+	}
+	var criteria []model.RubricCriterion
+	if err := json.Unmarshal([]byte(rubric), &criteria); err != nil {
+		return nil, err
+	}
+	return criteria, nil
+}
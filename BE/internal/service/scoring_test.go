@@ -0,0 +1,193 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/google/uuid"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func boolPtr(v bool) *bool { return &v }
+
+func scoringQuestions() []model.Question {
+	return []model.Question{
+		{ID: uuid.New(), CorrectAnswer: 0, Points: 4, Penalty: 1},
+		{ID: uuid.New(), CorrectAnswer: 1, Points: 2, Penalty: 0.5},
+		{ID: uuid.New(), CorrectAnswer: 2, Points: 1, Penalty: 0},
+		{ID: uuid.New(), Type: model.QuestionTypeEssay, Points: 3, MaxScore: 10},
+	}
+}
+
+func TestGradeSubmission(t *testing.T) {
+	questions := scoringQuestions()
+	answer := func(i, selected int, text string) UserAnswer {
+		return UserAnswer{QuestionID: questions[i].ID.String(), SelectedAnswer: selected, AnswerText: text}
+	}
+
+	tests := []struct {
+		name    string
+		test    model.Test
+		answers []UserAnswer
+		want    gradedSubmission
+	}{
+		{
+			name:    "percent correct ignores points and penalties",
+			test:    model.Test{ScoringPolicy: model.ScoringPercentCorrect},
+			answers: []UserAnswer{answer(0, 0, ""), answer(1, 0, ""), answer(3, 0, "my essay")},
+			want:    gradedSubmission{TotalQuestions: 4, CorrectCount: 1, WrongCount: 1, BlankCount: 1, RawPoints: 1, MaxPoints: 4},
+		},
+		{
+			name:    "weighted adds points and takes penalties",
+			test:    model.Test{ScoringPolicy: model.ScoringWeighted},
+			answers: []UserAnswer{answer(0, 0, ""), answer(1, 0, ""), answer(2, 2, "")},
+			want:    gradedSubmission{TotalQuestions: 4, CorrectCount: 2, WrongCount: 1, BlankCount: 1, RawPoints: 4.5, MaxPoints: 10},
+		},
+		{
+			name:    "weighted blank penalty applies to skipped questions and blank essays",
+			test:    model.Test{ScoringPolicy: model.ScoringWeighted, BlankPenalty: 0.25},
+			answers: []UserAnswer{answer(0, -1, ""), answer(3, 0, "   ")},
+			want:    gradedSubmission{TotalQuestions: 4, BlankCount: 4, RawPoints: -1, MaxPoints: 10},
+		},
+		{
+			name:    "answers with invalid question ids are ignored",
+			test:    model.Test{ScoringPolicy: model.ScoringWeighted},
+			answers: []UserAnswer{{QuestionID: "not-a-uuid", SelectedAnswer: 0}},
+			want:    gradedSubmission{TotalQuestions: 4, BlankCount: 4, MaxPoints: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gradeSubmission(&tt.test, questions, tt.answers)
+			if got.TotalQuestions != tt.want.TotalQuestions || got.CorrectCount != tt.want.CorrectCount ||
+				got.WrongCount != tt.want.WrongCount || got.BlankCount != tt.want.BlankCount {
+				t.Errorf("counts = %d/%d/%d/%d, want %d/%d/%d/%d", got.TotalQuestions, got.CorrectCount, got.WrongCount, got.BlankCount,
+					tt.want.TotalQuestions, tt.want.CorrectCount, tt.want.WrongCount, tt.want.BlankCount)
+			}
+			if math.Abs(got.RawPoints-tt.want.RawPoints) > 1e-9 || got.MaxPoints != tt.want.MaxPoints {
+				t.Errorf("points = %v of %v, want %v of %v", got.RawPoints, got.MaxPoints, tt.want.RawPoints, tt.want.MaxPoints)
+			}
+		})
+	}
+}
+
+func TestGradeSubmissionQueuesAnsweredEssays(t *testing.T) {
+	questions := scoringQuestions()
+	test := &model.Test{ScoringPolicy: model.ScoringWeighted}
+	answers := []UserAnswer{{QuestionID: questions[3].ID.String(), AnswerText: "an answer"}}
+
+	got := gradeSubmission(test, questions, answers)
+	if len(got.Essays) != 1 {
+		t.Fatalf("got %d essays, want 1", len(got.Essays))
+	}
+	essay := got.Essays[0]
+	if essay.QuestionID != questions[3].ID || essay.Points != 3 || essay.MaxScore != 10 || essay.Status != model.EssayStatusPending {
+		t.Errorf("essay = %+v", essay)
+	}
+	if got.BlankCount != 3 || got.RawPoints != 0 {
+		t.Errorf("blank = %d, raw = %v; essays must not count as blank or earn points before grading", got.BlankCount, got.RawPoints)
+	}
+}
+
+func TestEssayPoints(t *testing.T) {
+	tests := []struct {
+		name  string
+		essay model.EssayAnswer
+		want  float64
+	}{
+		{"ungraded", model.EssayAnswer{MaxScore: 10, Points: 3}, 0},
+		{"full marks", model.EssayAnswer{Score: floatPtr(10), MaxScore: 10, Points: 3}, 3},
+		{"half marks", model.EssayAnswer{Score: floatPtr(5), MaxScore: 10, Points: 3}, 1.5},
+		{"no rubric maximum", model.EssayAnswer{Score: floatPtr(5), Points: 3}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := essayPoints(&tt.essay); got != tt.want {
+				t.Errorf("essayPoints = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		test       model.Test
+		raw, max   float64
+		sections   []model.SectionScore
+		wantScore  float64
+		wantPassed *bool
+	}{
+		{
+			name: "no passing score leaves passed unset",
+			raw:  30, max: 40,
+			wantScore: 75,
+		},
+		{
+			name: "negative totals are reported as zero",
+			test: model.Test{PassingScore: floatPtr(10)},
+			raw:  -5, max: 40,
+			wantScore: 0, wantPassed: boolPtr(false),
+		},
+		{
+			name: "passing score is compared with raw points",
+			test: model.Test{PassingScore: floatPtr(30)},
+			raw:  30, max: 40,
+			wantScore: 75, wantPassed: boolPtr(true),
+		},
+		{
+			name: "missing a section threshold fails the test",
+			test: model.Test{PassingScore: floatPtr(20)},
+			raw:  30, max: 40,
+			sections: []model.SectionScore{
+				{RawPoints: 20, MaxPoints: 20, PassingScore: floatPtr(10)},
+				{RawPoints: 10, MaxPoints: 20, PassingScore: floatPtr(15)},
+			},
+			wantScore: 75, wantPassed: boolPtr(false),
+		},
+		{
+			name: "section thresholds alone decide when the test has none",
+			raw:  30, max: 40,
+			sections: []model.SectionScore{
+				{RawPoints: 20, MaxPoints: 20, PassingScore: floatPtr(10)},
+				{RawPoints: 10, MaxPoints: 20},
+			},
+			wantScore: 75, wantPassed: boolPtr(true),
+		},
+		{
+			name: "empty test scores zero",
+			raw:  0, max: 0,
+			wantScore: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.TestResult{RawPoints: tt.raw, MaxPoints: tt.max, SectionScores: tt.sections}
+			applyScore(result, &tt.test)
+			if result.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v", result.Score, tt.wantScore)
+			}
+			switch {
+			case tt.wantPassed == nil && result.Passed != nil:
+				t.Errorf("Passed = %v, want unset", *result.Passed)
+			case tt.wantPassed != nil && (result.Passed == nil || *result.Passed != *tt.wantPassed):
+				t.Errorf("Passed = %v, want %v", result.Passed, *tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestScoredQuestionsDropsUnsectionedQuestions(t *testing.T) {
+	sectionID := uuid.New()
+	questions := []model.Question{{ID: uuid.New(), SectionID: &sectionID}, {ID: uuid.New()}}
+
+	if got := scoredQuestions(&model.Test{}, questions); len(got) != 2 {
+		t.Errorf("unsectioned test kept %d questions, want 2", len(got))
+	}
+	sectioned := &model.Test{Sections: []model.TestSection{{ID: sectionID}}}
+	if got := scoredQuestions(sectioned, questions); len(got) != 1 || got[0].SectionID == nil {
+		t.Errorf("sectioned test kept %v, want only the sectioned question", got)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/psychometrics"
+	"github.com/google/uuid"
+)
+
+// similarityMinResults is the fewest results a check needs; the expected share of
+// shared wrong answers is fitted across the other students, so a tiny group gives
+// a meaningless baseline.
+const similarityMinResults = 10
+
+// SimilarityRequest selects the results to compare: those of a tryout event, or the
+// completed results of a test within an optional window of completion times.
+type SimilarityRequest struct {
+	TestID  uuid.UUID
+	EventID *uuid.UUID
+	From    *time.Time
+	To      *time.Time
+	Alpha   float64
+}
+
+type SimilarityService interface {
+	DetectSimilarity(req *SimilarityRequest) (*model.SimilarityRun, error)
+	GetSimilarityRuns(testID string) ([]model.SimilarityRun, error)
+	GetSimilarityRun(runID string) (*model.SimilarityRun, error)
+	ExportCSV(run *model.SimilarityRun) ([]byte, error)
+}
+
+type similarityService struct {
+	loader        *responseLoader
+	eventRepo     repository.EventRepository
+	integrityRepo repository.IntegrityRepository
+}
+
+func NewSimilarityService(testRepo repository.TestRepository, questionRepo repository.QuestionRepository, resultRepo repository.TestResultRepository, attemptRepo repository.AttemptRepository, eventRepo repository.EventRepository, integrityRepo repository.IntegrityRepository) SimilarityService {
+	return &similarityService{
+		loader:        &responseLoader{testRepo, questionRepo, resultRepo, attemptRepo},
+		eventRepo:     eventRepo,
+		integrityRepo: integrityRepo,
+	}
+}
+
+// DetectSimilarity compares the multiple choice answers of every pair of selected
+// results and stores the pairs whose identical wrong answers are significant. A
+// student's own repeated attempts are never paired with each other.
+func (s *similarityService) DetectSimilarity(req *SimilarityRequest) (*model.SimilarityRun, error) {
+	run := &model.SimilarityRun{
+		ID:         uuid.New(),
+		TestID:     req.TestID,
+		EventID:    req.EventID,
+		WindowFrom: req.From,
+		WindowTo:   req.To,
+		Alpha:      req.Alpha,
+	}
+	if run.Alpha <= 0 {
+		run.Alpha = 0.001
+	}
+
+	var inEvent map[uuid.UUID]bool
+	if req.EventID != nil {
+		event, err := s.eventRepo.FindByID(*req.EventID)
+		if err != nil {
+			return nil, errors.New("event not found")
+		}
+		run.TestID = event.TestID
+		results, err := s.loader.resultRepo.FindFinalByEventID(event.ID)
+		if err != nil {
+			return nil, err
+		}
+		inEvent = make(map[uuid.UUID]bool, len(results))
+		for _, result := range results {
+			inEvent[result.ID] = true
+		}
+	}
+
+	all, err := s.loader.load(run.TestID)
+	if err != nil {
+		return nil, err
+	}
+	responses := all.subset(func(result *model.TestResult) bool {
+		if inEvent != nil && !inEvent[result.ID] {
+			return false
+		}
+		if req.From != nil && result.CompletedAt.Before(*req.From) {
+			return false
+		}
+		return req.To == nil || !result.CompletedAt.After(*req.To)
+	})
+	if len(responses.results) < similarityMinResults {
+		return nil, fmt.Errorf("at least %d results are needed, found %d", similarityMinResults, len(responses.results))
+	}
+
+	key := make([]int, len(responses.questions))
+	for i, q := range responses.questions {
+		key[i] = q.CorrectAnswer
+	}
+	run.Results = len(responses.results)
+	run.Questions = len(key)
+
+	pairs := psychometrics.AnswerSimilarity(responses.selected, key, psychometrics.SimilarityOptions{Alpha: run.Alpha})
+	for _, pair := range pairs {
+		source, copier := responses.results[pair.Source], responses.results[pair.Copier]
+		if source.UserID == copier.UserID {
+			continue
+		}
+		run.Pairs = append(run.Pairs, model.SimilarityPair{
+			ID:               uuid.New(),
+			RunID:            run.ID,
+			SourceResultID:   source.ID,
+			SourceUserID:     source.UserID,
+			CopierResultID:   copier.ID,
+			CopierUserID:     copier.UserID,
+			SourceWrong:      pair.SourceWrong,
+			CopierWrong:      pair.CopierWrong,
+			IdenticalWrong:   pair.IdenticalWrong,
+			IdenticalAnswers: pair.IdenticalAnswers,
+			Expected:         round3(pair.Expected),
+			PValue:           pair.PValue,
+			Significance:     round3(significance(pair.PValue)),
+		})
+	}
+	run.PairCount = len(run.Pairs)
+
+	if err := s.integrityRepo.CreateSimilarityRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// significance is -log10(p), capped so a p-value that underflowed to zero still has
+// a finite score.
+func significance(p float64) float64 {
+	if p <= 0 {
+		return 300
+	}
+	return math.Min(-math.Log10(p), 300)
+}
+
+func (s *similarityService) GetSimilarityRuns(testID string) ([]model.SimilarityRun, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.integrityRepo.FindSimilarityRuns(testUUID)
+}
+
+func (s *similarityService) GetSimilarityRun(runID string) (*model.SimilarityRun, error) {
+	runUUID, err := uuid.Parse(runID)
+	if err != nil {
+		return nil, errors.New("similarity run not found")
+	}
+	run, err := s.integrityRepo.FindSimilarityRun(runUUID)
+	if err != nil {
+		return nil, errors.New("similarity run not found")
+	}
+	return run, nil
+}
+
+// ExportCSV writes one row per pair in the order of the run, most significant first.
+func (s *similarityService) ExportCSV(run *model.SimilarityRun) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"source_result_id", "source_name", "source_email", "copier_result_id", "copier_name", "copier_email",
+		"source_wrong", "copier_wrong", "identical_wrong", "identical_answers", "expected", "p_value", "significance"}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, pair := range run.Pairs {
+		row := []string{
+			pair.SourceResultID.String(),
+			pair.SourceUser.Name,
+			pair.SourceUser.Email,
+			pair.CopierResultID.String(),
+			pair.CopierUser.Name,
+			pair.CopierUser.Email,
+			strconv.Itoa(pair.SourceWrong),
+			strconv.Itoa(pair.CopierWrong),
+			strconv.Itoa(pair.IdenticalWrong),
+			strconv.Itoa(pair.IdenticalAnswers),
+			formatStat(pair.Expected),
+			strconv.FormatFloat(pair.PValue, 'e', 3, 64),
+			formatStat(pair.Significance),
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/pkg/upload"
+	"github.com/google/uuid"
+)
+
+// TestPackageFormatVersion is bumped whenever the manifest layout changes in a way
+// older servers cannot read.
+const TestPackageFormatVersion = 1
+
+const (
+	packageManifestName = "manifest.json"
+	packageMediaDir     = "media/"
+
+	// Limits on what an imported archive may unpack to, so a small zip cannot
+	// fill the disk or memory.
+	maxPackageFileSize = 64 << 20
+	maxPackageSize     = 512 << 20
+)
+
+// uploadRefPattern finds upload URLs inside image fields and question text
+// (markdown, HTML or bare links), with or without the host part.
+var uploadRefPattern = regexp.MustCompile(`(?:https?://[^\s"'()<>]*)?/uploads/[A-Za-z0-9._-]+`)
+
+// TestPackage is the manifest.json of an exported test. Questions from the question
+// bank are exported as plain questions so the archive does not depend on bank items
+// existing on the target server.
+type TestPackage struct {
+	FormatVersion int            `json:"format_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Test          model.Test     `json:"test"`
+	Media         []PackageMedia `json:"media"`
+	MissingMedia  []string       `json:"missing_media,omitempty"`
+}
+
+// PackageMedia maps an upload URL used by the test to its file inside the archive.
+type PackageMedia struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+}
+
+type PackageImportReport struct {
+	TestID    uuid.UUID `json:"test_id"`
+	Title     string    `json:"title"`
+	Sections  int       `json:"sections"`
+	Questions int       `json:"questions"`
+	Media     int       `json:"media"`
+	Conflicts []string  `json:"conflicts"`
+}
+
+type TestPackageService interface {
+	ExportTest(testID string) ([]byte, error)
+	ImportTest(archive []byte) (*PackageImportReport, error)
+}
+
+type testPackageService struct {
+	testRepo     repository.TestRepository
+	questionRepo repository.QuestionRepository
+}
+
+func NewTestPackageService(testRepo repository.TestRepository, questionRepo repository.QuestionRepository) TestPackageService {
+	return &testPackageService{testRepo, questionRepo}
+}
+
+// ExportTest writes the test, its sections, every question it serves and the
+// uploaded files they reference into a zip archive.
+func (s *testPackageService) ExportTest(testID string) ([]byte, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	test, err := s.testRepo.FindByID(testUUID)
+	if err != nil {
+		return nil, errors.New("test not found")
+	}
+	questions, err := s.questionRepo.FindByTestID(testUUID)
+	if err != nil {
+		return nil, err
+	}
+	test.Questions = questions
+
+	pkg := TestPackage{FormatVersion: TestPackageFormatVersion, ExportedAt: time.Now(), Test: *test, Media: []PackageMedia{}}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	written := make(map[string]bool)
+	for _, url := range testMediaURLs(test) {
+		name, ok := upload.FileName(url)
+		if !ok {
+			continue
+		}
+		if !written[name] {
+			if err := copyUploadToZip(zw, url, packageMediaDir+name); err != nil {
+				pkg.MissingMedia = append(pkg.MissingMedia, url)
+				continue
+			}
+			written[name] = true
+		}
+		pkg.Media = append(pkg.Media, PackageMedia{URL: url, Path: packageMediaDir + name})
+	}
+
+	manifest, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.Create(packageManifestName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportTest recreates a test from an exported archive under new IDs. Media files are
+// stored again through the upload package and their URLs rewritten. Anything that could
+// not be carried over is listed in Conflicts rather than failing the import.
+func (s *testPackageService) ImportTest(archive []byte) (*PackageImportReport, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, errors.New("file is not a test package archive")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	var unpacked uint64
+	for _, f := range zr.File {
+		files[f.Name] = f
+		unpacked += f.UncompressedSize64
+	}
+	if unpacked > maxPackageSize {
+		return nil, errors.New("archive is too large to import")
+	}
+	manifestFile, ok := files[packageManifestName]
+	if !ok {
+		return nil, errors.New("archive has no manifest.json")
+	}
+	var pkg TestPackage
+	if err := readZipJSON(manifestFile, &pkg); err != nil {
+		return nil, errors.New("manifest.json is not valid: " + err.Error())
+	}
+	if pkg.FormatVersion < 1 || pkg.FormatVersion > TestPackageFormatVersion {
+		return nil, fmt.Errorf("unsupported package format version %d", pkg.FormatVersion)
+	}
+	if strings.TrimSpace(pkg.Test.Title) == "" {
+		return nil, errors.New("manifest has no test title")
+	}
+
+	report := &PackageImportReport{Title: pkg.Test.Title, Conflicts: []string{}}
+	existing, err := s.testRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range existing {
+		if strings.EqualFold(t.Title, pkg.Test.Title) {
+			report.Conflicts = append(report.Conflicts, fmt.Sprintf("a test titled %q already exists (%s); the package was imported as a new test", t.Title, t.ID))
+			break
+		}
+	}
+	for _, url := range pkg.MissingMedia {
+		report.Conflicts = append(report.Conflicts, fmt.Sprintf("media %s was missing when the package was exported", url))
+	}
+
+	// Upload every media file once and remember the new URL of each old one.
+	newURLs := make(map[string]string)
+	storedPaths := make(map[string]string)
+	for _, media := range pkg.Media {
+		if stored, ok := storedPaths[media.Path]; ok {
+			newURLs[media.URL] = stored
+			continue
+		}
+		f, ok := files[media.Path]
+		if !ok || !strings.HasPrefix(media.Path, packageMediaDir) {
+			report.Conflicts = append(report.Conflicts, fmt.Sprintf("media %s is not in the archive", media.URL))
+			continue
+		}
+		stored, err := saveZipUpload(f)
+		if err != nil {
+			report.Conflicts = append(report.Conflicts, fmt.Sprintf("media %s could not be stored: %v", media.URL, err))
+			continue
+		}
+		storedPaths[media.Path] = stored
+		newURLs[media.URL] = stored
+		report.Media++
+	}
+	rewrite := func(text string) string {
+		return uploadRefPattern.ReplaceAllStringFunc(text, func(url string) string {
+			if stored, ok := newURLs[url]; ok {
+				return stored
+			}
+			return url
+		})
+	}
+
+	test := pkg.Test
+	test.ID = uuid.New()
+	test.Status = model.TestStatusDraft
+	test.CreatedAt, test.UpdatedAt = time.Time{}, time.Time{}
+	test.ImageURL = rewrite(test.ImageURL)
+	test.Description = rewrite(test.Description)
+	if test.ScoringPolicy == "" {
+		test.ScoringPolicy = model.ScoringPercentCorrect
+	}
+
+	sectionIDs := make(map[uuid.UUID]uuid.UUID, len(test.Sections))
+	for i := range test.Sections {
+		section := &test.Sections[i]
+		newID := uuid.New()
+		sectionIDs[section.ID] = newID
+		section.ID = newID
+		section.TestID = test.ID
+		section.CreatedAt, section.UpdatedAt = time.Time{}, time.Time{}
+	}
+	for i := range test.Questions {
+		question := &test.Questions[i]
+		question.ID = uuid.New()
+		question.TestID = test.ID
+		question.BankItemID = nil
+		question.BankVersion = 0
+		question.QuestionText = rewrite(question.QuestionText)
+		question.Options = rewrite(question.Options)
+		question.Explanation = rewrite(question.Explanation)
+		if question.SectionID != nil {
+			if newID, ok := sectionIDs[*question.SectionID]; ok {
+				question.SectionID = &newID
+			} else {
+				report.Conflicts = append(report.Conflicts, fmt.Sprintf("question %d refers to a section that is not in the package; it was imported without a section", i+1))
+				question.SectionID = nil
+			}
+		}
+		if err := normalizeQuestion(question); err != nil {
+			return nil, fmt.Errorf("question %d: %v", i+1, err)
+		}
+	}
+
+	if err := s.testRepo.Create(&test); err != nil {
+		return nil, err
+	}
+	report.TestID = test.ID
+	report.Sections = len(test.Sections)
+	report.Questions = len(test.Questions)
+	return report, nil
+}
+
+// testMediaURLs lists the upload URLs referenced by a test and its questions, in
+// order of first appearance.
+func testMediaURLs(test *model.Test) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	collect := func(text string) {
+		for _, url := range uploadRefPattern.FindAllString(text, -1) {
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	collect(test.ImageURL)
+	collect(test.Description)
+	for _, q := range test.Questions {
+		collect(q.QuestionText)
+		collect(q.Options)
+		collect(q.Explanation)
+	}
+	return urls
+}
+
+func copyUploadToZip(zw *zip.Writer, url, name string) error {
+	src, err := upload.Open(url)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// openPackageFile opens a file of an imported archive, refusing files that unpack to
+// more than maxPackageFileSize. Reads are capped as well in case the header lies.
+func openPackageFile(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > maxPackageFileSize {
+		return nil, errors.New("file is too large")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, maxPackageFileSize), rc}, nil
+}
+
+func saveZipUpload(f *zip.File) (string, error) {
+	rc, err := openPackageFile(f)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return upload.Save(path.Base(f.Name), rc)
+}
+
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := openPackageFile(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
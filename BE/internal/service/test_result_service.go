@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
@@ -10,84 +11,325 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserAnswer is one answer as sent by the client. TimeSpent is the seconds spent on
+// the question, when the client tracks it.
 type UserAnswer struct {
 	QuestionID     string `json:"question_id"`
 	SelectedAnswer int    `json:"selected_answer"`
+	AnswerText     string `json:"answer_text,omitempty"`
+	TimeSpent      int    `json:"time_spent,omitempty"`
 }
 
+// SubmitTestRequest grades a whole paper in one go. TimeSpent is the client's own
+// count; it is stored for display but never used to rank the result.
 type SubmitTestRequest struct {
 	TestID    string       `json:"test_id"`
 	TimeSpent int          `json:"time_spent"`
 	Answers   []UserAnswer `json:"answers"`
 }
 
+type InvalidateResultRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ResultListener is told about every result once its score is final: right after
+// submission, or after the last essay answer is graded.
+type ResultListener interface {
+	ResultFinalized(result *model.TestResult)
+}
+
+// ResultInvalidator is implemented by listeners that keep state which must be undone
+// when an admin invalidates a result. It is called after the student's official
+// result has been re-selected.
+type ResultInvalidator interface {
+	ResultInvalidated(result *model.TestResult)
+}
+
 type TestResultService interface {
 	SubmitTest(userID string, submission *SubmitTestRequest) (*model.TestResult, error)
 	GetResultsByUserID(userID string) ([]model.TestResult, error)
+	RecordAttempt(attempt *model.Attempt, test *model.Test, questions []model.Question, answers []UserAnswer) (*model.TestResult, error)
+	FinalizeReviewedResult(resultID uuid.UUID) (*model.TestResult, error)
+	InvalidateResult(resultID uuid.UUID, reason string) (*model.TestResult, error)
+	AddListener(listener ResultListener)
+	GetResultReview(resultID string) (*ResultReview, error)
+	GetResultReviewFor(userID, userRole, resultID string) (*ResultReview, error)
 }
 
 type testResultService struct {
-	resultRepo   repository.TestResultRepository
-	questionRepo repository.QuestionRepository
+	resultRepo          repository.TestResultRepository
+	testRepo            repository.TestRepository
+	questionRepo        repository.QuestionRepository
+	essayRepo           repository.EssayAnswerRepository
+	attemptRepo         repository.AttemptRepository
+	paramRepo           repository.ItemParameterRepository
+	eventRepo           repository.EventRepository
+	policyService       AttemptPolicyService
+	notificationService NotificationService
+	listeners           []ResultListener
+}
+
+func NewTestResultService(resultRepo repository.TestResultRepository, testRepo repository.TestRepository, questionRepo repository.QuestionRepository, essayRepo repository.EssayAnswerRepository, attemptRepo repository.AttemptRepository, paramRepo repository.ItemParameterRepository, eventRepo repository.EventRepository, policyService AttemptPolicyService, notificationService NotificationService) TestResultService {
+	return &testResultService{resultRepo: resultRepo, testRepo: testRepo, questionRepo: questionRepo, essayRepo: essayRepo, attemptRepo: attemptRepo, paramRepo: paramRepo, eventRepo: eventRepo, policyService: policyService, notificationService: notificationService}
+}
+
+// AddListener registers a listener for finalized results. Listeners are wired once at
+// startup, before the server takes requests.
+func (s *testResultService) AddListener(listener ResultListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *testResultService) notifyFinalized(result *model.TestResult) {
+	for _, listener := range s.listeners {
+		listener.ResultFinalized(result)
+	}
 }
 
-func NewTestResultService(resultRepo repository.TestResultRepository, questionRepo repository.QuestionRepository) TestResultService {
-	return &testResultService{resultRepo, questionRepo}
+func (s *testResultService) notifyInvalidated(result *model.TestResult) {
+	for _, listener := range s.listeners {
+		if invalidator, ok := listener.(ResultInvalidator); ok {
+			invalidator.ResultInvalidated(result)
+		}
+	}
 }
 
+// SubmitTest grades a paper answered without an attempt. It only takes plain tests:
+// anything with timed sections, a shuffled or drawn paper, adaptive delivery or a
+// tryout event is served and timed by the attempt API alone.
 func (s *testResultService) SubmitTest(userID string, submission *SubmitTestRequest) (*model.TestResult, error) {
 	userUUID, _ := uuid.Parse(userID)
 	testUUID, _ := uuid.Parse(submission.TestID)
 
-	correctAnswersMap := make(map[uuid.UUID]int)
-	questions, err := s.questionRepo.FindByTestID(testUUID)
+	test, err := s.testRepo.FindByID(testUUID)
 	if err != nil {
-		return nil, errors.New("could not retrieve questions for the test")
+		return nil, errors.New("test not found")
+	}
+	if !test.IsAvailable(time.Now()) {
+		return nil, errors.New("test is not available")
 	}
-	for _, q := range questions {
-		correctAnswersMap[q.ID] = q.CorrectAnswer
+	only, err := attemptOnly(test, s.eventRepo)
+	if err != nil {
+		return nil, err
+	}
+	if only {
+		return nil, errors.New("test must be taken through an attempt")
+	}
+	release, err := s.policyService.ReserveAttempt(userUUID, test)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	var correctCount int
-	totalQuestions := len(questions)
-	for _, userAnswer := range submission.Answers {
-		questionUUID, _ := uuid.Parse(userAnswer.QuestionID)
-		if correctAnswer, ok := correctAnswersMap[questionUUID]; ok {
-			if userAnswer.SelectedAnswer == correctAnswer {
-				correctCount++
-			}
-		}
+	questions, err := s.questionRepo.FindByTestID(testUUID)
+	if err != nil {
+		return nil, errors.New("could not retrieve questions for the test")
 	}
 
-	score := 0.0
-	if totalQuestions > 0 {
-		score = (float64(correctCount) / float64(totalQuestions)) * 100
+	return s.recordResult(userUUID, test, questions, submission.Answers, submission.TimeSpent, nil)
+}
+
+// RecordAttempt grades the answers collected by an attempt and stores the result.
+func (s *testResultService) RecordAttempt(attempt *model.Attempt, test *model.Test, questions []model.Question, answers []UserAnswer) (*model.TestResult, error) {
+	end := time.Now()
+	if end.After(attempt.ExpiresAt) {
+		end = attempt.ExpiresAt
 	}
+	timeSpent := int(end.Sub(attempt.StartedAt).Seconds())
+	return s.recordResult(attempt.UserID, test, questions, answers, timeSpent, &attempt.ID)
+}
 
-	userAnswersJSON, _ := json.Marshal(submission.Answers)
+func (s *testResultService) recordResult(userUUID uuid.UUID, test *model.Test, questions []model.Question, answers []UserAnswer, timeSpent int, attemptID *uuid.UUID) (*model.TestResult, error) {
+	questions = scoredQuestions(test, questions)
+	graded := gradeSubmission(test, questions, answers)
+	userAnswersJSON, _ := json.Marshal(answers)
 
+	now := time.Now()
 	result := &model.TestResult{
 		ID:             uuid.New(),
-		TestID:         testUUID,
+		TestID:         test.ID,
 		UserID:         userUUID,
-		Score:          score,
-		TotalQuestions: totalQuestions,
-		CorrectAnswers: correctCount,
-		TimeSpent:      submission.TimeSpent,
+		TotalQuestions: graded.TotalQuestions,
+		CorrectAnswers: graded.CorrectCount,
+		WrongAnswers:   graded.WrongCount,
+		BlankAnswers:   graded.BlankCount,
+		RawPoints:      graded.RawPoints,
+		MaxPoints:      graded.MaxPoints,
+		TimeSpent:      timeSpent,
 		Answers:        string(userAnswersJSON),
-		CompletedAt:    time.Now(),
+		Status:         model.ResultStatusCompleted,
+		CompletedAt:    now,
+		FinalizedAt:    &now,
+		AttemptID:      attemptID,
+		SectionScores:  gradeSections(test, questions, answers),
+	}
+	for i := range result.SectionScores {
+		result.SectionScores[i].TestResultID = result.ID
+	}
+	applyScore(result, test)
+	if test.AbilityEstimator != "" || test.Adaptive {
+		// Essays are not calibrated, so the ability estimate is final already.
+		if err := s.scoreAbility(result, test, questions, answers); err != nil {
+			return nil, err
+		}
+	}
+
+	// Essay answers keep the result open until every one of them is graded.
+	// Until then Score and Passed only reflect the auto-graded questions.
+	if len(graded.Essays) > 0 {
+		result.Status = model.ResultStatusPendingReview
+		result.FinalizedAt = nil
+		for i := range graded.Essays {
+			graded.Essays[i].TestResultID = result.ID
+			graded.Essays[i].UserID = userUUID
+		}
 	}
 
-	if err := s.resultRepo.Create(result); err != nil {
+	if err := s.resultRepo.CreateWithEssayAnswers(result, graded.Essays); err != nil {
 		return nil, err
 	}
+	if result.Status == model.ResultStatusCompleted {
+		if err := s.markOfficial(result, test); err != nil {
+			return nil, err
+		}
+		s.notifyFinalized(result)
+	}
 	return result, nil
 }
 
+// markOfficial re-selects the student's official result on the test now that result
+// is final, and updates the flags on result to match.
+func (s *testResultService) markOfficial(result *model.TestResult, test *model.Test) error {
+	results, err := s.resultRepo.FindByUserAndTest(result.UserID, result.TestID)
+	if err != nil {
+		return err
+	}
+	var final []model.TestResult
+	for _, r := range results {
+		if r.Status == model.ResultStatusCompleted {
+			final = append(final, r)
+		}
+	}
+	official, score := officialResult(test.OfficialResult, final)
+	if official == nil {
+		result.IsOfficial = false
+		result.OfficialScore = nil
+		return s.resultRepo.ClearOfficial(result.TestID, result.UserID)
+	}
+	if err := s.resultRepo.SetOfficial(result.TestID, result.UserID, official.ID, score); err != nil {
+		return err
+	}
+	result.IsOfficial = official.ID == result.ID
+	result.OfficialScore = nil
+	if result.IsOfficial {
+		result.OfficialScore = &score
+	}
+	return nil
+}
+
+func (s *testResultService) scoreAbility(result *model.TestResult, test *model.Test, questions []model.Question, answers []UserAnswer) error {
+	ids := make([]uuid.UUID, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	params, err := s.paramRepo.FindByQuestionIDs(ids)
+	if err != nil {
+		return err
+	}
+	applyAbility(result, test, estimateAbility(test, questions, answers, params))
+	return nil
+}
+
 func (s *testResultService) GetResultsByUserID(userID string) ([]model.TestResult, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
 	return s.resultRepo.FindByUserID(userUUID)
-}
\ No newline at end of file
+}
+
+// FinalizeReviewedResult recomputes the score of a pending_review result once all of
+// its essay answers are graded and lets the student know. It is a no-op while any
+// essay is still waiting for a grader.
+func (s *testResultService) FinalizeReviewedResult(resultID uuid.UUID) (*model.TestResult, error) {
+	result, err := s.resultRepo.FindByID(resultID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	if result.Status != model.ResultStatusPendingReview {
+		return result, nil
+	}
+
+	essays, err := s.essayRepo.FindByResultID(resultID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range essays {
+		if essays[i].Status != model.EssayStatusGraded || essays[i].Score == nil {
+			return result, nil
+		}
+	}
+
+	for i := range essays {
+		points := essayPoints(&essays[i])
+		result.RawPoints += points
+		for j := range result.SectionScores {
+			if essays[i].SectionID != nil && result.SectionScores[j].SectionID == *essays[i].SectionID {
+				result.SectionScores[j].RawPoints += points
+			}
+		}
+	}
+
+	now := time.Now()
+	applyScore(result, &result.Test)
+	result.Status = model.ResultStatusCompleted
+	result.FinalizedAt = &now
+	finalized, err := s.resultRepo.FinalizePending(result)
+	if err != nil {
+		return nil, err
+	}
+	if !finalized {
+		// Another grader finalized it at the same time and has told everyone.
+		return s.resultRepo.FindByID(resultID)
+	}
+	if err := s.markOfficial(result, &result.Test); err != nil {
+		return nil, err
+	}
+	s.notifyFinalized(result)
+
+	message := fmt.Sprintf("Your answers for \"%s\" have been reviewed. Final score: %.2f", result.Test.Title, result.Score)
+	_ = s.notificationService.Notify(result.UserID, "result_finalized", "Test result is ready", message, "/result/"+result.ID.String())
+
+	return result, nil
+}
+
+// InvalidateResult voids a result, e.g. after an integrity review. The student's
+// official result is re-selected from what is left, listeners drop the result from
+// rankings and the student is told why.
+func (s *testResultService) InvalidateResult(resultID uuid.UUID, reason string) (*model.TestResult, error) {
+	result, err := s.resultRepo.FindByID(resultID)
+	if err != nil {
+		return nil, errors.New("test result not found")
+	}
+	if result.Status == model.ResultStatusInvalidated {
+		return nil, errors.New("test result is already invalidated")
+	}
+
+	result.Status = model.ResultStatusInvalidated
+	result.InvalidatedReason = reason
+	if err := s.resultRepo.Update(result); err != nil {
+		return nil, err
+	}
+	if err := s.markOfficial(result, &result.Test); err != nil {
+		return nil, err
+	}
+	s.notifyInvalidated(result)
+
+	message := fmt.Sprintf("Your result for \"%s\" has been invalidated and no longer counts.", result.Test.Title)
+	if reason != "" {
+		message += " Reason: " + reason
+	}
+	_ = s.notificationService.Notify(result.UserID, "result_invalidated", "Test result invalidated", message, "/result/"+result.ID.String())
+
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
+	"github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
+	"github.com/google/uuid"
+)
+
+type SectionRequest struct {
+	Title        string   `json:"title" validate:"required"`
+	Position     int      `json:"position" validate:"gte=0"`
+	Duration     int      `json:"duration" validate:"required,gt=0"`
+	PassingScore *float64 `json:"passing_score"`
+}
+
+type TestSectionService interface {
+	CreateSection(testID string, req *SectionRequest) (*model.TestSection, error)
+	GetSectionsByTestID(testID string) ([]model.TestSection, error)
+	UpdateSection(id string, req *SectionRequest) (*model.TestSection, error)
+	DeleteSection(id string) error
+}
+
+type testSectionService struct {
+	repo     repository.TestSectionRepository
+	testRepo repository.TestRepository
+}
+
+func NewTestSectionService(repo repository.TestSectionRepository, testRepo repository.TestRepository) TestSectionService {
+	return &testSectionService{repo, testRepo}
+}
+
+func (s *testSectionService) CreateSection(testID string, req *SectionRequest) (*model.TestSection, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	if _, err := s.testRepo.FindByID(testUUID); err != nil {
+		return nil, errors.New("test not found")
+	}
+
+	section := &model.TestSection{
+		ID:           uuid.New(),
+		TestID:       testUUID,
+		Title:        req.Title,
+		Position:     req.Position,
+		Duration:     req.Duration,
+		PassingScore: req.PassingScore,
+	}
+	if err := s.repo.Create(section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+func (s *testSectionService) GetSectionsByTestID(testID string) ([]model.TestSection, error) {
+	testUUID, err := uuid.Parse(testID)
+	if err != nil {
+		return nil, errors.New("invalid test id format")
+	}
+	return s.repo.FindByTestID(testUUID)
+}
+
+func (s *testSectionService) UpdateSection(id string, req *SectionRequest) (*model.TestSection, error) {
+	sectionUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid id format")
+	}
+	section, err := s.repo.FindByID(sectionUUID)
+	if err != nil {
+		return nil, errors.New("section not found")
+	}
+
+	section.Title = req.Title
+	section.Position = req.Position
+	section.Duration = req.Duration
+	section.PassingScore = req.PassingScore
+
+	if err := s.repo.Update(section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+func (s *testSectionService) DeleteSection(id string) error {
+	sectionUUID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid id format")
+	}
+	return s.repo.Delete(sectionUUID)
+}
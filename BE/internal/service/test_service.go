@@ -1,6 +1,10 @@
 package service
 
 import (
+    "errors"
+    "sort"
+    "time"
+
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/model"
     "github.com/Grimarks/Project-TryOutOnline-GDGoC/internal/repository"
     "github.com/google/uuid"
@@ -9,21 +13,97 @@ import (
 type TestService interface {
     CreateTest(test *model.Test) error
     GetAllTests() ([]model.Test, error)
+    GetAvailableTests() ([]PublicTest, error)
     GetTestByID(id string) (*model.Test, error)
+    GetPublicTest(id string) (*PublicTest, error)
     UpdateTest(id string, testData *model.Test) (*model.Test, error)
     DeleteTest(id string) error
+    GetPublishChecklist(id string) ([]ChecklistItem, error)
+    ChangeStatus(id, status string) (*model.Test, []ChecklistItem, error)
 }
 
 type testService struct {
-    repo repository.TestRepository
+    repo         repository.TestRepository
+    questionRepo repository.QuestionRepository
+    eventRepo    repository.EventRepository
+}
+
+func NewTestService(repo repository.TestRepository, questionRepo repository.QuestionRepository, eventRepo repository.EventRepository) TestService {
+    return &testService{repo, questionRepo, eventRepo}
+}
+
+// PublicTest is a test as students see it. Questions come without their keys, and
+// are left out for tests whose paper is only served through an attempt.
+type PublicTest struct {
+    *model.Test
+    Questions []AttemptQuestion `json:"questions"`
 }
 
-func NewTestService(repo repository.TestRepository) TestService {
-    return &testService{repo}
+// attemptOnly reports whether the test can only be taken through the attempt API,
+// which serves and times the paper: tests with sections, a shuffled or drawn paper,
+// adaptive delivery or a tryout event.
+func attemptOnly(test *model.Test, eventRepo repository.EventRepository) (bool, error) {
+    if len(test.Sections) > 0 || test.ShuffleQuestions || test.ShuffleOptions || test.DrawCount > 0 || test.DrawTag != "" || test.DrawDifficulty != "" || test.Adaptive {
+        return true, nil
+    }
+    events, err := eventRepo.CountByTestID(test.ID)
+    if err != nil {
+        return false, err
+    }
+    return events > 0, nil
+}
+
+// publicQuestions strips the keys, explanations and rubrics from questions.
+func publicQuestions(questions []model.Question) []AttemptQuestion {
+    public := make([]AttemptQuestion, len(questions))
+    for i, q := range questions {
+        public[i] = AttemptQuestion{
+            ID:           q.ID,
+            SectionID:    q.SectionID,
+            Type:         q.Type,
+            QuestionText: q.QuestionText,
+            Options:      q.Options,
+            Points:       q.Points,
+        }
+    }
+    return public
+}
+
+func (s *testService) publicTest(test *model.Test) (*PublicTest, error) {
+    only, err := attemptOnly(test, s.eventRepo)
+    if err != nil {
+        return nil, err
+    }
+    public := &PublicTest{Test: test, Questions: []AttemptQuestion{}}
+    if only {
+        return public, nil
+    }
+    // Bank-linked questions are not in test.Questions, so the paper is read the
+    // same way an attempt reads it.
+    questions, err := s.questionRepo.FindByTestID(test.ID)
+    if err != nil {
+        return nil, errors.New("could not retrieve questions for the test")
+    }
+    sort.SliceStable(questions, func(i, j int) bool { return questions[i].Position < questions[j].Position })
+    public.Questions = publicQuestions(questions)
+    return public, nil
 }
 
 func (s *testService) CreateTest(test *model.Test) error {
+    if test.ScoringPolicy == "" {
+        test.ScoringPolicy = model.ScoringPercentCorrect
+    }
+    if test.OfficialResult == "" {
+        test.OfficialResult = model.OfficialBest
+    }
+    if test.ScaleSD <= 0 {
+        test.ScaleMean, test.ScaleSD = 500, 100
+    }
+    if err := checkWindow(test); err != nil {
+        return err
+    }
     test.ID = uuid.New()
+    test.Status = model.TestStatusDraft
     return s.repo.Create(test)
 }
 
@@ -31,6 +111,33 @@ func (s *testService) GetAllTests() ([]model.Test, error) {
     return s.repo.FindAll()
 }
 
+// GetAvailableTests is the public listing: published tests inside their window.
+func (s *testService) GetAvailableTests() ([]PublicTest, error) {
+    tests, err := s.repo.FindAvailable(time.Now())
+    if err != nil {
+        return nil, err
+    }
+    public := make([]PublicTest, 0, len(tests))
+    for i := range tests {
+        view, err := s.publicTest(&tests[i])
+        if err != nil {
+            return nil, err
+        }
+        public = append(public, *view)
+    }
+    return public, nil
+}
+
+// GetPublicTest shows a test to a student. Drafts, tests in review or archived and
+// tests outside their window are reported as not found.
+func (s *testService) GetPublicTest(id string) (*PublicTest, error) {
+    test, err := s.GetTestByID(id)
+    if err != nil || !test.IsAvailable(time.Now()) {
+        return nil, errors.New("test not found")
+    }
+    return s.publicTest(test)
+}
+
 func (s *testService) GetTestByID(id string) (*model.Test, error) {
     uuid, err := uuid.Parse(id)
     if err != nil {
@@ -58,6 +165,36 @@ func (s *testService) UpdateTest(id string, testData *model.Test) (*model.Test,
     existingTest.Duration = testData.Duration
     existingTest.IsPremium = testData.IsPremium
     existingTest.ImageURL = testData.ImageURL
+    if testData.ScoringPolicy != "" {
+        existingTest.ScoringPolicy = testData.ScoringPolicy
+    }
+    existingTest.BlankPenalty = testData.BlankPenalty
+    existingTest.PassingScore = testData.PassingScore
+    existingTest.ShuffleQuestions = testData.ShuffleQuestions
+    existingTest.ShuffleOptions = testData.ShuffleOptions
+    existingTest.DrawCount = testData.DrawCount
+    existingTest.DrawTag = testData.DrawTag
+    existingTest.DrawDifficulty = testData.DrawDifficulty
+    existingTest.AvailableFrom = testData.AvailableFrom
+    existingTest.AvailableUntil = testData.AvailableUntil
+    existingTest.AbilityEstimator = testData.AbilityEstimator
+    existingTest.Adaptive = testData.Adaptive
+    existingTest.AdaptiveMinItems = testData.AdaptiveMinItems
+    existingTest.AdaptiveMaxItems = testData.AdaptiveMaxItems
+    existingTest.AdaptiveStopSE = testData.AdaptiveStopSE
+    existingTest.AdaptiveMaxExposure = testData.AdaptiveMaxExposure
+    existingTest.MaxAttempts = testData.MaxAttempts
+    existingTest.AttemptCooldown = testData.AttemptCooldown
+    if testData.OfficialResult != "" {
+        existingTest.OfficialResult = testData.OfficialResult
+    }
+    if testData.ScaleSD > 0 {
+        existingTest.ScaleMean = testData.ScaleMean
+        existingTest.ScaleSD = testData.ScaleSD
+    }
+    if err := checkWindow(existingTest); err != nil {
+        return nil, err
+    }
     // --- AKHIR PERBAIKAN ---
 
     err = s.repo.Update(existingTest)
@@ -70,4 +207,56 @@ func (s *testService) DeleteTest(id string) error {
         return err
     }
     return s.repo.Delete(uuid)
-}
\ No newline at end of file
+}
+// GetPublishChecklist runs the publish checks without changing the test.
+func (s *testService) GetPublishChecklist(id string) ([]ChecklistItem, error) {
+    test, err := s.GetTestByID(id)
+    if err != nil {
+        return nil, errors.New("test not found")
+    }
+    questions, err := s.questionRepo.FindByTestID(test.ID)
+    if err != nil {
+        return nil, err
+    }
+    return publishChecklist(test, questions), nil
+}
+
+// ChangeStatus moves a test through draft, review, published and archived. Publishing
+// is refused while any checklist item fails; the checklist is returned either way.
+func (s *testService) ChangeStatus(id, status string) (*model.Test, []ChecklistItem, error) {
+    switch status {
+    case model.TestStatusDraft, model.TestStatusReview, model.TestStatusPublished, model.TestStatusArchived:
+    default:
+        return nil, nil, errors.New("invalid status")
+    }
+
+    test, err := s.GetTestByID(id)
+    if err != nil {
+        return nil, nil, errors.New("test not found")
+    }
+
+    var checklist []ChecklistItem
+    if status == model.TestStatusPublished {
+        questions, err := s.questionRepo.FindByTestID(test.ID)
+        if err != nil {
+            return nil, nil, err
+        }
+        checklist = publishChecklist(test, questions)
+        if !checklistPassed(checklist) {
+            return nil, checklist, errors.New("publish checklist failed")
+        }
+    }
+
+    test.Status = status
+    if err := s.repo.Update(test); err != nil {
+        return nil, nil, err
+    }
+    return test, checklist, nil
+}
+
+func checkWindow(test *model.Test) error {
+    if test.AvailableFrom != nil && test.AvailableUntil != nil && !test.AvailableUntil.After(*test.AvailableFrom) {
+        return errors.New("available_until must be after available_from")
+    }
+    return nil
+}
@@ -0,0 +1,69 @@
+// Package certificate issues and checks certificate serial numbers. A serial is a
+// random part followed by an HMAC of that part and the certificate's details, so a
+// serial cannot be guessed and a stored certificate cannot be edited without the
+// check failing.
+package certificate
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+)
+
+const (
+	// Prefix starts every serial.
+	Prefix = "ET"
+	// randomLength and checkLength are in base32 characters (5 bits each).
+	randomLength = 10
+	checkLength  = 8
+)
+
+// Crockford's alphabet leaves out I, L, O and U so serials read back without mix-ups.
+var encoding = base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)
+
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{key: []byte(secret)}
+}
+
+// NewSerial returns a serial such as ET-4K7QD-9XH2M-PZ81C6QA for a certificate with
+// the given details. The same details must be passed to Valid.
+func (s *Signer) NewSerial(details string) (string, error) {
+	raw := make([]byte, randomLength*5/8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	random := encoding.EncodeToString(raw)
+	return strings.Join([]string{Prefix, random[:5], random[5:], s.check(random, details)}, "-"), nil
+}
+
+// Valid reports whether serial was issued by this signer for details.
+func (s *Signer) Valid(serial, details string) bool {
+	parts := strings.Split(Normalize(serial), "-")
+	if len(parts) != 4 || parts[0] != Prefix {
+		return false
+	}
+	random := parts[1] + parts[2]
+	if len(random) != randomLength {
+		return false
+	}
+	return hmac.Equal([]byte(parts[3]), []byte(s.check(random, details)))
+}
+
+// Normalize upper-cases a serial typed in by hand and trims surrounding spaces.
+func Normalize(serial string) string {
+	return strings.ToUpper(strings.TrimSpace(serial))
+}
+
+func (s *Signer) check(random, details string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(random))
+	mac.Write([]byte{0})
+	mac.Write([]byte(details))
+	return encoding.EncodeToString(mac.Sum(nil))[:checkLength]
+}
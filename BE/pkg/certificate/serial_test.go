@@ -0,0 +1,80 @@
+package certificate
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var serialFormat = regexp.MustCompile(`^ET-[0-9A-HJKMNP-TV-Z]{5}-[0-9A-HJKMNP-TV-Z]{5}-[0-9A-HJKMNP-TV-Z]{8}$`)
+
+func TestNewSerial(t *testing.T) {
+	signer := NewSigner("secret")
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		serial, err := signer.NewSerial("result|user|test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !serialFormat.MatchString(serial) {
+			t.Fatalf("serial %q does not match %s", serial, serialFormat)
+		}
+		if seen[serial] {
+			t.Fatalf("serial %q was issued twice", serial)
+		}
+		seen[serial] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	signer := NewSigner("secret")
+	details := "result|user|test|Ana|Tryout|87.50|1700000000"
+	serial, err := signer.NewSerial(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the last character of the check part for another one of the alphabet.
+	replacement := "0"
+	if strings.HasSuffix(serial, replacement) {
+		replacement = "1"
+	}
+	tampered := serial[:len(serial)-1] + replacement
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		serial  string
+		details string
+		want    bool
+	}{
+		{"issued serial", signer, serial, details, true},
+		{"typed in lower case with spaces", signer, "  " + strings.ToLower(serial) + " ", details, true},
+		{"edited details", signer, serial, strings.Replace(details, "87.50", "97.50", 1), false},
+		{"other secret", NewSigner("other"), serial, details, false},
+		{"tampered check", signer, tampered, details, false},
+		{"wrong prefix", signer, "XX" + serial[2:], details, false},
+		{"missing part", signer, serial[:strings.LastIndex(serial, "-")], details, false},
+		{"short random part", signer, serial[:7] + serial[8:], details, false},
+		{"empty", signer, "", details, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Valid(tt.serial, tt.details); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.serial, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		" et-abcde-fghjk-mnpqrstv\n": "ET-ABCDE-FGHJK-MNPQRSTV",
+		"ET-00000-00000-00000000":    "ET-00000-00000-00000000",
+		"":                           "",
+	}
+	for serial, want := range tests {
+		if got := Normalize(serial); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", serial, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package idempotency provides a Fiber middleware that makes retried requests safe.
+//
+// A client sends the same Idempotency-Key header on every retry of one logical
+// request. The first request runs normally and its response is stored in Redis;
+// later requests with the key get that response back instead of running again.
+// Requests that arrive while the first is still running wait for it to finish.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderReplayed is set on responses that were replayed from an earlier request.
+const HeaderReplayed = "Idempotent-Replayed"
+
+type Config struct {
+	Redis *redis.Client
+	// Header carries the key. Defaults to "Idempotency-Key".
+	Header string
+	// Prefix namespaces the Redis keys. Defaults to "idempotency".
+	Prefix string
+	// TTL is how long a response can be replayed. Defaults to 24 hours.
+	TTL time.Duration
+	// LockTTL bounds how long a request may hold its key while it runs, and how long
+	// a concurrent duplicate waits for it. Defaults to 30 seconds.
+	LockTTL time.Duration
+	// Scope partitions keys, e.g. by user, so one client cannot replay another's
+	// responses. Requests with an empty scope share one namespace.
+	Scope func(c *fiber.Ctx) string
+}
+
+// record is what Redis holds for a key: a lock while the first request runs, then
+// its response.
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+const pollInterval = 50 * time.Millisecond
+
+// New returns the middleware. Requests without the header pass straight through.
+// A key reused with a different method, path or body is rejected with 422. Responses
+// with a 5xx status are not stored, so the client can retry them. If Redis cannot be
+// reached the request runs without protection rather than failing.
+func New(cfg Config) fiber.Handler {
+	if cfg.Header == "" {
+		cfg.Header = "Idempotency-Key"
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "idempotency"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = 30 * time.Second
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(cfg.Header)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": cfg.Header + " must be at most 255 characters"})
+		}
+
+		scope := ""
+		if cfg.Scope != nil {
+			scope = cfg.Scope(c)
+		}
+		redisKey := cfg.Prefix + ":" + scope + ":" + key
+		fingerprint := fingerprintOf(c)
+		ctx := context.Background()
+		deadline := time.Now().Add(cfg.LockTTL)
+
+		for {
+			lock, _ := json.Marshal(record{Fingerprint: fingerprint})
+			acquired, err := cfg.Redis.SetNX(ctx, redisKey, lock, cfg.LockTTL).Result()
+			if err != nil {
+				log.Printf("idempotency: redis unavailable, running %s without a key: %v", c.Path(), err)
+				return c.Next()
+			}
+			if acquired {
+				return run(c, cfg, redisKey, fingerprint)
+			}
+
+			stored, err := cfg.Redis.Get(ctx, redisKey).Bytes()
+			if err == redis.Nil {
+				// The first request failed and released the key; try to take it.
+				continue
+			}
+			if err != nil {
+				log.Printf("idempotency: redis unavailable, running %s without a key: %v", c.Path(), err)
+				return c.Next()
+			}
+			var existing record
+			if err := json.Unmarshal(stored, &existing); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Stored idempotent response is invalid"})
+			}
+			if existing.Fingerprint != fingerprint {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": cfg.Header + " was already used for a different request"})
+			}
+			if existing.Done {
+				c.Set(HeaderReplayed, "true")
+				if existing.ContentType != "" {
+					c.Set(fiber.HeaderContentType, existing.ContentType)
+				}
+				return c.Status(existing.Status).Send(existing.Body)
+			}
+			if time.Now().After(deadline) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A request with this " + cfg.Header + " is still in progress"})
+			}
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
+// run handles the request that holds the key and stores its response.
+func run(c *fiber.Ctx, cfg Config, redisKey, fingerprint string) error {
+	ctx := context.Background()
+	if err := c.Next(); err != nil {
+		cfg.Redis.Del(ctx, redisKey)
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		cfg.Redis.Del(ctx, redisKey)
+		return nil
+	}
+	done, err := json.Marshal(record{
+		Fingerprint: fingerprint,
+		Done:        true,
+		Status:      status,
+		ContentType: string(c.Response().Header.ContentType()),
+		Body:        append([]byte(nil), c.Response().Body()...),
+	})
+	if err == nil {
+		err = cfg.Redis.Set(ctx, redisKey, done, cfg.TTL).Err()
+	}
+	if err != nil {
+		log.Printf("idempotency: could not store response for %s: %v", c.Path(), err)
+		cfg.Redis.Del(ctx, redisKey)
+	}
+	return nil
+}
+
+// fingerprintOf identifies a request by method, path and body, so a key cannot be
+// reused for a different request by mistake.
+func fingerprintOf(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
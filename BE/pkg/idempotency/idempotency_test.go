@@ -0,0 +1,279 @@
+package idempotency
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeRedis serves the GET, SET (with NX and a TTL) and DEL commands the middleware
+// uses from a map, so the tests run without a Redis server. TTLs are ignored.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func startFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return client
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, s.execute(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) execute(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToLower(args[0]) {
+	case "get":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+	case "set":
+		for _, option := range args[3:] {
+			if strings.EqualFold(option, "nx") {
+				if _, exists := s.values[args[1]]; exists {
+					return "$-1\r\n"
+				}
+			}
+		}
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "del":
+		_, existed := s.values[args[1]]
+		delete(s.values, args[1])
+		if existed {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+// testApp counts how often the handler runs. A body of "fail" makes it answer 500.
+func testApp(client *redis.Client, calls *int) *fiber.App {
+	app := fiber.New()
+	app.Use(New(Config{Redis: client, LockTTL: 200 * time.Millisecond, Scope: func(c *fiber.Ctx) string { return c.Get("X-User") }}))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		*calls++
+		if string(c.Body()) == "fail" {
+			return c.Status(fiber.StatusInternalServerError).SendString("failed")
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"order": *calls})
+	})
+	return app
+}
+
+type request struct {
+	key, user, body string
+}
+
+type response struct {
+	status   int
+	body     string
+	replayed bool
+}
+
+func send(t *testing.T, app *fiber.App, r request) response {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(r.body))
+	if r.key != "" {
+		req.Header.Set("Idempotency-Key", r.key)
+	}
+	req.Header.Set("X-User", r.user)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return response{status: resp.StatusCode, body: string(body), replayed: resp.Header.Get(HeaderReplayed) == "true"}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requests  []request
+		want      []response
+		wantCalls int
+	}{
+		{
+			name:      "no key runs every time",
+			requests:  []request{{body: "a"}, {body: "a"}},
+			want:      []response{{201, `{"order":1}`, false}, {201, `{"order":2}`, false}},
+			wantCalls: 2,
+		},
+		{
+			name:      "retry is replayed",
+			requests:  []request{{key: "k1", body: "a"}, {key: "k1", body: "a"}},
+			want:      []response{{201, `{"order":1}`, false}, {201, `{"order":1}`, true}},
+			wantCalls: 1,
+		},
+		{
+			name:      "key reused for another body",
+			requests:  []request{{key: "k1", body: "a"}, {key: "k1", body: "b"}},
+			want:      []response{{201, `{"order":1}`, false}, {422, "", false}},
+			wantCalls: 1,
+		},
+		{
+			name:      "keys are scoped per user",
+			requests:  []request{{key: "k1", user: "ana", body: "a"}, {key: "k1", user: "budi", body: "a"}},
+			want:      []response{{201, `{"order":1}`, false}, {201, `{"order":2}`, false}},
+			wantCalls: 2,
+		},
+		{
+			name:      "server errors are not stored",
+			requests:  []request{{key: "k1", body: "fail"}, {key: "k1", body: "fail"}},
+			want:      []response{{500, "failed", false}, {500, "failed", false}},
+			wantCalls: 2,
+		},
+		{
+			name:      "overlong key",
+			requests:  []request{{key: strings.Repeat("k", 256), body: "a"}},
+			want:      []response{{400, "", false}},
+			wantCalls: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			app := testApp(startFakeRedis(t), &calls)
+			for i, r := range tt.requests {
+				got := send(t, app, r)
+				want := tt.want[i]
+				if got.status != want.status || got.replayed != want.replayed || (want.body != "" && got.body != want.body) {
+					t.Errorf("request %d: got %+v, want %+v", i, got, want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRunsWithoutRedis(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	calls := 0
+	app := testApp(client, &calls)
+	for i := 0; i < 2; i++ {
+		if got := send(t, app, request{key: "k1", body: "a"}); got.status != fiber.StatusCreated || got.replayed {
+			t.Errorf("request %d: got %+v, want it to run", i, got)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestMiddlewareRejectsDuplicateStillRunning(t *testing.T) {
+	client := startFakeRedis(t)
+	calls := 0
+	app := testApp(client, &calls)
+
+	// A lock left by a request that is still running.
+	lock := `{"fingerprint":"` + fingerprintFor(t, "a") + `","done":false}`
+	if err := client.Set(client.Context(), "idempotency::k1", lock, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := send(t, app, request{key: "k1", body: "a"}); got.status != fiber.StatusConflict {
+		t.Errorf("got %+v, want 409 after waiting out the lock", got)
+	}
+	if calls != 0 {
+		t.Errorf("handler ran %d times, want 0", calls)
+	}
+}
+
+// fingerprintFor returns the fingerprint the middleware computes for a POST to
+// /orders with the given body.
+func fingerprintFor(t *testing.T, body string) string {
+	t.Helper()
+	app := fiber.New()
+	var fingerprint string
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		fingerprint = fingerprintOf(c)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest("POST", "/orders", strings.NewReader(body))); err != nil {
+		t.Fatal(err)
+	}
+	return fingerprint
+}
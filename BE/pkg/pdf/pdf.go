@@ -0,0 +1,255 @@
+// Package pdf writes simple PDF documents: text in the standard Helvetica fonts,
+// lines and filled rectangles. It covers what the score reports and certificates
+// need without a third-party library or an external renderer.
+//
+// Coordinates are in points (1/72 inch) from the top-left corner of the page, with y
+// growing downwards; the package converts them to PDF's bottom-left origin.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Page sizes in points.
+var (
+	A4          = Size{595.28, 841.89}
+	A4Landscape = Size{841.89, 595.28}
+)
+
+type Size struct {
+	Width, Height float64
+}
+
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = [...]string{"Helvetica", "Helvetica-Bold"}
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Color is an RGB color with components from 0 to 255.
+type Color struct {
+	R, G, B uint8
+}
+
+// Document is a PDF being built. Add pages, draw on them and call WriteTo or Bytes
+// once at the end.
+type Document struct {
+	size  Size
+	title string
+	pages []*Page
+}
+
+func New(size Size, title string) *Document {
+	return &Document{size: size, title: title}
+}
+
+// Page is one page of a document. Drawing methods append to its content stream.
+type Page struct {
+	size     Size
+	content  bytes.Buffer
+	font     Font
+	fontSize float64
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{size: d.size, font: Helvetica, fontSize: 12}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+func (d *Document) Size() Size {
+	return d.size
+}
+
+func (p *Page) SetFont(font Font, size float64) {
+	p.font = font
+	p.fontSize = size
+}
+
+func (p *Page) SetFillColor(c Color) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", component(c.R), component(c.G), component(c.B))
+}
+
+func (p *Page) SetStrokeColor(c Color) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", component(c.R), component(c.G), component(c.B))
+}
+
+func (p *Page) SetLineWidth(width float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(width))
+}
+
+// Text draws s with its baseline at y, positioned against x by align.
+func (p *Page) Text(x, y float64, align Align, s string) {
+	switch align {
+	case AlignCenter:
+		x -= p.TextWidth(s) / 2
+	case AlignRight:
+		x -= p.TextWidth(s)
+	}
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		p.font+1, num(p.fontSize), num(x), num(p.size.Height-y), escape(encode(s)))
+}
+
+// TextWidth is the width of s in the current font and size.
+func (p *Page) TextWidth(s string) float64 {
+	return TextWidth(p.font, p.fontSize, s)
+}
+
+// WrapText splits s into lines no wider than width, breaking at spaces. A single word
+// wider than width gets a line of its own.
+func (p *Page) WrapText(s string, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && p.TextWidth(candidate) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Rect draws a rectangle with its top-left corner at x, y, filled with the fill color
+// and/or outlined with the stroke color.
+func (p *Page) Rect(x, y, width, height float64, fill, stroke bool) {
+	op := "n"
+	switch {
+	case fill && stroke:
+		op = "B"
+	case fill:
+		op = "f"
+	case stroke:
+		op = "S"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(p.size.Height-y-height), num(width), num(height), op)
+}
+
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(p.size.Height-y1), num(x2), num(p.size.Height-y2))
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo renders the document to w. Objects are numbered: 1 catalog, 2 page tree,
+// 3 info, then one object per font, then a page and a content stream per page.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	firstFont := 4
+	firstPage := firstFont + len(fontNames)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (EduTest+) /CreationDate (D:%s) >>",
+		escape(encode(d.title)), time.Now().UTC().Format("20060102150405Z")))
+
+	fonts := make([]string, len(fontNames))
+	for i, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts[i] = fmt.Sprintf("/F%d %d 0 R", i+1, firstFont+i)
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			num(page.size.Width), num(page.size.Height), strings.Join(fonts, " "), firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// encode maps s to WinAnsiEncoding. Latin-1 characters map to themselves; anything
+// else the standard fonts cannot show becomes '?'.
+func encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			b.WriteByte(' ')
+		case r >= 32 && r < 127, r >= 160 && r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+func num(x float64) string {
+	s := fmt.Sprintf("%.2f", x)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+func component(c uint8) string {
+	return num(float64(c) / 255)
+}
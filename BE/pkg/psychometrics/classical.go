@@ -0,0 +1,72 @@
+// Package psychometrics holds the statistics behind item analysis and ability
+// scoring. It works on plain slices and knows nothing about the database.
+package psychometrics
+
+import "math"
+
+// Mean returns the arithmetic mean of xs, or 0 for an empty slice.
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, x := range xs {
+		total += x
+	}
+	return total / float64(len(xs))
+}
+
+// StdDev returns the population standard deviation of xs.
+func StdDev(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	mean := Mean(xs)
+	sum := 0.0
+	for _, x := range xs {
+		sum += (x - mean) * (x - mean)
+	}
+	return math.Sqrt(sum / float64(len(xs)))
+}
+
+// Correlation returns the Pearson correlation of two equally long series. It is 0
+// when either series has no variance.
+func Correlation(xs, ys []float64) float64 {
+	if len(xs) != len(ys) || len(xs) == 0 {
+		return 0
+	}
+	meanX, meanY := Mean(xs), Mean(ys)
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// PointBiserial is the correlation between a dichotomous item score (0 or 1) and a
+// continuous criterion such as the total score. Pass the rest score (total minus
+// the item) to get the corrected item-total correlation.
+func PointBiserial(itemScores, criterion []float64) float64 {
+	return Correlation(itemScores, criterion)
+}
+
+// GroupBounds returns the indexes into an ascending ordering of n scores that mark
+// the lower and upper groups of the classic upper-lower analysis, using the given
+// fraction (usually 0.27) of the group on each side. Both groups have at least one
+// member when n > 0.
+func GroupBounds(n int, fraction float64) (lowerEnd, upperStart int) {
+	size := int(math.Round(float64(n) * fraction))
+	if size < 1 {
+		size = 1
+	}
+	if size > n {
+		size = n
+	}
+	return size, n - size
+}
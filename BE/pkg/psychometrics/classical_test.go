@@ -0,0 +1,80 @@
+package psychometrics
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestMeanAndStdDev(t *testing.T) {
+	tests := []struct {
+		name     string
+		xs       []float64
+		mean, sd float64
+	}{
+		{"empty", nil, 0, 0},
+		{"single", []float64{7}, 7, 0},
+		{"constant", []float64{3, 3, 3}, 3, 0},
+		{"population deviation", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mean(tt.xs); !approx(got, tt.mean, 1e-12) {
+				t.Errorf("Mean = %v, want %v", got, tt.mean)
+			}
+			if got := StdDev(tt.xs); !approx(got, tt.sd, 1e-12) {
+				t.Errorf("StdDev = %v, want %v", got, tt.sd)
+			}
+		})
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs, ys []float64
+		want   float64
+	}{
+		{"perfect", []float64{1, 2, 3, 4}, []float64{2, 4, 6, 8}, 1},
+		{"inverse", []float64{1, 2, 3, 4}, []float64{8, 6, 4, 2}, -1},
+		{"uncorrelated", []float64{1, 2, 3, 4}, []float64{1, -1, -1, 1}, 0},
+		{"no variance", []float64{1, 1, 1}, []float64{1, 2, 3}, 0},
+		{"length mismatch", []float64{1, 2}, []float64{1, 2, 3}, 0},
+		{"empty", nil, nil, 0},
+		{"point biserial", []float64{0, 0, 1, 1, 1}, []float64{2, 3, 3, 4, 5}, 0.7205766921228921},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Correlation(tt.xs, tt.ys); !approx(got, tt.want, 1e-9) {
+				t.Errorf("Correlation = %v, want %v", got, tt.want)
+			}
+			if got := PointBiserial(tt.xs, tt.ys); !approx(got, tt.want, 1e-9) {
+				t.Errorf("PointBiserial = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupBounds(t *testing.T) {
+	tests := []struct {
+		n                    int
+		fraction             float64
+		lowerEnd, upperStart int
+	}{
+		{100, 0.27, 27, 73},
+		{10, 0.27, 3, 7},
+		{3, 0.27, 1, 2},
+		{1, 0.27, 1, 0},
+		{2, 0.9, 2, 0},
+		{0, 0.27, 0, 0},
+	}
+	for _, tt := range tests {
+		lowerEnd, upperStart := GroupBounds(tt.n, tt.fraction)
+		if lowerEnd != tt.lowerEnd || upperStart != tt.upperStart {
+			t.Errorf("GroupBounds(%d, %v) = %d, %d, want %d, %d", tt.n, tt.fraction, lowerEnd, upperStart, tt.lowerEnd, tt.upperStart)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package psychometrics
+
+import (
+	"errors"
+	"math"
+)
+
+// D is the scaling constant that brings the logistic curve close to the normal ogive.
+const D = 1.702
+
+const (
+	Model1PL = "1PL"
+	Model2PL = "2PL"
+	Model3PL = "3PL"
+)
+
+// Missing marks an item the examinee was not given. Any other response value below
+// one counts as incorrect.
+const Missing = -1
+
+// ItemParams are the discrimination (A), difficulty (B) and pseudo-guessing (C)
+// parameters of an item. The 1PL model fixes A at 1 and C at 0; 2PL fixes C at 0.
+type ItemParams struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+	C float64 `json:"c"`
+}
+
+// Probability is the chance that an examinee of ability theta answers the item
+// correctly.
+func Probability(theta float64, item ItemParams) float64 {
+	logistic := 1 / (1 + math.Exp(-D*item.A*(theta-item.B)))
+	return item.C + (1-item.C)*logistic
+}
+
+// Information is the Fisher information the item gives about theta.
+func Information(theta float64, item ItemParams) float64 {
+	p := clampProbability(Probability(theta, item))
+	ratio := (p - item.C) / (1 - item.C)
+	return D * D * item.A * item.A * (1 - p) / p * ratio * ratio
+}
+
+// Quadrature is a set of ability points with prior weights that sum to one.
+type Quadrature struct {
+	Points  []float64
+	Weights []float64
+}
+
+// NormalQuadrature spreads n points evenly over [-4, 4] weighted by the standard
+// normal density.
+func NormalQuadrature(n int) Quadrature {
+	if n < 2 {
+		n = 2
+	}
+	q := Quadrature{Points: make([]float64, n), Weights: make([]float64, n)}
+	total := 0.0
+	for k := 0; k < n; k++ {
+		theta := -4 + 8*float64(k)/float64(n-1)
+		q.Points[k] = theta
+		q.Weights[k] = math.Exp(-theta * theta / 2)
+		total += q.Weights[k]
+	}
+	for k := range q.Weights {
+		q.Weights[k] /= total
+	}
+	return q
+}
+
+// Ability is an ability estimate with its standard error.
+type Ability struct {
+	Theta float64 `json:"theta"`
+	SE    float64 `json:"se"`
+}
+
+// EAP estimates ability as the mean of the posterior over the quadrature points,
+// with the posterior standard deviation as SE. It is defined for every response
+// pattern, including all correct or all wrong.
+func EAP(responses []int, items []ItemParams, q Quadrature) Ability {
+	logPosterior := make([]float64, len(q.Points))
+	for k, theta := range q.Points {
+		logPosterior[k] = math.Log(q.Weights[k]) + logLikelihood(theta, responses, items)
+	}
+	posterior := normalizeLog(logPosterior)
+
+	var mean, second float64
+	for k, theta := range q.Points {
+		mean += posterior[k] * theta
+		second += posterior[k] * theta * theta
+	}
+	return Ability{Theta: mean, SE: math.Sqrt(math.Max(second-mean*mean, 0))}
+}
+
+// MLE estimates ability by Newton-Raphson on the likelihood. It reports false when
+// the estimate does not exist, which happens for all-correct and all-wrong patterns
+// and when no item was answered; callers should fall back to EAP.
+func MLE(responses []int, items []ItemParams) (Ability, bool) {
+	correct, answered := 0, 0
+	for _, u := range responses {
+		if u == Missing {
+			continue
+		}
+		answered++
+		if u > 0 {
+			correct++
+		}
+	}
+	if answered == 0 || correct == 0 || correct == answered {
+		return Ability{}, false
+	}
+
+	theta := 0.0
+	for iteration := 0; iteration < 50; iteration++ {
+		var gradient, information float64
+		for j, u := range responses {
+			if u == Missing {
+				continue
+			}
+			item := items[j]
+			p := clampProbability(Probability(theta, item))
+			logistic := (p - item.C) / (1 - item.C)
+			// Derivative of P with respect to theta.
+			dp := D * item.A * (1 - item.C) * logistic * (1 - logistic)
+			score := 0.0
+			if u > 0 {
+				score = 1
+			}
+			gradient += (score - p) * dp / (p * (1 - p))
+			information += dp * dp / (p * (1 - p))
+		}
+		if information <= 0 {
+			return Ability{}, false
+		}
+		step := gradient / information
+		theta = math.Max(-6, math.Min(6, theta+step))
+		if math.Abs(step) < 1e-6 {
+			return Ability{Theta: theta, SE: 1 / math.Sqrt(information)}, true
+		}
+	}
+	return Ability{}, false
+}
+
+// TestInformation sums the information of the answered items at theta.
+func TestInformation(theta float64, responses []int, items []ItemParams) float64 {
+	total := 0.0
+	for j, u := range responses {
+		if u != Missing {
+			total += Information(theta, items[j])
+		}
+	}
+	return total
+}
+
+type CalibrationOptions struct {
+	Model     string
+	MaxCycles int
+	Tolerance float64
+	Points    int
+}
+
+type CalibrationResult struct {
+	Items     []ItemParams
+	Cycles    int
+	Converged bool
+}
+
+// Calibrate estimates item parameters by marginal maximum likelihood with the EM
+// algorithm (Bock and Aitkin), assuming a standard normal ability distribution.
+// responses[i][j] is 1 for correct, 0 for incorrect and Missing when examinee i
+// was not given item j. Weak priors on A (log-normal), B (normal) and C (beta)
+// keep items that nearly everyone gets right or wrong from drifting off.
+func Calibrate(responses [][]int, items int, opts CalibrationOptions) (CalibrationResult, error) {
+	switch opts.Model {
+	case Model1PL, Model2PL, Model3PL:
+	default:
+		return CalibrationResult{}, errors.New("model must be 1PL, 2PL or 3PL")
+	}
+	if len(responses) == 0 || items == 0 {
+		return CalibrationResult{}, errors.New("no responses to calibrate")
+	}
+	if opts.MaxCycles <= 0 {
+		opts.MaxCycles = 200
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 1e-3
+	}
+	if opts.Points <= 0 {
+		opts.Points = 41
+	}
+	q := NormalQuadrature(opts.Points)
+
+	params := initialParams(responses, items, opts.Model)
+	result := CalibrationResult{Items: params}
+	expectedTotal := make([][]float64, items)
+	expectedCorrect := make([][]float64, items)
+	for j := range expectedTotal {
+		expectedTotal[j] = make([]float64, len(q.Points))
+		expectedCorrect[j] = make([]float64, len(q.Points))
+	}
+
+	// Log probabilities at each quadrature point are computed once per cycle rather
+	// than once per examinee.
+	logCorrect := make([][]float64, items)
+	logWrong := make([][]float64, items)
+	for j := range logCorrect {
+		logCorrect[j] = make([]float64, len(q.Points))
+		logWrong[j] = make([]float64, len(q.Points))
+	}
+	logWeights := make([]float64, len(q.Points))
+	for k, w := range q.Weights {
+		logWeights[k] = math.Log(w)
+	}
+	logPosterior := make([]float64, len(q.Points))
+	for cycle := 1; cycle <= opts.MaxCycles; cycle++ {
+		// E step: expected examinees and correct answers at each ability point.
+		for j := range expectedTotal {
+			for k := range q.Points {
+				expectedTotal[j][k], expectedCorrect[j][k] = 0, 0
+			}
+		}
+		for j := range params {
+			for k, theta := range q.Points {
+				p := clampProbability(Probability(theta, params[j]))
+				logCorrect[j][k], logWrong[j][k] = math.Log(p), math.Log(1-p)
+			}
+		}
+		for _, row := range responses {
+			for k := range q.Points {
+				logPosterior[k] = logWeights[k]
+			}
+			for j, u := range row {
+				switch {
+				case u == Missing:
+				case u > 0:
+					for k := range q.Points {
+						logPosterior[k] += logCorrect[j][k]
+					}
+				default:
+					for k := range q.Points {
+						logPosterior[k] += logWrong[j][k]
+					}
+				}
+			}
+			posterior := normalizeLog(logPosterior)
+			for j, u := range row {
+				if u == Missing {
+					continue
+				}
+				for k := range q.Points {
+					expectedTotal[j][k] += posterior[k]
+					if u > 0 {
+						expectedCorrect[j][k] += posterior[k]
+					}
+				}
+			}
+		}
+
+		// M step: refit every item against its expected counts.
+		maxChange := 0.0
+		for j := range params {
+			next := maximizeItem(params[j], q.Points, expectedTotal[j], expectedCorrect[j], opts.Model)
+			maxChange = math.Max(maxChange, math.Abs(next.A-params[j].A))
+			maxChange = math.Max(maxChange, math.Abs(next.B-params[j].B))
+			maxChange = math.Max(maxChange, math.Abs(next.C-params[j].C))
+			params[j] = next
+		}
+		result.Cycles = cycle
+		if maxChange < opts.Tolerance {
+			result.Converged = true
+			break
+		}
+	}
+	result.Items = params
+	return result, nil
+}
+
+func initialParams(responses [][]int, items int, model string) []ItemParams {
+	params := make([]ItemParams, items)
+	for j := range params {
+		var correct, answered float64
+		for _, row := range responses {
+			if row[j] == Missing {
+				continue
+			}
+			answered++
+			if row[j] > 0 {
+				correct++
+			}
+		}
+		p := 0.5
+		if answered > 0 {
+			p = math.Max(0.02, math.Min(0.98, correct/answered))
+		}
+		params[j] = ItemParams{A: 1, B: -math.Log(p/(1-p)) / D}
+		if model == Model3PL {
+			params[j].C = 0.2
+		}
+	}
+	return params
+}
+
+// itemObjective is the expected log-likelihood of one item plus its log priors.
+func itemObjective(item ItemParams, points, total, correct []float64, model string) float64 {
+	f := 0.0
+	for k, theta := range points {
+		p := clampProbability(Probability(theta, item))
+		f += correct[k]*math.Log(p) + (total[k]-correct[k])*math.Log(1-p)
+	}
+	if model != Model1PL {
+		logA := math.Log(item.A)
+		f += -logA*logA/(2*0.25) - logA
+	}
+	f += -item.B * item.B / (2 * 4)
+	if model == Model3PL {
+		f += 4*math.Log(item.C) + 16*math.Log(1-item.C)
+	}
+	return f
+}
+
+// maximizeItem improves an item's parameters by gradient ascent with step halving.
+func maximizeItem(item ItemParams, points, total, correct []float64, model string) ItemParams {
+	current := itemObjective(item, points, total, correct, model)
+	for iteration := 0; iteration < 25; iteration++ {
+		var gradA, gradB, gradC float64
+		for k, theta := range points {
+			p := clampProbability(Probability(theta, item))
+			logistic := (p - item.C) / (1 - item.C)
+			weight := correct[k]/p - (total[k]-correct[k])/(1-p)
+			gradA += weight * (1 - item.C) * logistic * (1 - logistic) * D * (theta - item.B)
+			gradB += weight * -(1 - item.C) * logistic * (1 - logistic) * D * item.A
+			gradC += weight * (1 - logistic)
+		}
+		gradA += -math.Log(item.A)/(0.25*item.A) - 1/item.A
+		gradB += -item.B / 4
+		gradC += 4/item.C - 16/(1-item.C)
+		if model == Model1PL {
+			gradA = 0
+		}
+		if model != Model3PL {
+			gradC = 0
+		}
+
+		norm := math.Sqrt(gradA*gradA + gradB*gradB + gradC*gradC)
+		if norm < 1e-8 {
+			break
+		}
+		improved := false
+		for step := 0.5 / norm; step > 1e-6; step /= 2 {
+			candidate := clampParams(ItemParams{A: item.A + step*gradA, B: item.B + step*gradB, C: item.C + step*gradC}, model)
+			if value := itemObjective(candidate, points, total, correct, model); value > current {
+				item, current, improved = candidate, value, true
+				break
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return item
+}
+
+func clampParams(item ItemParams, model string) ItemParams {
+	item.A = math.Max(0.1, math.Min(4, item.A))
+	item.B = math.Max(-5, math.Min(5, item.B))
+	item.C = math.Max(0.001, math.Min(0.5, item.C))
+	if model == Model1PL {
+		item.A = 1
+	}
+	if model != Model3PL {
+		item.C = 0
+	}
+	return item
+}
+
+func logLikelihood(theta float64, responses []int, items []ItemParams) float64 {
+	total := 0.0
+	for j, u := range responses {
+		if u == Missing {
+			continue
+		}
+		p := clampProbability(Probability(theta, items[j]))
+		if u > 0 {
+			total += math.Log(p)
+		} else {
+			total += math.Log(1 - p)
+		}
+	}
+	return total
+}
+
+// normalizeLog turns log weights into probabilities that sum to one.
+func normalizeLog(logWeights []float64) []float64 {
+	maxLog := math.Inf(-1)
+	for _, w := range logWeights {
+		maxLog = math.Max(maxLog, w)
+	}
+	weights := make([]float64, len(logWeights))
+	total := 0.0
+	for k, w := range logWeights {
+		weights[k] = math.Exp(w - maxLog)
+		total += weights[k]
+	}
+	for k := range weights {
+		weights[k] /= total
+	}
+	return weights
+}
+
+func clampProbability(p float64) float64 {
+	return math.Max(1e-9, math.Min(1-1e-9, p))
+}
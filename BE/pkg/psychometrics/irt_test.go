@@ -0,0 +1,182 @@
+package psychometrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestProbability(t *testing.T) {
+	tests := []struct {
+		name  string
+		theta float64
+		item  ItemParams
+		want  float64
+	}{
+		{"at difficulty", 0.5, ItemParams{A: 1, B: 0.5}, 0.5},
+		{"at difficulty with guessing", 0, ItemParams{A: 1.3, C: 0.2}, 0.6},
+		{"far above difficulty", 10, ItemParams{A: 1}, 1},
+		{"far below difficulty keeps the guessing floor", -10, ItemParams{A: 1, C: 0.25}, 0.25},
+		{"one unit above", 1, ItemParams{A: 1}, 1 / (1 + math.Exp(-D))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Probability(tt.theta, tt.item); !approx(got, tt.want, 1e-6) {
+				t.Errorf("Probability = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInformationPeaksAtDifficultyWithoutGuessing(t *testing.T) {
+	item := ItemParams{A: 1.5, B: 0.7}
+	peak := Information(item.B, item)
+	if want := D * D * item.A * item.A / 4; !approx(peak, want, 1e-9) {
+		t.Errorf("information at b = %v, want %v", peak, want)
+	}
+	for _, theta := range []float64{-2, 0, 0.6, 0.8, 2} {
+		if Information(theta, item) >= peak {
+			t.Errorf("information at %v is not below the peak at b", theta)
+		}
+	}
+
+	responses := []int{1, Missing, 0}
+	items := []ItemParams{item, {A: 1}, {A: 0.5, B: -1}}
+	if got, want := TestInformation(0, responses, items), Information(0, items[0])+Information(0, items[2]); !approx(got, want, 1e-12) {
+		t.Errorf("TestInformation = %v, want %v without the missing item", got, want)
+	}
+}
+
+func TestNormalQuadrature(t *testing.T) {
+	for _, n := range []int{0, 2, 41} {
+		q := NormalQuadrature(n)
+		if len(q.Points) < 2 || len(q.Points) != len(q.Weights) {
+			t.Fatalf("NormalQuadrature(%d) has %d points and %d weights", n, len(q.Points), len(q.Weights))
+		}
+		total, mean := 0.0, 0.0
+		for k, w := range q.Weights {
+			total += w
+			mean += w * q.Points[k]
+		}
+		if !approx(total, 1, 1e-12) || !approx(mean, 0, 1e-12) {
+			t.Errorf("NormalQuadrature(%d): weights sum to %v with mean %v", n, total, mean)
+		}
+		if q.Points[0] != -4 || q.Points[len(q.Points)-1] != 4 {
+			t.Errorf("NormalQuadrature(%d) spans %v to %v, want -4 to 4", n, q.Points[0], q.Points[len(q.Points)-1])
+		}
+	}
+}
+
+func TestAbilityEstimates(t *testing.T) {
+	items := []ItemParams{{A: 1, B: -1}, {A: 1, B: 0}, {A: 1, B: 1}, {A: 1.2, B: 0.5}}
+	q := NormalQuadrature(41)
+
+	tests := []struct {
+		name      string
+		responses []int
+		mleOK     bool
+		sign      float64
+	}{
+		{"all correct", []int{1, 1, 1, 1}, false, 1},
+		{"all wrong", []int{0, 0, 0, 0}, false, -1},
+		{"nothing answered", []int{Missing, Missing, Missing, Missing}, false, 0},
+		{"easy right, hard wrong", []int{1, 1, 0, 0}, true, 0},
+		{"mostly right", []int{1, 1, 1, 0}, true, 1},
+		{"mostly wrong", []int{1, 0, 0, 0}, true, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eap := EAP(tt.responses, items, q)
+			if eap.SE <= 0 || eap.SE > 1 {
+				t.Errorf("EAP SE = %v, want within (0, 1]", eap.SE)
+			}
+			checkSign(t, "EAP", eap.Theta, tt.sign)
+
+			mle, ok := MLE(tt.responses, items)
+			if ok != tt.mleOK {
+				t.Fatalf("MLE ok = %v, want %v", ok, tt.mleOK)
+			}
+			if ok {
+				checkSign(t, "MLE", mle.Theta, tt.sign)
+				// EAP shrinks towards the prior mean, MLE does not.
+				if math.Abs(mle.Theta) < math.Abs(eap.Theta)-1e-9 {
+					t.Errorf("MLE %v is closer to zero than EAP %v", mle.Theta, eap.Theta)
+				}
+				if want := 1 / math.Sqrt(TestInformation(mle.Theta, tt.responses, items)); !approx(mle.SE, want, 1e-6) {
+					t.Errorf("MLE SE = %v, want %v", mle.SE, want)
+				}
+			}
+		})
+	}
+}
+
+// checkSign checks that theta is above zero, below zero or close to zero.
+func checkSign(t *testing.T, name string, theta, sign float64) {
+	t.Helper()
+	switch {
+	case sign > 0 && theta <= 0, sign < 0 && theta >= 0, sign == 0 && math.Abs(theta) > 0.35:
+		t.Errorf("%s theta = %v, want sign %v", name, theta, sign)
+	}
+}
+
+func TestCalibrateRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses [][]int
+		items     int
+		model     string
+	}{
+		{"unknown model", [][]int{{1}}, 1, "4PL"},
+		{"no responses", nil, 1, Model2PL},
+		{"no items", [][]int{{}}, 0, Model1PL},
+	}
+	for _, tt := range tests {
+		if _, err := Calibrate(tt.responses, tt.items, CalibrationOptions{Model: tt.model}); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestCalibrateRecoversSimulatedItems(t *testing.T) {
+	truth := []ItemParams{{A: 1, B: -1.5}, {A: 1, B: -0.5}, {A: 1, B: 0}, {A: 1, B: 0.5}, {A: 1, B: 1.5}}
+	rng := rand.New(rand.NewSource(7))
+	responses := make([][]int, 1500)
+	for i := range responses {
+		theta := rng.NormFloat64()
+		responses[i] = make([]int, len(truth))
+		for j, item := range truth {
+			switch {
+			case i%10 == 0 && j == 2:
+				responses[i][j] = Missing
+			case rng.Float64() < Probability(theta, item):
+				responses[i][j] = 1
+			}
+		}
+	}
+
+	for _, model := range []string{Model1PL, Model2PL} {
+		t.Run(model, func(t *testing.T) {
+			result, err := Calibrate(responses, len(truth), CalibrationOptions{Model: model})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Converged {
+				t.Errorf("did not converge in %d cycles", result.Cycles)
+			}
+			for j, item := range result.Items {
+				if !approx(item.B, truth[j].B, 0.25) {
+					t.Errorf("item %d: b = %.3f, want about %.3f", j, item.B, truth[j].B)
+				}
+				if model == Model1PL && item.A != 1 {
+					t.Errorf("item %d: 1PL a = %v, want 1", j, item.A)
+				}
+				if model == Model2PL && !approx(item.A, truth[j].A, 0.35) {
+					t.Errorf("item %d: a = %.3f, want about %.3f", j, item.A, truth[j].A)
+				}
+				if item.C != 0 {
+					t.Errorf("item %d: c = %v, want 0 outside 3PL", j, item.C)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package psychometrics
+
+import (
+	"math"
+	"sort"
+)
+
+// minShare is the lowest expected share of a source's wrong answers that any
+// examinee is assumed to reproduce by chance.
+const minShare = 0.01
+
+// SimilarityOptions tune AnswerSimilarity. Alpha is the family-wise error rate below
+// which a pair is reported (default 0.001). MinSourceWrong skips sources with fewer wrong answers
+// than this (default 3), since a handful of shared mistakes means nothing.
+type SimilarityOptions struct {
+	Alpha          float64
+	MinSourceWrong int
+}
+
+// SimilarPair is a pair of examinees whose shared wrong answers are unlikely to be a
+// coincidence. Source is the examinee whose wrong answers were matched and Copier
+// the one matching them; the labels only name the direction with the stronger
+// evidence, not who copied from whom. PValue is already Bonferroni adjusted.
+type SimilarPair struct {
+	Source           int     `json:"source"`
+	Copier           int     `json:"copier"`
+	SourceWrong      int     `json:"source_wrong"`
+	CopierWrong      int     `json:"copier_wrong"`
+	IdenticalWrong   int     `json:"identical_wrong"`
+	IdenticalAnswers int     `json:"identical_answers"`
+	Expected         float64 `json:"expected"`
+	PValue           float64 `json:"p_value"`
+}
+
+// AnswerSimilarity looks for pairs of examinees who share more identical wrong answers
+// than chance allows, in the style of the K-index. responses[r][i] is the option
+// examinee r chose on item i and key[i] the correct option; negative values are
+// blank or not served and never match.
+//
+// For every source s with W wrong answers, each other examinee j is described by
+// their own number of wrong answers and the share of s's wrong answers they
+// reproduced. A least squares line through those points gives the share expected
+// from an examinee with a given number of wrong answers (the K2 variant of the
+// K-index, which works without large groups of equal wrong counts). The number of
+// matches is then compared with a binomial with W trials. Pairs are tested in both
+// directions, so a group of n examinees runs n*(n-1) tests; each p-value is
+// multiplied by that count (Bonferroni) before it is compared with Alpha, keeping the
+// chance of listing any innocent pair at Alpha however large the group. Pairs are
+// reported once, most significant first.
+func AnswerSimilarity(responses [][]int, key []int, opts SimilarityOptions) []SimilarPair {
+	if opts.Alpha <= 0 {
+		opts.Alpha = 0.001
+	}
+	if opts.MinSourceWrong <= 0 {
+		opts.MinSourceWrong = 3
+	}
+	n := len(responses)
+	tests := float64(n) * float64(n-1)
+	wrong := make([]int, n)
+	for r, row := range responses {
+		for i, answer := range row {
+			if i < len(key) && answer >= 0 && answer != key[i] {
+				wrong[r]++
+			}
+		}
+	}
+
+	best := make(map[[2]int]SimilarPair)
+	matches := make([]int, n)
+	for s := range responses {
+		if wrong[s] < opts.MinSourceWrong {
+			continue
+		}
+		var wrongItems []int
+		for i, answer := range responses[s] {
+			if i < len(key) && answer >= 0 && answer != key[i] {
+				wrongItems = append(wrongItems, i)
+			}
+		}
+		for j := range responses {
+			matches[j] = 0
+			if j == s {
+				continue
+			}
+			for _, i := range wrongItems {
+				if i < len(responses[j]) && responses[j][i] == responses[s][i] {
+					matches[j]++
+				}
+			}
+		}
+
+		slope, intercept := shareLine(wrong, matches, s, float64(wrong[s]))
+		for c := range responses {
+			if c == s || matches[c] == 0 {
+				continue
+			}
+			share := intercept + slope*float64(wrong[c])
+			// The fitted line can dip towards zero for examinees with few wrong
+			// answers; a floor keeps two shared mistakes from looking impossible.
+			share = math.Min(math.Max(share, minShare), 1-minShare)
+			p := math.Min(BinomialTail(wrong[s], matches[c], share)*tests, 1)
+			if p >= opts.Alpha {
+				continue
+			}
+			pair := [2]int{s, c}
+			if c < s {
+				pair = [2]int{c, s}
+			}
+			if current, ok := best[pair]; ok && current.PValue <= p {
+				continue
+			}
+			best[pair] = SimilarPair{
+				Source:           s,
+				Copier:           c,
+				SourceWrong:      wrong[s],
+				CopierWrong:      wrong[c],
+				IdenticalWrong:   matches[c],
+				IdenticalAnswers: identicalAnswers(responses[s], responses[c]),
+				Expected:         float64(wrong[s]) * share,
+				PValue:           p,
+			}
+		}
+	}
+
+	pairs := make([]SimilarPair, 0, len(best))
+	for _, pair := range best {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].PValue != pairs[j].PValue {
+			return pairs[i].PValue < pairs[j].PValue
+		}
+		if pairs[i].Source != pairs[j].Source {
+			return pairs[i].Source < pairs[j].Source
+		}
+		return pairs[i].Copier < pairs[j].Copier
+	})
+	return pairs
+}
+
+// shareLine fits matches[j]/total against wrong[j] over every examinee except source.
+func shareLine(wrong, matches []int, source int, total float64) (slope, intercept float64) {
+	var count, sumX, sumY, sumXX, sumXY float64
+	for j := range wrong {
+		if j == source {
+			continue
+		}
+		x, y := float64(wrong[j]), float64(matches[j])/total
+		count++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	denominator := count*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, sumY / count
+	}
+	slope = (count*sumXY - sumX*sumY) / denominator
+	return slope, (sumY - slope*sumX) / count
+}
+
+func identicalAnswers(a, b []int) int {
+	same := 0
+	for i := range a {
+		if i < len(b) && a[i] >= 0 && a[i] == b[i] {
+			same++
+		}
+	}
+	return same
+}
+
+// BinomialTail is the probability of at least k successes in n trials with success
+// probability p.
+func BinomialTail(n, k int, p float64) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if k > n {
+		return 0
+	}
+	logP, logQ := math.Log(p), math.Log1p(-p)
+	lgN, _ := math.Lgamma(float64(n + 1))
+	total := 0.0
+	for x := k; x <= n; x++ {
+		lgX, _ := math.Lgamma(float64(x + 1))
+		lgRest, _ := math.Lgamma(float64(n - x + 1))
+		total += math.Exp(lgN - lgX - lgRest + float64(x)*logP + float64(n-x)*logQ)
+	}
+	return math.Min(total, 1)
+}
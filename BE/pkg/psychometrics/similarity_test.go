@@ -0,0 +1,130 @@
+package psychometrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBinomialTail(t *testing.T) {
+	tests := []struct {
+		name string
+		n, k int
+		p    float64
+		want float64
+	}{
+		{"no successes needed", 10, 0, 0.3, 1},
+		{"more successes than trials", 3, 4, 0.5, 0},
+		{"all heads", 3, 3, 0.5, 0.125},
+		{"at least two of three", 3, 2, 0.5, 0.5},
+		{"at least one of four", 4, 1, 0.1, 1 - 0.9*0.9*0.9*0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BinomialTail(tt.n, tt.k, tt.p); !approx(got, tt.want, 1e-12) {
+				t.Errorf("BinomialTail(%d, %d, %v) = %v, want %v", tt.n, tt.k, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+// similarityGroup simulates independent examinees answering items with four
+// options, each correct with a chance that depends on their ability.
+func similarityGroup(examinees, items int, seed int64) ([][]int, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	key := make([]int, items)
+	for i := range key {
+		key[i] = rng.Intn(4)
+	}
+	responses := make([][]int, examinees)
+	for r := range responses {
+		ability := 0.3 + 0.5*rng.Float64()
+		responses[r] = make([]int, items)
+		for i := range key {
+			switch {
+			case rng.Float64() < 0.03:
+				responses[r][i] = -1
+			case rng.Float64() < ability:
+				responses[r][i] = key[i]
+			default:
+				responses[r][i] = (key[i] + 1 + rng.Intn(3)) % 4
+			}
+		}
+	}
+	return responses, key
+}
+
+func TestAnswerSimilarity(t *testing.T) {
+	tests := []struct {
+		name      string
+		copied    int
+		wantPairs int
+	}{
+		{"independent examinees", 0, 0},
+		{"copied wrong answers", 25, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses, key := similarityGroup(60, 60, 3)
+			source, copier := 4, 17
+			copied := 0
+			for i := range key {
+				if copied == tt.copied {
+					break
+				}
+				if responses[source][i] >= 0 && responses[source][i] != key[i] {
+					responses[copier][i] = responses[source][i]
+					copied++
+				}
+			}
+
+			pairs := AnswerSimilarity(responses, key, SimilarityOptions{})
+			if len(pairs) != tt.wantPairs {
+				t.Fatalf("got %d pairs, want %d: %+v", len(pairs), tt.wantPairs, pairs)
+			}
+			for _, pair := range pairs {
+				members := map[int]bool{pair.Source: true, pair.Copier: true}
+				if !members[source] || !members[copier] {
+					t.Errorf("flagged %d and %d, want %d and %d", pair.Source, pair.Copier, source, copier)
+				}
+				if pair.PValue >= 0.001 || pair.IdenticalWrong < copied || pair.Expected >= float64(pair.IdenticalWrong) {
+					t.Errorf("pair = %+v", pair)
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerSimilarityCorrectsForGroupSize(t *testing.T) {
+	responses, key := similarityGroup(50, 60, 5)
+	for i := range key {
+		if responses[0][i] >= 0 && responses[0][i] != key[i] {
+			responses[1][i] = responses[0][i]
+		}
+	}
+
+	pairs := AnswerSimilarity(responses, key, SimilarityOptions{})
+	if len(pairs) == 0 {
+		t.Fatal("copied answers were not flagged")
+	}
+	tests := float64(len(responses) * (len(responses) - 1))
+	for _, pair := range pairs {
+		share := pair.Expected / float64(pair.SourceWrong)
+		raw := BinomialTail(pair.SourceWrong, pair.IdenticalWrong, share)
+		if want := math.Min(raw*tests, 1); !approx(pair.PValue, want, want*1e-9) {
+			t.Errorf("PValue = %v, want the raw %v times %v tests", pair.PValue, raw, tests)
+		}
+	}
+}
+
+func TestAnswerSimilaritySkipsSourcesWithFewWrongAnswers(t *testing.T) {
+	key := []int{0, 0, 0, 0, 0, 0}
+	responses := [][]int{
+		{1, 1, 0, 0, 0, 0},
+		{1, 1, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0},
+	}
+	if pairs := AnswerSimilarity(responses, key, SimilarityOptions{Alpha: 1}); len(pairs) != 0 {
+		t.Errorf("got %+v, want no pairs for sources with two wrong answers", pairs)
+	}
+}
@@ -0,0 +1,173 @@
+package questionimport
+
+import (
+	"strings"
+)
+
+// parseGIFT reads the Moodle GIFT subset that maps onto our question types:
+// multiple choice ({=right ~wrong}), true/false ({T} / {F}) and essay ({}).
+// Titles (::title::), per-answer feedback (#...) and general feedback (####...) are
+// accepted; general feedback becomes the explanation. $CATEGORY lines become tags.
+func parseGIFT(text string) ([]Item, []RowError) {
+	var items []Item
+	var rowErrors []RowError
+
+	category := ""
+	for n, block := range splitGIFTBlocks(text) {
+		row := n + 1
+		if strings.HasPrefix(block, "$CATEGORY:") {
+			category = strings.TrimSpace(strings.TrimPrefix(block, "$CATEGORY:"))
+			if slash := strings.LastIndex(category, "/"); slash >= 0 {
+				category = category[slash+1:]
+			}
+			continue
+		}
+
+		open := indexUnescaped(block, '{', 0)
+		closeAt := indexUnescaped(block, '}', open+1)
+		if open < 0 || closeAt < 0 {
+			rowErrors = append(rowErrors, RowError{Row: row, Message: "answer block {...} is missing"})
+			continue
+		}
+
+		stem := block[:open]
+		if strings.HasPrefix(strings.TrimSpace(stem), "::") {
+			stem = strings.TrimSpace(stem)[2:]
+			if end := strings.Index(stem, "::"); end >= 0 {
+				stem = stem[end+2:]
+			}
+		}
+		stem = strings.TrimSpace(stripGIFTFormat(stem) + " " + strings.TrimSpace(block[closeAt+1:]))
+
+		item := Item{Row: row, QuestionText: unescapeGIFT(stem), Points: 1, Tags: category}
+		answers := strings.TrimSpace(block[open+1 : closeAt])
+
+		if general := strings.Index(answers, "####"); general >= 0 {
+			item.Explanation = unescapeGIFT(strings.TrimSpace(answers[general+4:]))
+			answers = strings.TrimSpace(answers[:general])
+		}
+
+		var itemErrors []RowError
+		switch strings.ToUpper(answers) {
+		case "":
+			item.Type = TypeEssay
+			item.MaxScore = 10
+		case "T", "TRUE":
+			item.Type = TypeMultipleChoice
+			item.Options = []string{"True", "False"}
+			item.CorrectAnswer = 0
+		case "F", "FALSE":
+			item.Type = TypeMultipleChoice
+			item.Options = []string{"True", "False"}
+			item.CorrectAnswer = 1
+		default:
+			item.Type = TypeMultipleChoice
+			item.CorrectAnswer = -1
+			for _, choice := range splitGIFTChoices(answers) {
+				option := choice[1:]
+				if feedback := indexUnescaped(option, '#', 0); feedback >= 0 {
+					option = option[:feedback]
+				}
+				option = strings.TrimSpace(option)
+				if strings.HasPrefix(option, "%") {
+					if end := strings.Index(option[1:], "%"); end >= 0 {
+						option = strings.TrimSpace(option[end+2:])
+					}
+				}
+				if choice[0] == '=' {
+					if item.CorrectAnswer >= 0 {
+						itemErrors = append(itemErrors, RowError{Row: row, Field: "correct_answer", Message: "more than one correct answer"})
+					}
+					item.CorrectAnswer = len(item.Options)
+				}
+				item.Options = append(item.Options, unescapeGIFT(option))
+			}
+		}
+
+		if itemErrors = append(itemErrors, item.check()...); len(itemErrors) > 0 {
+			rowErrors = append(rowErrors, itemErrors...)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, rowErrors
+}
+
+// splitGIFTBlocks splits on blank lines and drops // comment lines.
+func splitGIFTBlocks(text string) []string {
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+	var blocks []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, strings.TrimSpace(strings.Join(current, "\n")))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "//"):
+			continue
+		case trimmed == "":
+			flush()
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// splitGIFTChoices splits "=a ~b ~c" on unescaped = and ~ markers.
+func splitGIFTChoices(answers string) []string {
+	var choices []string
+	start := -1
+	for i := 0; i < len(answers); i++ {
+		if answers[i] == '\\' {
+			i++
+			continue
+		}
+		if answers[i] == '=' || answers[i] == '~' {
+			if start >= 0 {
+				choices = append(choices, answers[start:i])
+			}
+			start = i
+		}
+	}
+	if start >= 0 {
+		choices = append(choices, answers[start:])
+	}
+	return choices
+}
+
+func indexUnescaped(s string, ch byte, from int) int {
+	if from < 0 {
+		return -1
+	}
+	for i := from; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == ch {
+			return i
+		}
+	}
+	return -1
+}
+
+func stripGIFTFormat(stem string) string {
+	stem = strings.TrimSpace(stem)
+	if strings.HasPrefix(stem, "[") {
+		if end := strings.Index(stem, "]"); end >= 0 {
+			return stem[end+1:]
+		}
+	}
+	return stem
+}
+
+func unescapeGIFT(s string) string {
+	replacer := strings.NewReplacer(`\:`, ":", `\=`, "=", `\~`, "~", `\#`, "#", `\{`, "{", `\}`, "}", `\n`, "\n")
+	return strings.TrimSpace(replacer.Replace(s))
+}
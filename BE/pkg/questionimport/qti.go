@@ -0,0 +1,182 @@
+package questionimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type qtiAssessmentItem struct {
+	XMLName    xml.Name `xml:"assessmentItem"`
+	Identifier string   `xml:"identifier,attr"`
+	Title      string   `xml:"title,attr"`
+	Responses  []struct {
+		Identifier string   `xml:"identifier,attr"`
+		Values     []string `xml:"correctResponse>value"`
+	} `xml:"responseDeclaration"`
+	Body struct {
+		Inner    string          `xml:",innerxml"`
+		Choices  []qtiChoice     `xml:"choiceInteraction"`
+		Extended []qtiExtendText `xml:"extendedTextInteraction"`
+	} `xml:"itemBody"`
+}
+
+type qtiChoice struct {
+	ResponseIdentifier string  `xml:"responseIdentifier,attr"`
+	Prompt             qtiText `xml:"prompt"`
+	SimpleChoices      []struct {
+		Identifier string `xml:"identifier,attr"`
+		Inner      string `xml:",innerxml"`
+	} `xml:"simpleChoice"`
+}
+
+type qtiExtendText struct {
+	Prompt qtiText `xml:"prompt"`
+}
+
+type qtiText struct {
+	Inner string `xml:",innerxml"`
+}
+
+var (
+	qtiInteraction = regexp.MustCompile(`(?s)<(choiceInteraction|extendedTextInteraction)\b.*?</(choiceInteraction|extendedTextInteraction)>`)
+	xmlTag         = regexp.MustCompile(`(?s)<[^>]+>`)
+	whitespace     = regexp.MustCompile(`[ \t]+`)
+)
+
+// parseQTI reads IMS QTI 2.1 assessment items with a single choiceInteraction or an
+// extendedTextInteraction (essay). Both a single item XML file and a zipped content
+// package with one item per file are accepted.
+func parseQTI(data []byte) ([]Item, []RowError, error) {
+	if bytes.HasPrefix(data, []byte("PK")) {
+		return parseQTIPackage(data)
+	}
+	item, rowErrors := parseQTIItem(data, 1)
+	if item == nil {
+		return nil, rowErrors, nil
+	}
+	return []Item{*item}, rowErrors, nil
+}
+
+func parseQTIPackage(data []byte) ([]Item, []RowError, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, errors.New("file is not a valid QTI package")
+	}
+	if err := checkZipSize(archive); err != nil {
+		return nil, nil, err
+	}
+
+	xmlFiles := make([]*zip.File, 0, len(archive.File))
+	for _, f := range archive.File {
+		if strings.HasSuffix(strings.ToLower(f.Name), ".xml") && !strings.HasSuffix(f.Name, "imsmanifest.xml") {
+			xmlFiles = append(xmlFiles, f)
+		}
+	}
+	sort.Slice(xmlFiles, func(i, j int) bool { return xmlFiles[i].Name < xmlFiles[j].Name })
+
+	var items []Item
+	var rowErrors []RowError
+	row := 0
+	for _, f := range xmlFiles {
+		rc, err := openZipEntry(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !bytes.Contains(content, []byte("<assessmentItem")) {
+			continue
+		}
+		row++
+		item, itemErrors := parseQTIItem(content, row)
+		rowErrors = append(rowErrors, itemErrors...)
+		if item != nil {
+			items = append(items, *item)
+		}
+	}
+	if row == 0 {
+		return nil, []RowError{{Row: 1, Message: "package contains no assessmentItem"}}, nil
+	}
+	return items, rowErrors, nil
+}
+
+func parseQTIItem(data []byte, row int) (*Item, []RowError) {
+	var qti qtiAssessmentItem
+	if err := xml.Unmarshal(data, &qti); err != nil {
+		return nil, []RowError{{Row: row, Message: "invalid assessmentItem XML: " + err.Error()}}
+	}
+
+	stem := plainText(qtiInteraction.ReplaceAllString(qti.Body.Inner, ""))
+	item := &Item{Row: row, Points: 1}
+
+	switch {
+	case len(qti.Body.Choices) == 1:
+		choice := qti.Body.Choices[0]
+		item.Type = TypeMultipleChoice
+		item.QuestionText = joinText(stem, plainText(choice.Prompt.Inner))
+
+		correct := map[string]bool{}
+		for _, response := range qti.Responses {
+			if response.Identifier == choice.ResponseIdentifier {
+				for _, v := range response.Values {
+					correct[strings.TrimSpace(v)] = true
+				}
+			}
+		}
+		if len(correct) != 1 {
+			return nil, []RowError{{Row: row, Field: "correct_answer", Message: "exactly one correct response is required"}}
+		}
+
+		item.CorrectAnswer = -1
+		for i, simple := range choice.SimpleChoices {
+			item.Options = append(item.Options, plainText(simple.Inner))
+			if correct[simple.Identifier] {
+				item.CorrectAnswer = i
+			}
+		}
+	case len(qti.Body.Extended) == 1 && len(qti.Body.Choices) == 0:
+		item.Type = TypeEssay
+		item.MaxScore = 10
+		item.QuestionText = joinText(stem, plainText(qti.Body.Extended[0].Prompt.Inner))
+	default:
+		return nil, []RowError{{Row: row, Message: "only items with one choiceInteraction or extendedTextInteraction are supported"}}
+	}
+
+	if rowErrors := item.check(); len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+	return item, nil
+}
+
+func plainText(markup string) string {
+	text := html.UnescapeString(xmlTag.ReplaceAllString(markup, " "))
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(whitespace.ReplaceAllString(line, " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func joinText(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,110 @@
+// Package questionimport reads question files written outside the app (spreadsheets,
+// Moodle GIFT, IMS QTI 2.1) into a neutral Item list. Parsers never stop at the first
+// bad row: every problem is reported as a RowError so the whole file can be fixed
+// in one go.
+package questionimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatGIFT = "gift"
+	FormatQTI  = "qti"
+
+	TypeMultipleChoice = "multiple_choice"
+	TypeEssay          = "essay"
+)
+
+// Item is one question read from a file. Row is the 1-based row (CSV/XLSX, header
+// included) or item number (GIFT/QTI) used in error reports.
+type Item struct {
+	Row           int
+	Type          string
+	QuestionText  string
+	Options       []string
+	CorrectAnswer int
+	Explanation   string
+	Points        float64
+	Penalty       float64
+	MaxScore      float64
+	Tags          string
+	Difficulty    string
+	Topic         string
+	Section       string
+}
+
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e RowError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("row %d, %s: %s", e.Row, e.Field, e.Message)
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// DetectFormat guesses the format from a file name.
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".xlsx":
+		return FormatXLSX
+	case ".gift", ".txt":
+		return FormatGIFT
+	case ".xml", ".zip":
+		return FormatQTI
+	}
+	return ""
+}
+
+// Parse reads every item in r. The returned error is only set when the file as a
+// whole cannot be read; problems with single items are returned as RowErrors.
+func Parse(format string, r io.Reader) ([]Item, []RowError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch format {
+	case FormatCSV:
+		return parseCSV(data)
+	case FormatXLSX:
+		return parseXLSX(data)
+	case FormatGIFT:
+		items, rowErrors := parseGIFT(string(data))
+		return items, rowErrors, nil
+	case FormatQTI:
+		return parseQTI(data)
+	}
+	return nil, nil, errors.New("unsupported import format")
+}
+
+// check applies the rules shared by every format.
+func (item *Item) check() []RowError {
+	var rowErrors []RowError
+	if strings.TrimSpace(item.QuestionText) == "" {
+		rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "question_text", Message: "is empty"})
+	}
+	if item.Type == TypeMultipleChoice {
+		if len(item.Options) < 2 {
+			rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "options", Message: "at least two options are required"})
+		} else if item.CorrectAnswer < 0 || item.CorrectAnswer >= len(item.Options) {
+			rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "correct_answer", Message: "does not match any option"})
+		}
+	}
+	if item.Type == TypeEssay && item.MaxScore <= 0 {
+		rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "max_score", Message: "is required for essay questions"})
+	}
+	return rowErrors
+}
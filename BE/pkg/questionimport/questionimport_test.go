@@ -0,0 +1,350 @@
+package questionimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// zipFiles builds an in-memory zip archive from file names and contents.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// parseCase is the outcome every parser test checks: the items read and the rows
+// and fields of the errors reported.
+type parseCase struct {
+	name       string
+	input      string
+	wantItems  []Item
+	wantErrors []RowError
+}
+
+func checkParse(t *testing.T, tt parseCase, items []Item, rowErrors []RowError, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(items, tt.wantItems) {
+		t.Errorf("items = %+v\nwant    %+v", items, tt.wantItems)
+	}
+	// Messages are for people; only rows and fields are compared.
+	got := make([]RowError, len(rowErrors))
+	for i, e := range rowErrors {
+		got[i] = RowError{Row: e.Row, Field: e.Field}
+	}
+	if len(got) != len(tt.wantErrors) || (len(got) > 0 && !reflect.DeepEqual(got, tt.wantErrors)) {
+		t.Errorf("errors = %+v, want %+v", rowErrors, tt.wantErrors)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]string{
+		"questions.CSV":  FormatCSV,
+		"bank.xlsx":      FormatXLSX,
+		"moodle.gift":    FormatGIFT,
+		"export.txt":     FormatGIFT,
+		"item.xml":       FormatQTI,
+		"package.zip":    FormatQTI,
+		"questions.xls":  "",
+		"no-extension":   "",
+		"archive.tar.gz": "",
+	}
+	for filename, want := range tests {
+		if got := DetectFormat(filename); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestParseAnswerKey(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int
+		wantOK bool
+	}{
+		{"A", 0, true},
+		{"b", 1, true},
+		{" E ", 4, true},
+		{"3", 2, true},
+		{"0", 0, false},
+		{"", 0, false},
+		{"AB", 0, false},
+		{"-1", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseAnswerKey(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseAnswerKey(%q) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []parseCase{
+		{
+			name: "multiple choice and essay",
+			input: "\ufeffQuestion Text,Option A,Option B,Option C,Correct Answer,Type,Points,Max Score,Tags\n" +
+				"2+2?,3,4,5,B,,2,,math\n" +
+				"Explain gravity,,,,,essay,,10,physics\n",
+			wantItems: []Item{
+				{Row: 2, Type: TypeMultipleChoice, QuestionText: "2+2?", Options: []string{"3", "4", "5"}, CorrectAnswer: 1, Points: 2, Tags: "math"},
+				{Row: 3, Type: TypeEssay, QuestionText: "Explain gravity", Points: 1, MaxScore: 10, Tags: "physics"},
+			},
+		},
+		{
+			name: "numeric key, decimal comma and blank rows",
+			input: "question_text,option_1,option_2,correct_answer,penalty\n" +
+				",,,,\n" +
+				"Capital of France?,Paris,Rome,1,\"0,5\"\n",
+			wantItems: []Item{
+				{Row: 3, Type: TypeMultipleChoice, QuestionText: "Capital of France?", Options: []string{"Paris", "Rome"}, CorrectAnswer: 0, Points: 1, Penalty: 0.5},
+			},
+		},
+		{
+			name: "every bad row is reported",
+			input: "question_text,option_a,option_b,correct_answer,type,points,max_score\n" +
+				",x,y,A,,,\n" +
+				"Only one option,x,,A,,,\n" +
+				"Key out of range,x,y,D,,,\n" +
+				"Missing key,x,y,,,,\n" +
+				"Bad type,x,y,A,true_false,,\n" +
+				"Bad points,x,y,A,,-1,\n" +
+				"Essay without max,,,,essay,,\n",
+			wantErrors: []RowError{
+				{Row: 2, Field: "question_text"},
+				{Row: 3, Field: "options"},
+				{Row: 4, Field: "correct_answer"},
+				{Row: 5, Field: "correct_answer"},
+				{Row: 6, Field: "type"},
+				{Row: 7, Field: "points"},
+				{Row: 8, Field: "max_score"},
+			},
+		},
+		{
+			name:       "missing question_text column",
+			input:      "text,option_a\nhello,x\n",
+			wantErrors: []RowError{{Row: 1, Field: "question_text"}},
+		},
+		{
+			name:       "empty file",
+			input:      "",
+			wantErrors: []RowError{{Row: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, rowErrors, err := Parse(FormatCSV, strings.NewReader(tt.input))
+			checkParse(t, tt, items, rowErrors, err)
+		})
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	workbook := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Questions" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/questions.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>question_text</t></si><si><t>option_a</t></si><si><t>option_b</t></si>` +
+			`<si><t>correct_answer</t></si><si><r><t>Largest </t></r><r><t>planet?</t></r></si><si><t>Jupiter</t></si></sst>`,
+		"xl/worksheets/questions.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>4</v></c><c r="B3" t="inlineStr"><is><t>Mars</t></is></c><c r="C3" t="s"><v>5</v></c><c r="D3"><v>2</v></c></row>` +
+			`<row r="4"><c r="A4" t="inlineStr"><is><t>No options</t></is></c><c r="D4" t="inlineStr"><is><t>A</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+
+	items, rowErrors, err := Parse(FormatXLSX, bytes.NewReader(zipFiles(t, workbook)))
+	checkParse(t, parseCase{
+		wantItems: []Item{
+			{Row: 3, Type: TypeMultipleChoice, QuestionText: "Largest planet?", Options: []string{"Mars", "Jupiter"}, CorrectAnswer: 1, Points: 1},
+		},
+		wantErrors: []RowError{{Row: 4, Field: "options"}},
+	}, items, rowErrors, err)
+
+	if _, _, err := Parse(FormatXLSX, strings.NewReader("not a zip")); err == nil {
+		t.Errorf("expected an error for a file that is not a workbook")
+	}
+	if _, _, err := Parse(FormatXLSX, bytes.NewReader(zipFiles(t, map[string]string{"docProps/app.xml": "<x/>"}))); err == nil {
+		t.Errorf("expected an error for a workbook without a worksheet")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := map[string]int{"A1": 0, "C12": 2, "Z3": 25, "AA7": 26, "AB1": 27}
+	for ref, want := range tests {
+		if got := columnIndex(ref); got != want {
+			t.Errorf("columnIndex(%q) = %d, want %d", ref, got, want)
+		}
+	}
+}
+
+func TestParseGIFT(t *testing.T) {
+	tests := []parseCase{
+		{
+			name: "question kinds",
+			input: "// a comment\n" +
+				"$CATEGORY: $course$/Science/Planets\n\n" +
+				"::Q1:: Which planet is red? {=Mars#Right! ~Venus ~%50%Earth ####Iron oxide.}\n\n" +
+				"The sun is a star. {T}\n\n" +
+				"[html]Describe \\{orbits\\}. {}\n",
+			wantItems: []Item{
+				{Row: 2, Type: TypeMultipleChoice, QuestionText: "Which planet is red?", Options: []string{"Mars", "Venus", "Earth"}, CorrectAnswer: 0, Explanation: "Iron oxide.", Points: 1, Tags: "Planets"},
+				{Row: 3, Type: TypeMultipleChoice, QuestionText: "The sun is a star.", Options: []string{"True", "False"}, CorrectAnswer: 0, Points: 1, Tags: "Planets"},
+				{Row: 4, Type: TypeEssay, QuestionText: "Describe {orbits}.", Points: 1, MaxScore: 10, Tags: "Planets"},
+			},
+		},
+		{
+			name:  "text after the answer block and false",
+			input: "Water boils at {F} 50 degrees.\r\n",
+			wantItems: []Item{
+				{Row: 1, Type: TypeMultipleChoice, QuestionText: "Water boils at 50 degrees.", Options: []string{"True", "False"}, CorrectAnswer: 1, Points: 1},
+			},
+		},
+		{
+			name: "problems are reported per block",
+			input: "No answers here\n\n" +
+				"Two right {=a =b ~c}\n\n" +
+				"None right {~a ~b}\n",
+			wantErrors: []RowError{
+				{Row: 1},
+				{Row: 2, Field: "correct_answer"},
+				{Row: 3, Field: "correct_answer"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, rowErrors, err := Parse(FormatGIFT, strings.NewReader(tt.input))
+			checkParse(t, tt, items, rowErrors, err)
+		})
+	}
+}
+
+const qtiChoiceItem = `<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1" identifier="q1" title="Capitals">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="identifier">
+    <correctResponse><value>B</value></correctResponse>
+  </responseDeclaration>
+  <itemBody>
+    <p>Look at the map.</p>
+    <choiceInteraction responseIdentifier="RESPONSE" shuffle="false" maxChoices="1">
+      <prompt>What is the capital of <b>Italy</b>?</prompt>
+      <simpleChoice identifier="A">Paris</simpleChoice>
+      <simpleChoice identifier="B">Rome &amp; Lazio</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+</assessmentItem>`
+
+const qtiEssayItem = `<assessmentItem identifier="q2">
+  <itemBody>
+    <extendedTextInteraction responseIdentifier="RESPONSE"><prompt>Explain photosynthesis.</prompt></extendedTextInteraction>
+  </itemBody>
+</assessmentItem>`
+
+func TestParseQTI(t *testing.T) {
+	choice := Item{Row: 1, Type: TypeMultipleChoice, QuestionText: "Look at the map.\nWhat is the capital of Italy ?", Options: []string{"Paris", "Rome & Lazio"}, CorrectAnswer: 1, Points: 1}
+	essay := Item{Row: 2, Type: TypeEssay, QuestionText: "Explain photosynthesis.", Points: 1, MaxScore: 10}
+
+	tests := []parseCase{
+		{
+			name:      "single choice item",
+			input:     qtiChoiceItem,
+			wantItems: []Item{choice},
+		},
+		{
+			name: "package in file name order, manifest and other files skipped",
+			input: string(zipFiles(t, map[string]string{
+				"imsmanifest.xml": `<manifest><assessmentItem/></manifest>`,
+				"items/b.xml":     qtiEssayItem,
+				"items/a.xml":     qtiChoiceItem,
+				"items/style.xml": `<style/>`,
+			})),
+			wantItems: []Item{choice, essay},
+		},
+		{
+			name:       "no correct response",
+			input:      strings.Replace(qtiChoiceItem, "<value>B</value>", "", 1),
+			wantErrors: []RowError{{Row: 1, Field: "correct_answer"}},
+		},
+		{
+			name:       "unsupported interaction",
+			input:      `<assessmentItem identifier="q3"><itemBody><p>Drag things.</p></itemBody></assessmentItem>`,
+			wantErrors: []RowError{{Row: 1}},
+		},
+		{
+			name:       "invalid xml",
+			input:      `<assessmentItem`,
+			wantErrors: []RowError{{Row: 1}},
+		},
+		{
+			name:       "package without items",
+			input:      string(zipFiles(t, map[string]string{"imsmanifest.xml": `<manifest/>`})),
+			wantErrors: []RowError{{Row: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, rowErrors, err := Parse(FormatQTI, strings.NewReader(tt.input))
+			checkParse(t, tt, items, rowErrors, err)
+		})
+	}
+}
+
+// bombZip builds an archive whose headers claim each file unpacks to size bytes.
+func bombZip(t *testing.T, names []string, size uint64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		if _, err := w.CreateRaw(&zip.FileHeader{Name: name, Method: zip.Deflate, UncompressedSize64: size}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseRejectsOversizedArchives(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{"xlsx sheet over the entry limit", FormatXLSX, bombZip(t, []string{"xl/worksheets/sheet1.xml"}, maxZipEntrySize+1)},
+		{"qti package over the total limit", FormatQTI, bombZip(t, []string{"a.xml", "b.xml", "c.xml", "d.xml", "e.xml"}, maxZipEntrySize)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse(tt.format, bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), "too large") {
+				t.Errorf("got %v, want a too large error", err)
+			}
+		})
+	}
+}
+
+func TestParseUnsupportedFormat(t *testing.T) {
+	if _, _, err := Parse("docx", strings.NewReader("")); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
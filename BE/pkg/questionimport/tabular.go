@@ -0,0 +1,146 @@
+package questionimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheet layout (CSV and the first XLSX sheet). The first row is a header;
+// column names are case-insensitive and only question_text is required:
+//
+//	question_text, option_a..option_e (any column starting with "option"),
+//	correct_answer (letter A-E or 1-based number), explanation, type
+//	(multiple_choice|essay), points, penalty, max_score, tags, difficulty, topic,
+//	section
+func parseCSV(data []byte) ([]Item, []RowError, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	items, rowErrors := parseRows(rows)
+	return items, rowErrors, nil
+}
+
+func parseRows(rows [][]string) ([]Item, []RowError) {
+	if len(rows) == 0 {
+		return nil, []RowError{{Row: 1, Message: "file is empty"}}
+	}
+
+	columns := map[string]int{}
+	var optionColumns []int
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, " ", "_")
+		if strings.HasPrefix(name, "option") {
+			optionColumns = append(optionColumns, i)
+			continue
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["question_text"]; !ok {
+		return nil, []RowError{{Row: 1, Field: "question_text", Message: "header column is missing"}}
+	}
+
+	var items []Item
+	var rowErrors []RowError
+	for r, row := range rows[1:] {
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if isBlankRow(row) {
+			continue
+		}
+
+		item := Item{
+			Row:          r + 2,
+			Type:         strings.ToLower(cell("type")),
+			QuestionText: cell("question_text"),
+			Explanation:  cell("explanation"),
+			Tags:         cell("tags"),
+			Difficulty:   cell("difficulty"),
+			Topic:        cell("topic"),
+			Section:      cell("section"),
+		}
+		if item.Type == "" {
+			item.Type = TypeMultipleChoice
+		}
+		if item.Type != TypeMultipleChoice && item.Type != TypeEssay {
+			rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "type", Message: "must be multiple_choice or essay"})
+			continue
+		}
+
+		for _, i := range optionColumns {
+			if i < len(row) && strings.TrimSpace(row[i]) != "" {
+				item.Options = append(item.Options, strings.TrimSpace(row[i]))
+			}
+		}
+
+		var numberErrors []RowError
+		item.Points = parseNumber(cell("points"), 1, item.Row, "points", &numberErrors)
+		item.Penalty = parseNumber(cell("penalty"), 0, item.Row, "penalty", &numberErrors)
+		item.MaxScore = parseNumber(cell("max_score"), 0, item.Row, "max_score", &numberErrors)
+		rowErrors = append(rowErrors, numberErrors...)
+
+		if item.Type == TypeMultipleChoice {
+			correct, ok := parseAnswerKey(cell("correct_answer"))
+			if !ok {
+				rowErrors = append(rowErrors, RowError{Row: item.Row, Field: "correct_answer", Message: "must be a letter (A-E) or an option number"})
+				continue
+			}
+			item.CorrectAnswer = correct
+		}
+
+		if itemErrors := item.check(); len(itemErrors) > 0 || len(numberErrors) > 0 {
+			rowErrors = append(rowErrors, itemErrors...)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, rowErrors
+}
+
+// parseAnswerKey accepts "B", "b" or "2" for the second option.
+func parseAnswerKey(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 1 {
+		letter := strings.ToUpper(value)[0]
+		if letter >= 'A' && letter <= 'Z' {
+			return int(letter - 'A'), true
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+func parseNumber(value string, fallback float64, row int, field string, rowErrors *[]RowError) float64 {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || n < 0 {
+		*rowErrors = append(*rowErrors, RowError{Row: row, Field: field, Message: "must be a non-negative number"})
+		return fallback
+	}
+	return n
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package questionimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// parseXLSX reads the first worksheet of an Office Open XML workbook with the
+// standard library only. It understands shared strings, inline strings and plain
+// values, which covers sheets saved by Excel, LibreOffice and Google Sheets.
+func parseXLSX(data []byte) ([]Item, []RowError, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, errors.New("file is not a valid xlsx workbook")
+	}
+	if err := checkZipSize(archive); err != nil {
+		return nil, nil, err
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sharedStrings, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := readSheet(files[sheetPath], sharedStrings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, rowErrors := parseRows(rows)
+	return items, rowErrors, nil
+}
+
+// Limits on what an uploaded archive may unpack to, so a small zip cannot expand
+// into gigabytes of memory.
+const (
+	maxZipEntrySize = 32 << 20
+	maxZipSize      = 128 << 20
+)
+
+// checkZipSize refuses archives whose files add up to more than maxZipSize.
+func checkZipSize(archive *zip.Reader) error {
+	var total uint64
+	for _, f := range archive.File {
+		total += f.UncompressedSize64
+		if total > maxZipSize {
+			return errors.New("archive is too large to import")
+		}
+	}
+	return nil
+}
+
+// openZipEntry opens a file of an uploaded archive, refusing entries that unpack to
+// more than maxZipEntrySize. Reads are capped as well in case the header lies.
+func openZipEntry(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > maxZipEntrySize {
+		return nil, errors.New(f.Name + " is too large to import")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, maxZipEntrySize), rc}, nil
+}
+
+func readZipXML(f *zip.File, v interface{}) error {
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := readZipXML(f, &sst); err != nil {
+		return nil, errors.New("could not read xlsx shared strings")
+	}
+	strs := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		if len(si.Runs) == 0 {
+			strs[i] = si.Text
+			continue
+		}
+		var b strings.Builder
+		for _, run := range si.Runs {
+			b.WriteString(run.Text)
+		}
+		strs[i] = b.String()
+	}
+	return strs, nil
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	workbook, rels := files["xl/workbook.xml"], files["xl/_rels/workbook.xml.rels"]
+	if workbook != nil && rels != nil {
+		var wb struct {
+			Sheets []struct {
+				RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+			} `xml:"sheets>sheet"`
+		}
+		var rel struct {
+			Items []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if readZipXML(workbook, &wb) == nil && readZipXML(rels, &rel) == nil && len(wb.Sheets) > 0 {
+			for _, r := range rel.Items {
+				if r.ID != wb.Sheets[0].RelID {
+					continue
+				}
+				target := strings.TrimPrefix(r.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				if files[target] != nil {
+					return target, nil
+				}
+			}
+		}
+	}
+	if files["xl/worksheets/sheet1.xml"] != nil {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	return "", errors.New("xlsx workbook has no worksheet")
+}
+
+func readSheet(f *zip.File, sharedStrings []string) ([][]string, error) {
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var sheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&sheet); err != nil && err != io.EOF {
+		return nil, errors.New("could not read xlsx worksheet")
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// Keep spreadsheet row numbers so errors point at the right line even
+		// when empty rows were skipped in the XML.
+		for row.Number > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			switch cell.Type {
+			case "s":
+				if idx, err := strconv.Atoi(cell.Value); err == nil && idx >= 0 && idx < len(sharedStrings) {
+					values[col] = sharedStrings[idx]
+				}
+			case "inlineStr":
+				values[col] = cell.Inline
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// columnIndex turns a cell reference such as "C12" into the zero-based column 2.
+func columnIndex(ref string) int {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,70 @@
+// Package upload stores user files under the public uploads directory that the
+// server exposes at /uploads.
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Dir is where files are written on disk.
+	Dir = "./public/uploads"
+	// URLPrefix is the public path the files are served from.
+	URLPrefix = "/uploads/"
+)
+
+// ErrNotUpload is returned by Open for URLs that do not point into the uploads directory.
+var ErrNotUpload = errors.New("not an uploaded file")
+
+// Save writes r to a new file that keeps the extension of filename and returns its
+// public URL.
+func Save(filename string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(Dir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s%s", uuid.New().String(), filepath.Ext(filename))
+
+	dst, err := os.Create(filepath.Join(Dir, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return URLPrefix + name, nil
+}
+
+// Open opens the file behind a URL returned by Save. Absolute URLs are accepted as
+// long as their path starts with URLPrefix.
+func Open(url string) (*os.File, error) {
+	name, ok := FileName(url)
+	if !ok {
+		return nil, ErrNotUpload
+	}
+	return os.Open(filepath.Join(Dir, name))
+}
+
+// FileName returns the stored file name of an upload URL.
+func FileName(url string) (string, bool) {
+	i := strings.Index(url, URLPrefix)
+	if i < 0 {
+		return "", false
+	}
+	name := url[i+len(URLPrefix):]
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `\?#`) {
+		return "", false
+	}
+	return name, true
+}
@@ -1,4 +1,4 @@
-import { useState, useEffect } from "react";
+import { useState, useEffect, useRef } from "react";
 import { useParams, useNavigate, Link } from "react-router-dom";
 import axios from "@/api/axiosConfig";
 import { useAuth } from "@/context/UseAuth";
@@ -49,6 +49,7 @@ const OrderPage = () => {
     const [item, setItem] = useState(null);
     const [isLoading, setIsLoading] = useState(true);
     const [isSubmitting, setIsSubmitting] = useState(false);
+    const orderKeyRef = useRef(crypto.randomUUID());
     const [error, setError] = useState(null);
     const [selectedPlan, setSelectedPlan] = useState(premiumPlans[0]);
 
@@ -107,7 +108,9 @@ const OrderPage = () => {
                 amount: selectedPlan.price,
             };
 
-            await axios.post("/orders", payload);
+            await axios.post("/orders", payload, {
+                headers: { "Idempotency-Key": orderKeyRef.current },
+            });
             toast.success("Order Dibuat!", {
                 description: "Silakan lanjutkan ke pembayaran.",
             });
@@ -115,6 +118,8 @@ const OrderPage = () => {
             navigate("/my-orders");
 
         } catch (err) {
+            // The server answered, so a new attempt is a new request.
+            if (err.response) orderKeyRef.current = crypto.randomUUID();
             toast.error("Gagal Membuat Order", {
                 description: err.response?.data?.error || "Terjadi kesalahan.",
             });
@@ -2,10 +2,11 @@ import { useState, useEffect } from "react";
 import { useParams, Link, useNavigate } from "react-router-dom";
 import axios from "@/api/axiosConfig";
 import { useAuth } from "@/context/UseAuth";
-import { Loader2, Lock, ArrowLeft, User, Clock, PlayCircle, Mic, FileText } from "lucide-react";
+import { Loader2, Lock, ArrowLeft, User, Clock, PlayCircle, FileText, Paperclip, ClipboardList, CheckCircle2, Award } from "lucide-react";
 import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
 import { Badge } from "@/components/ui/badge";
+import { Progress } from "@/components/ui/progress";
 import { toast } from "sonner";
 
 const PremiumClassDetail = () => {
@@ -13,6 +14,10 @@ const PremiumClassDetail = () => {
     const { user, isLoggedIn, isLoading: isAuthLoading } = useAuth();
     const navigate = useNavigate();
     const [cls, setCls] = useState(null);
+    const [modules, setModules] = useState([]);
+    const [enrollment, setEnrollment] = useState(null);
+    const [isEnrolling, setIsEnrolling] = useState(false);
+    const [lesson, setLesson] = useState(null);
     const [isLoading, setIsLoading] = useState(true);
 
     useEffect(() => {
@@ -26,8 +31,14 @@ const PremiumClassDetail = () => {
             const fetchClass = async () => {
                 setIsLoading(true);
                 try {
-                    const res = await axios.get(`/premium-classes/${classId}`);
-                    setCls(res.data);
+                    const [classRes, modulesRes, enrolledRes] = await Promise.all([
+                        axios.get(`/premium-classes/${classId}`),
+                        axios.get(`/premium-classes/${classId}/modules`),
+                        axios.get(`/premium-classes/enrolled`),
+                    ]);
+                    setCls(classRes.data);
+                    setModules(modulesRes.data || []);
+                    setEnrollment((enrolledRes.data || []).find((e) => e.class_id === classId) || null);
                 } catch (err) {
                     console.error("Gagal fetch kelas:", err);
                     toast.error("Gagal memuat kelas", { description: "Kelas tidak ditemukan." });
@@ -40,6 +51,76 @@ const PremiumClassDetail = () => {
         }
     }, [classId, isLoggedIn, isAuthLoading, navigate]);
 
+    const handleEnroll = async () => {
+        setIsEnrolling(true);
+        try {
+            const res = await axios.post(`/premium-classes/${classId}/enroll`);
+            setEnrollment(res.data);
+            toast.success("Berhasil mengikuti kelas");
+        } catch (err) {
+            toast.error("Tidak dapat mengikuti kelas", {
+                description: err.response?.data?.error || "Silakan coba lagi.",
+            });
+        } finally {
+            setIsEnrolling(false);
+        }
+    };
+
+    const refreshProgress = async () => {
+        try {
+            const res = await axios.get(`/premium-classes/${classId}/progress`);
+            setCls((prev) => ({ ...prev, progress: res.data }));
+        } catch (err) {
+            console.error("Gagal memuat progres:", err);
+        }
+    };
+
+    const completeLesson = async (lessonId) => {
+        try {
+            const res = await axios.post(`/premium-classes/${classId}/lessons/${lessonId}/complete`);
+            setLesson((prev) => ({ ...prev, progress: res.data }));
+            if (res.data.completed_at) {
+                toast.success("Materi selesai");
+            } else if (res.data.pending?.includes("practice_test")) {
+                toast.info("Kerjakan latihan untuk menyelesaikan materi ini.");
+            } else if (res.data.pending?.includes("video")) {
+                toast.info("Tonton video hingga selesai untuk menyelesaikan materi ini.");
+            }
+            refreshProgress();
+        } catch (err) {
+            toast.error("Gagal menyimpan progres", { description: err.response?.data?.error });
+        }
+    };
+
+    const downloadCertificate = async () => {
+        try {
+            const res = await axios.get(`/premium-classes/${classId}/certificate.pdf`, { responseType: "blob" });
+            const url = URL.createObjectURL(res.data);
+            const link = document.createElement("a");
+            link.href = url;
+            link.download = `certificate-${classId}.pdf`;
+            link.click();
+            URL.revokeObjectURL(url);
+        } catch (err) {
+            console.error("Gagal mengunduh sertifikat:", err);
+        }
+    };
+
+    const lessonDone = (lessonId) =>
+        cls?.progress?.lessons?.some((p) => p.lesson_id === lessonId && p.completed_at);
+
+    const openLesson = async (lessonId) => {
+        try {
+            const res = await axios.get(`/premium-classes/${classId}/lessons/${lessonId}`);
+            setLesson(res.data);
+            refreshProgress();
+        } catch (err) {
+            toast.error("Materi terkunci", {
+                description: err.response?.data?.error || "Anda belum terdaftar di kelas ini.",
+            });
+        }
+    };
+
     if (isLoading || isAuthLoading) {
         return (
             <div className="flex justify-center items-center min-h-[50vh]">
@@ -48,7 +129,7 @@ const PremiumClassDetail = () => {
         );
     }
 
-    if (!user.is_premium) {
+    if (!user.is_premium && !enrollment) {
         return (
             <div className="flex flex-col items-center justify-center min-h-[70vh] text-center p-4">
                 <Lock className="h-16 w-16 text-destructive mb-4" />
@@ -67,7 +148,7 @@ const PremiumClassDetail = () => {
         );
     }
 
-    if (user.is_premium && cls) {
+    if (cls) {
         return (
             <div className="min-h-screen bg-gradient-page py-12 px-4">
                 <div className="max-w-4xl mx-auto space-y-6">
@@ -101,30 +182,111 @@ const PremiumClassDetail = () => {
                             <div className="border-t pt-6">
                                 <h3 className="text-2xl font-semibold mb-4">Materi Kelas</h3>
 
-                                <div className="space-y-3">
-                                    <div className="flex items-center justify-between p-4 border rounded-lg hover:bg-muted/50">
-                                        <div className="flex items-center gap-3">
-                                            <PlayCircle className="h-6 w-6 text-primary" />
-                                            <span className="font-medium">Video Pembelajaran HD</span>
+                                {cls.progress && (
+                                    <div className="mb-6 space-y-2">
+                                        <div className="flex items-center justify-between text-sm">
+                                            <span className="text-muted-foreground">
+                                                {cls.progress.completed_lessons} dari {cls.progress.total_lessons} materi selesai
+                                                {cls.progress.final_test_id && (cls.progress.final_test_passed ? " · Ujian akhir lulus" : " · Ujian akhir belum lulus")}
+                                            </span>
+                                            <span className="font-semibold">{cls.progress.percent}%</span>
                                         </div>
-                                        <Button size="sm">Tonton</Button>
+                                        <Progress value={cls.progress.percent} />
+                                        {cls.progress.completed && (
+                                            <Button variant="outline" size="sm" onClick={downloadCertificate}>
+                                                <Award className="h-4 w-4 mr-2" />
+                                                Unduh Sertifikat
+                                            </Button>
+                                        )}
                                     </div>
-                                    <div className="flex items-center justify-between p-4 border rounded-lg hover:bg-muted/50">
-                                        <div className="flex items-center gap-3">
-                                            <Mic className="h-6 w-6 text-primary" />
-                                            <span className="font-medium">Live Q&A Mingguan</span>
-                                        </div>
-                                        <Button size="sm">Gabung</Button>
+                                )}
+
+                                {!enrollment && (
+                                    <div className="flex items-center justify-between p-4 mb-4 border rounded-lg bg-muted/30">
+                                        <span className="text-muted-foreground">Ikuti kelas ini untuk membuka seluruh materi.</span>
+                                        <Button onClick={handleEnroll} disabled={isEnrolling}>
+                                            {isEnrolling && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+                                            Ikuti Kelas
+                                        </Button>
                                     </div>
-                                    <div className="flex items-center justify-between p-4 border rounded-lg hover:bg-muted/50">
-                                        <div className="flex items-center gap-3">
-                                            <FileText className="h-6 w-6 text-primary" />
-                                            <span className="font-medium">Studi Kasus</span>
+                                )}
+
+                                {modules.length === 0 && (
+                                    <p className="text-muted-foreground">Materi kelas belum tersedia.</p>
+                                )}
+
+                                <div className="space-y-6">
+                                    {modules.map((module) => (
+                                        <div key={module.id} className="space-y-3">
+                                            <div>
+                                                <h4 className="text-lg font-semibold">{module.title}</h4>
+                                                {module.description && (
+                                                    <p className="text-sm text-muted-foreground">{module.description}</p>
+                                                )}
+                                            </div>
+                                            {module.lessons.map((item) => (
+                                                <div key={item.id} className="flex items-center justify-between p-4 border rounded-lg hover:bg-muted/50">
+                                                    <div className="flex items-center gap-3">
+                                                        {item.has_video
+                                                            ? <PlayCircle className="h-6 w-6 text-primary" />
+                                                            : <FileText className="h-6 w-6 text-primary" />}
+                                                        <span className="font-medium">{item.title}</span>
+                                                        {lessonDone(item.id) && <CheckCircle2 className="h-4 w-4 text-green-600" />}
+                                                        {item.has_practice_test && <Badge variant="outline">Latihan</Badge>}
+                                                    </div>
+                                                    <Button size="sm" onClick={() => openLesson(item.id)} disabled={!enrollment && user.role !== "admin"}>
+                                                        {enrollment || user.role === "admin" ? "Buka" : <Lock className="h-4 w-4" />}
+                                                    </Button>
+                                                </div>
+                                            ))}
                                         </div>
-                                        <Button size="sm">Lihat</Button>
-                                    </div>
+                                    ))}
                                 </div>
 
+                                {lesson && (
+                                    <div className="mt-8 border-t pt-6 space-y-4">
+                                        <h3 className="text-2xl font-semibold">{lesson.title}</h3>
+                                        {lesson.video_url && (
+                                            <Button asChild variant="outline">
+                                                <a href={lesson.video_url} target="_blank" rel="noreferrer">
+                                                    <PlayCircle className="h-4 w-4 mr-2" />
+                                                    Tonton Video
+                                                </a>
+                                            </Button>
+                                        )}
+                                        {lesson.content && (
+                                            <div className="prose max-w-none" dangerouslySetInnerHTML={{ __html: lesson.content }} />
+                                        )}
+                                        {lesson.attachments.length > 0 && (
+                                            <div className="space-y-2">
+                                                {lesson.attachments.map((attachment) => (
+                                                    <a key={attachment.url} href={attachment.url} target="_blank" rel="noreferrer"
+                                                       className="flex items-center gap-2 text-primary hover:underline">
+                                                        <Paperclip className="h-4 w-4" />
+                                                        {attachment.name}
+                                                    </a>
+                                                ))}
+                                            </div>
+                                        )}
+                                        <div className="flex flex-wrap gap-2">
+                                            {lesson.practice_test && (
+                                                <Button asChild>
+                                                    <Link to={`/test/${lesson.practice_test.id}`}>
+                                                        <ClipboardList className="h-4 w-4 mr-2" />
+                                                        Kerjakan Latihan: {lesson.practice_test.title}
+                                                    </Link>
+                                                </Button>
+                                            )}
+                                            {lesson.progress && !lesson.progress.completed_at && (
+                                                <Button variant="outline" onClick={() => completeLesson(lesson.id)}>
+                                                    <CheckCircle2 className="h-4 w-4 mr-2" />
+                                                    Tandai Selesai
+                                                </Button>
+                                            )}
+                                        </div>
+                                    </div>
+                                )}
+
                             </div>
                         </CardContent>
                     </Card>
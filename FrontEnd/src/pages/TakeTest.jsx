@@ -24,6 +24,8 @@ const TakeTest = () => {
     const [isSubmitted, setIsSubmitted] = useState(false);
 
     const timerRef = useRef(null);
+    // Reused on every retry so the server records the submission only once.
+    const submitKeyRef = useRef(crypto.randomUUID());
 
     useEffect(() => {
         if (!isAuthLoading && !isLoggedIn) {
@@ -61,7 +63,6 @@ const TakeTest = () => {
                     ...q,
                     id: String(q.id),
                     options: typeof q.options === "string" ? JSON.parse(q.options) : q.options || [],
-                    correctAnswer: q.correct_answer,
                 }));
                 setQuestions(formattedQuestions);
             } catch (err) {
@@ -136,12 +137,16 @@ const TakeTest = () => {
                 test_id: testId,
                 time_spent: timeSpent,
                 answers: formattedAnswers,
+            }, {
+                headers: { "Idempotency-Key": submitKeyRef.current },
             });
 
             navigate("/result", {
                 state: {
                     testId: test.id,
                     testTitle: test.title,
+                    resultId: response.data.id,
+                    passed: response.data.passed === true && response.data.is_official,
                     score: Math.round(response.data.score),
                     correctAnswers: response.data.correct_answers,
                     totalQuestions: response.data.total_questions,
@@ -152,6 +157,7 @@ const TakeTest = () => {
             });
         } catch (error) {
             console.error("Failed to submit test:", error);
+            if (error.response) submitKeyRef.current = crypto.randomUUID();
             setIsSubmitted(false);
             toast.error("Submit Gagal", {
                 description: "Gagal mengirimkan hasil tes. Coba lagi.",
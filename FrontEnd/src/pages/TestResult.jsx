@@ -5,15 +5,18 @@ import { Button } from "../components/ui/button";
 import { Card, CardContent, CardHeader, CardTitle } from "../components/ui/card";
 import { Badge } from "../components/ui/badge";
 import { Progress } from "../components/ui/progress";
-import { Trophy, Clock, Target, BookOpen, CheckCircle, XCircle, Star, ArrowRight } from "lucide-react";
+import { Trophy, Clock, Target, BookOpen, CheckCircle, XCircle, Star, ArrowRight, Download, Award } from "lucide-react";
 
 const TestResult = () => {
     const location = useLocation();
     const navigate = useNavigate();
     const [recommendedClasses, setRecommendedClasses] = useState([]);
+    const [review, setReview] = useState(null);
     const {
         testId,
         testTitle,
+        resultId,
+        passed,
         score,
         correctAnswers,
         totalQuestions,
@@ -27,6 +30,33 @@ const TestResult = () => {
         }
     }, [location.state, navigate]);
 
+    // Answer keys and explanations only come from the server once the test is submitted.
+    useEffect(() => {
+        if (!resultId) return;
+        const fetchReview = async () => {
+            try {
+                const res = await axios.get(`/test-results/${resultId}`);
+                setReview(res.data);
+            } catch (err) {
+                console.error("Failed to fetch result review:", err);
+            }
+        };
+        fetchReview();
+    }, [resultId]);
+
+    const reviewQuestions = review
+        ? review.questions.map((q) => ({
+              id: String(q.question_id),
+              question_text: q.question_text,
+              options: typeof q.options === "string" ? JSON.parse(q.options || "[]") : q.options || [],
+              correctAnswer: q.correct_answer,
+              explanation: q.explanation,
+          }))
+        : questions;
+    const reviewAnswers = review
+        ? Object.fromEntries(review.questions.map((q) => [String(q.question_id), q.selected_answer]))
+        : answers;
+
     useEffect(() => {
         const fetchRecommendations = async () => {
             try {
@@ -41,6 +71,20 @@ const TestResult = () => {
         }
     }, [score, location.state]);
 
+    const downloadPdf = async (path, filename) => {
+        try {
+            const res = await axios.get(path, { responseType: "blob" });
+            const url = URL.createObjectURL(res.data);
+            const link = document.createElement("a");
+            link.href = url;
+            link.download = filename;
+            link.click();
+            URL.revokeObjectURL(url);
+        } catch (err) {
+            console.error("Failed to download PDF:", err);
+        }
+    };
+
     const formatTime = (seconds) => {
         if (typeof seconds !== "number") return "0:00";
         const minutes = Math.floor(seconds / 60);
@@ -134,8 +178,8 @@ const TestResult = () => {
                         </CardTitle>
                     </CardHeader>
                     <CardContent className="space-y-4">
-                        {questions?.map((question, index) => {
-                            const userAnswerIndex = answers?.[question.id];
+                        {reviewQuestions?.map((question, index) => {
+                            const userAnswerIndex = reviewAnswers?.[question.id];
                             const isCorrect = userAnswerIndex === question.correctAnswer;
 
                             return (
@@ -250,6 +294,20 @@ const TestResult = () => {
                 )}
 
                 {/* Actions */}
+                {resultId && (
+                    <div className="flex flex-col sm:flex-row gap-4 justify-center mb-4">
+                        <Button variant="outline" onClick={() => downloadPdf(`/test-results/${resultId}/report.pdf`, `score-report-${resultId}.pdf`)}>
+                            <Download className="h-4 w-4 mr-2" />
+                            Download Report (PDF)
+                        </Button>
+                        {passed && (
+                            <Button variant="outline" onClick={() => downloadPdf(`/test-results/${resultId}/certificate.pdf`, `certificate-${resultId}.pdf`)}>
+                                <Award className="h-4 w-4 mr-2" />
+                                Download Certificate
+                            </Button>
+                        )}
+                    </div>
+                )}
                 <div className="flex flex-col sm:flex-row gap-4 justify-center">
                     <Button variant="outline" asChild>
                         <Link to="/tests">Take Another Test</Link>
@@ -25,7 +25,7 @@ const AdminDashboard = () => {
                     ordersRes
                 ] = await Promise.all([
                     axios.get("/auth/users"),
-                    axios.get("/tests"),
+                    axios.get("/tests/manage"),
                     axios.get("/premium-classes"),
                     axios.get("/orders"),
                 ]);
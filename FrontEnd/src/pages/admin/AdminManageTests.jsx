@@ -15,7 +15,7 @@ const AdminManageTests = () => {
     const fetchTests = async () => {
         setIsLoading(true);
         try {
-            const response = await axios.get("/tests");
+            const response = await axios.get("/tests/manage");
             setTests(response.data || []);
         } catch (err) {
             setError("Gagal mengambil data tes.");